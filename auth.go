@@ -0,0 +1,32 @@
+package main
+
+import (
+	"cncf-language-stats/stats"
+	"errors"
+	"fmt"
+	"golang.org/x/oauth2"
+	"os"
+	"strconv"
+)
+
+// newAppTokenSource Authenticates as the installation of the GitHub App from the -app-* flags
+func newAppTokenSource(appID, installationID, keyPath string) (oauth2.TokenSource, error) {
+	id, err := strconv.ParseInt(appID, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid -app-id %q, must be the numeric app ID", appID)
+	}
+	var installation int64
+	if installationID != "" {
+		if installation, err = strconv.ParseInt(installationID, 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid -app-installation-id %q, must be numeric", installationID)
+		}
+	}
+	if keyPath == "" {
+		return nil, errors.New("-app-id needs the app's -app-private-key")
+	}
+	key, err := os.ReadFile(keyPath)
+	if err != nil {
+		return nil, err
+	}
+	return stats.NewAppTokenSource(id, installation, key, nil)
+}
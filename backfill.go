@@ -0,0 +1,136 @@
+package main
+
+import (
+	"cncf-language-stats/stats"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// backfillIntervals The months between the dates of every -interval
+var backfillIntervals = map[string]int{"monthly": 1, "quarterly": 3, "yearly": 12}
+
+// runBackfill Counts the lines of the projects in clones of their repos as they were at past dates and saves
+// every group's result under that date, seeding the trend with history from before the tool ran
+func runBackfill(args []string) error {
+	fs := flag.NewFlagSet("backfill", flag.ExitOnError)
+	reposPath := fs.String("repos", envOr("CNCF_STATS_REPOS", "repos.yaml"), "Path of the repos.yaml listing the projects, or set CNCF_STATS_REPOS")
+	outDir := fs.String("out", envOr("CNCF_STATS_OUT", "results"), "Directory the <date>-<group>.json result files are saved in, or set CNCF_STATS_OUT")
+	years := fs.Int("years", 5, "Years of history to backfill")
+	interval := fs.String("interval", "quarterly", "Spacing of the backfilled dates, monthly, quarterly or yearly")
+	groupList := fs.String("groups", "graduated,incubating,sandbox", "Comma separated groups to backfill")
+	cloneCache := fs.String("clone-cache", ".backfill-clones", "Directory keeping the history clones between the dates and runs")
+	cloneExcludes := fs.String("clone-exclude", strings.Join(stats.DefaultCloneExcludes, ","), "Comma separated globs of vendored and generated files left out")
+	workers := fs.Int("workers", 4, "Number of repos cloned and counted concurrently")
+	storeSpec := fs.String("store", "", "Also save every result to this sqlite:<path> or postgres database")
+	force := fs.Bool("force", false, "Replace the result files of dates already saved instead of skipping them")
+	fileMode := fileModeFlag(0644)
+	fs.Var(&fileMode, "file-mode", "Octal permissions of the written result files, the clone cache's directories get the matching search permissions")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: cncf-language-stats backfill [flags]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	months, ok := backfillIntervals[*interval]
+	if !ok {
+		return fmt.Errorf("invalid -interval %q, must be monthly, quarterly or yearly", *interval)
+	}
+	if *years < 1 {
+		return fmt.Errorf("invalid -years %d, must be at least 1", *years)
+	}
+
+	repos, err := stats.LoadRepos(*reposPath)
+	if err != nil {
+		return err
+	}
+	groups := make(map[string]map[string]stats.Project)
+	for _, name := range splitList(*groupList) {
+		switch name {
+		case "graduated":
+			groups[name] = repos.Graduated
+		case "incubating":
+			groups[name] = repos.Incubating
+		case "sandbox":
+			groups[name] = repos.Sandbox
+		default:
+			return fmt.Errorf("invalid group %q in -groups, must be graduated, incubating or sandbox", name)
+		}
+	}
+	var store resultStore
+	if *storeSpec != "" {
+		if store, err = openStore(*storeSpec); err != nil {
+			return err
+		}
+		defer store.Close()
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	for _, date := range backfillDates(time.Now(), *years, months) {
+		collector := stats.NewCollector(stats.Options{
+			Token:          os.Getenv("GITHUB_TOKEN"),
+			Workers:        *workers,
+			Clone:          true,
+			CloneAt:        date,
+			CloneCache:     *cloneCache,
+			CloneCacheMode: stats.DirMode(os.FileMode(fileMode)),
+			CloneExcludes:  splitList(*cloneExcludes),
+			Build:          toolBuild(),
+		})
+		collector.Weights = repos.Weights
+		day := date.Format("2006-01-02")
+		for _, name := range splitList(*groupList) {
+			path := filepath.Join(*outDir, day+"-"+name+".json")
+			if _, err := os.Stat(path); err == nil && !*force {
+				slog.Info("Already backfilled, skipping", "group", name, "date", day, "path", path)
+				continue
+			} else if err != nil && !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+			slog.Info("Backfilling", "group", name, "date", day)
+			result, err := collector.Collect(ctx, name, groups[name])
+			if err != nil {
+				return fmt.Errorf("backfilling %s on %s: %w", name, day, err)
+			}
+			b, err := marshalResult(result, nil)
+			if err != nil {
+				return err
+			}
+			if err := writeFile(path, b, os.FileMode(fileMode)); err != nil {
+				return err
+			}
+			if store != nil {
+				if err := store.Save(day, name, result); err != nil {
+					return err
+				}
+			}
+			slog.Info("Saved the backfilled result", "group", name, "date", day, "path", path)
+		}
+	}
+	return nil
+}
+
+// backfillDates The first days of the last years of intervals of months before now, oldest first. The first
+// day of now's interval is left out, it is collected by the regular runs.
+func backfillDates(now time.Time, years, months int) []time.Time {
+	now = now.UTC()
+	start := now.Year()*12 + int(now.Month()) - 1
+	start -= start % months
+	var dates []time.Time
+	for m := start - years*12; m < start; m += months {
+		dates = append(dates, time.Date(m/12, time.Month(m%12+1), 1, 0, 0, 0, 0, time.UTC))
+	}
+	return dates
+}
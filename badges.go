@@ -0,0 +1,73 @@
+package main
+
+import (
+	"cncf-language-stats/stats"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// badge A shields.io endpoint badge, see https://shields.io/badges/endpoint-badge
+type badge struct {
+	SchemaVersion int    `json:"schemaVersion"`
+	Label         string `json:"label"`
+	Message       string `json:"message"`
+	Color         string `json:"color"`
+}
+
+// groupBadges The badges of a group's top language by project count, its top language by bytes or lines and
+// its number of projects, keyed by the name of their file
+func groupBadges(repoGroup string, result stats.Result, colors languageColors) map[string]badge {
+	var projects int
+	for _, count := range result.TopLanguage {
+		projects += count
+	}
+	label := "CNCF"
+	if repoGroup != "all" {
+		label += " " + repoGroup
+	}
+	return map[string]badge{
+		"top-language":                     languageBadge("top "+label+" language", stats.Leaders(result.TopLanguage), colors),
+		"top-language-by-" + result.Unit(): languageBadge("top "+label+" language by "+result.Unit(), stats.Leaders(result.Totals()), colors),
+		"projects":                         {SchemaVersion: 1, Label: label + " projects", Message: fmt.Sprint(projects), Color: "blue"},
+	}
+}
+
+// languageBadge A badge naming the languages tied for the top, in the color of the first
+func languageBadge(label string, leaders []string, colors languageColors) badge {
+	b := badge{SchemaVersion: 1, Label: label, Message: "none", Color: "lightgrey"}
+	if len(leaders) > 0 {
+		// shields.io takes hex colors without the #, which every language has including those without a known color
+		b.Message, b.Color = strings.Join(leaders, ", "), strings.TrimPrefix(colors.color(leaders[0]), "#")
+	}
+	return b
+}
+
+// writeBadges Writes the badges of every group to dir/badges/<group>-<badge>.json, overwriting those of the
+// previous run so that embedded badges stay current, and returns the paths of the written files
+func writeBadges(dir string, names []string, results []stats.Result, colors languageColors, perm os.FileMode) ([]string, error) {
+	var written []string
+	for i, name := range names {
+		badges := groupBadges(name, results[i], colors)
+		files := make([]string, 0, len(badges))
+		for file := range badges {
+			files = append(files, file)
+		}
+		sort.Strings(files)
+		for _, file := range files {
+			data, err := json.Marshal(badges[file])
+			if err != nil {
+				return nil, err
+			}
+			path := filepath.Join(dir, "badges", name+"-"+file+".json")
+			if err := writeFile(path, data, perm); err != nil {
+				return nil, err
+			}
+			written = append(written, path)
+		}
+	}
+	return written, nil
+}
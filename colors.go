@@ -0,0 +1,90 @@
+package main
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// defaultLanguageColors GitHub linguist's colors of the languages common in CNCF projects
+//
+//go:embed language-colors.json
+var defaultLanguageColors []byte
+
+var hexColor = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// languageColors Maps languages to the #rrggbb colors they are drawn with in charts
+type languageColors map[string]string
+
+// loadLanguageColors Reads the embedded linguist colors, overridden by the colors in the JSON file at path when set
+func loadLanguageColors(path string) (languageColors, error) {
+	colors := make(languageColors)
+	if err := json.Unmarshal(defaultLanguageColors, &colors); err != nil {
+		return nil, err
+	}
+	if path == "" {
+		return colors, nil
+	}
+
+	f, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var overrides map[string]string
+	if err := json.Unmarshal(f, &overrides); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	for lang, color := range overrides {
+		if !hexColor.MatchString(color) {
+			return nil, fmt.Errorf("%s: color %q of %s is not formatted as #rrggbb", path, color, lang)
+		}
+		colors[lang] = color
+	}
+	return colors, nil
+}
+
+// color The #rrggbb color of the language, languages without a known color get one whose hue is derived from
+// their name
+func (c languageColors) color(lang string) string {
+	if color, ok := c[lang]; ok {
+		return color
+	}
+	h := fnv.New32a()
+	h.Write([]byte(lang))
+	return hslToHex(float64(h.Sum32()%360), 0.55, 0.5)
+}
+
+// hslToHex Converts the color of hue h in degrees and saturation s and lightness l from 0 to 1 to #rrggbb
+func hslToHex(h, s, l float64) string {
+	c := (1 - math.Abs(2*l-1)) * s
+	x := c * (1 - math.Abs(math.Mod(h/60, 2)-1))
+	var r, g, b float64
+	switch {
+	case h < 60:
+		r, g = c, x
+	case h < 120:
+		r, g = x, c
+	case h < 180:
+		g, b = c, x
+	case h < 240:
+		g, b = x, c
+	case h < 300:
+		r, b = x, c
+	default:
+		r, b = c, x
+	}
+	m := l - c/2
+	channel := func(v float64) int { return int(math.Round((v + m) * 255)) }
+	return fmt.Sprintf("#%02x%02x%02x", channel(r), channel(g), channel(b))
+}
+
+// swatch A Markdown image of a shields.io badge filled with the language's color, for tables without styling
+func (c languageColors) swatch(lang string) string {
+	color := c.color(lang)
+	return fmt.Sprintf("![%s](https://img.shields.io/badge/%%20-%%20-%s)", color, strings.TrimPrefix(color, "#"))
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"cncf-language-stats/stats"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// parseGroups The groups of a comma separated -groups list
+func parseGroups(list string) ([]string, error) {
+	names := splitList(list)
+	for _, name := range names {
+		if !isGroup(name) {
+			return nil, fmt.Errorf("invalid group %q in -groups, must be %s", name, strings.Join(allGroups, ", "))
+		}
+	}
+	return names, nil
+}
+
+// runTrend Reports how each language changed across the saved results of every group, see saveTrendReport
+func runTrend(args []string) error {
+	fs := flag.NewFlagSet("trend", flag.ExitOnError)
+	dir := fs.String("dir", envOr("CNCF_STATS_OUT", "results"), "Directory the results are read from and the trends saved in, or set CNCF_STATS_OUT")
+	groupList := fs.String("groups", strings.Join(allGroups, ","), "Comma separated groups to report the trend of")
+	fileMode := fileModeFlag(0644)
+	fs.Var(&fileMode, "file-mode", "Octal permissions of the written trend files")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: cncf-language-stats trend [flags]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	groups, err := parseGroups(*groupList)
+	if err != nil {
+		return err
+	}
+	for _, repoGroup := range groups {
+		if err := saveTrendReport(*dir, repoGroup, os.FileMode(fileMode)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runServe Serves the saved results and a dashboard over HTTP until interrupted
+func runServe(args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := fs.String("addr", ":8080", "Address to listen on")
+	dir := fs.String("dir", envOr("CNCF_STATS_OUT", "results"), "Directory the served results are read from, or set CNCF_STATS_OUT")
+	colorsPath := fs.String("language-colors", "", "JSON file mapping languages to #rrggbb colors overriding the GitHub linguist colors")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: cncf-language-stats serve [flags]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	colors, err := loadLanguageColors(*colorsPath)
+	if err != nil {
+		return err
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return serve(ctx, *addr, *dir, colors)
+}
+
+// runReport Renders the newest saved result of every group as an HTML dashboard or a Markdown report without
+// collecting anything
+func runReport(args []string) error {
+	fs := flag.NewFlagSet("report", flag.ExitOnError)
+	dir := fs.String("dir", envOr("CNCF_STATS_OUT", "results"), "Directory the results are read from, or set CNCF_STATS_OUT")
+	groupList := fs.String("groups", strings.Join(allGroups, ","), "Comma separated groups to report")
+	format := fs.String("format", "html", "Format of the report, \"html\" or \"markdown\"")
+	out := fs.String("out", "", "Path of the report, index.html or report.md in -dir by default")
+	colorsPath := fs.String("language-colors", "", "JSON file mapping languages to #rrggbb colors overriding the GitHub linguist colors of the dashboard and the Markdown swatches")
+	fileMode := fileModeFlag(0644)
+	fs.Var(&fileMode, "file-mode", "Octal permissions of the written report")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: cncf-language-stats report [flags]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if *format != "html" && *format != "markdown" {
+		return fmt.Errorf("invalid -format %q, must be \"html\" or \"markdown\"", *format)
+	}
+	groups, err := parseGroups(*groupList)
+	if err != nil {
+		return err
+	}
+
+	var names []string
+	var results []stats.Result
+	latestNames, latest, err := latestResults(*dir)
+	if err != nil {
+		return err
+	}
+	for i, name := range latestNames {
+		for _, g := range groups {
+			if g == name {
+				names = append(names, name)
+				results = append(results, latest[i])
+			}
+		}
+	}
+	if len(names) == 0 {
+		return fmt.Errorf("no results of %s in %s", strings.Join(groups, ", "), *dir)
+	}
+
+	colors, err := loadLanguageColors(*colorsPath)
+	if err != nil {
+		return err
+	}
+	path := *out
+	if *format == "markdown" {
+		if path == "" {
+			path = filepath.Join(*dir, "report.md")
+		}
+		var b []byte
+		for i, name := range names {
+			if i > 0 {
+				b = append(b, '\n')
+			}
+			b = append(b, marshalMarkdown(name, results[i], colors)...)
+		}
+		if err := writeFile(path, b, os.FileMode(fileMode)); err != nil {
+			return err
+		}
+	} else {
+		if path == "" {
+			path = filepath.Join(*dir, "index.html")
+		}
+		if err := writeHTMLReport(path, names, results, colors, os.FileMode(fileMode)); err != nil {
+			return err
+		}
+	}
+	fmt.Println(path)
+	return nil
+}
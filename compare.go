@@ -0,0 +1,78 @@
+package main
+
+import (
+	"cncf-language-stats/stats"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+var errLeaderChanged = errors.New("the top language of a group changed")
+
+var errProjectsFailed = errors.New("the stats of some projects could not be collected")
+
+// loadBaseline Reads a result file from disk or, when path is an http(s) URL, downloads it, upgraded to the
+// current schema version
+func loadBaseline(path string) (stats.Result, error) {
+	var baseline stats.Result
+	var f []byte
+	var err error
+	if strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") {
+		f, err = fetchURL(path)
+	} else {
+		f, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return baseline, err
+	}
+	if baseline, _, err = stats.MigrateResult(f); err != nil {
+		return baseline, fmt.Errorf("parsing baseline %s: %w", path, err)
+	}
+	return baseline, nil
+}
+
+// fetchURL Downloads the body of url, failing on non 2xx responses
+func fetchURL(url string) ([]byte, error) {
+	client := http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("fetching %s: %s", url, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+func printRankChanges(repoGroup string, changes []stats.RankChange) {
+	fmt.Printf("Rank changes for %s:\n", repoGroup)
+	if len(changes) == 0 {
+		fmt.Println("  no changes")
+	}
+	for _, c := range changes {
+		fmt.Println(" ", c)
+	}
+}
+
+func printLeaderChanges(repoGroup string, changes []stats.LeaderChange) {
+	fmt.Printf("Top language for %s:\n", repoGroup)
+	for _, c := range changes {
+		fmt.Println(" ", c)
+	}
+}
+
+// printSummary Prints the languages that are top in the most projects
+func printSummary(repoGroup string, result stats.Result) {
+	fmt.Printf("Top languages for %s:\n", repoGroup)
+	for i, language := range stats.SortLanguageMap(result.TopLanguage) {
+		if i == 10 {
+			break
+		}
+		fmt.Printf("  %d. %s: %d projects, %d %s\n", i+1, language.Language, language.Lines, result.Totals()[language.Language], result.Unit())
+	}
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Config Settings read from the -config file
+type Config struct {
+	// Defaults Flag values of every run. The environment variables of flags, a profile and the command line
+	// override them.
+	Defaults map[string]flagValue `yaml:"defaults"`
+	// Profiles Named sets of flag values, selected with -profile
+	Profiles map[string]map[string]flagValue `yaml:"profiles"`
+	// Aliases Renames languages before they are aggregated, e.g. "Jupyter Notebook" to "Python".
+	// Languages renamed to the same name are summed up.
+	Aliases map[string]string `yaml:"aliases"`
+}
+
+// flagValue The value of a flag in the config file, a scalar or a list joined with commas
+type flagValue string
+
+func (v *flagValue) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind != yaml.SequenceNode {
+		var s string
+		if err := value.Decode(&s); err != nil {
+			return err
+		}
+		*v = flagValue(s)
+		return nil
+	}
+	var items []string
+	if err := value.Decode(&items); err != nil {
+		return err
+	}
+	*v = flagValue(strings.Join(items, ","))
+	return nil
+}
+
+// flagEnv The environment variables setting the defaults of flags, which take precedence over Config.Defaults
+var flagEnv = map[string][]string{
+	"repos":               {"CNCF_STATS_REPOS"},
+	"out":                 {"CNCF_STATS_OUT"},
+	"app-id":              {"GITHUB_APP_ID"},
+	"app-installation-id": {"GITHUB_APP_INSTALLATION_ID"},
+	"app-private-key":     {"GITHUB_APP_PRIVATE_KEY_FILE"},
+	"token-file":          {"GITHUB_TOKEN", "GITHUB_TOKENS"},
+	"notify-webhook":      {"CNCF_STATS_NOTIFY_WEBHOOK"},
+	"email-from":          {"SMTP_FROM"},
+	"smtp-addr":           {"SMTP_ADDR"},
+}
+
+// loadConfig Reads the config file at path. A missing file is an empty config unless required.
+func loadConfig(path string, required bool) (Config, error) {
+	var cfg Config
+	f, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) && !required {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, err
+	}
+	if err := yaml.Unmarshal(f, &cfg); err != nil {
+		return cfg, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// applyConfig Sets the flags to the config's defaults and then to the values of the named profile, if any.
+// explicit holds the flags given on the command line, which take precedence over both, and defaults are
+// skipped for flags whose environment variable is set.
+func applyConfig(fs *flag.FlagSet, cfg Config, profile string, explicit map[string]bool) error {
+	defaults := make(map[string]flagValue, len(cfg.Defaults))
+	for flagName, value := range cfg.Defaults {
+		if !envSet(flagName) {
+			defaults[flagName] = value
+		}
+	}
+	if err := setFlags(fs, defaults, explicit, "defaults"); err != nil {
+		return err
+	}
+	if profile == "" {
+		return nil
+	}
+	values, ok := cfg.Profiles[profile]
+	if !ok {
+		available := make([]string, 0, len(cfg.Profiles))
+		for p := range cfg.Profiles {
+			available = append(available, p)
+		}
+		sort.Strings(available)
+		return fmt.Errorf("unknown profile %q, available profiles: %s", profile, strings.Join(available, ", "))
+	}
+	return setFlags(fs, values, explicit, fmt.Sprintf("profile %q", profile))
+}
+
+// reloadConfig Reads the config file at path again and applies it like applyConfig. The flags the previous
+// config set are reset to their defaults first, so that values removed from the file no longer apply, and orgs,
+// which -org appends to, is emptied unless -org was given on the command line.
+func reloadConfig(fs *flag.FlagSet, path string, previous Config, profile string, explicit map[string]bool, orgs *[]string) (Config, error) {
+	cfg, err := loadConfig(path, explicit["config"] || profile != "")
+	if err != nil {
+		return previous, err
+	}
+	configured := make(map[string]bool)
+	for flagName := range previous.Defaults {
+		configured[flagName] = true
+	}
+	for flagName := range previous.Profiles[profile] {
+		configured[flagName] = true
+	}
+	names := make([]string, 0, len(configured))
+	for flagName := range configured {
+		names = append(names, flagName)
+	}
+	sort.Strings(names)
+	for _, flagName := range names {
+		f := fs.Lookup(flagName)
+		if f == nil || explicit[flagName] {
+			continue
+		}
+		if flagName == "org" {
+			*orgs = nil
+			continue
+		}
+		if err := fs.Set(flagName, f.DefValue); err != nil {
+			return previous, err
+		}
+	}
+	return cfg, applyConfig(fs, cfg, profile, explicit)
+}
+
+// envSet Reports whether an environment variable of the flag is set
+func envSet(flagName string) bool {
+	for _, name := range flagEnv[flagName] {
+		if os.Getenv(name) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// setFlags Sets the flags to the values in the order of their names, except the explicit ones. source names
+// the values in errors.
+func setFlags(fs *flag.FlagSet, values map[string]flagValue, explicit map[string]bool, source string) error {
+	names := make([]string, 0, len(values))
+	for flagName := range values {
+		names = append(names, flagName)
+	}
+	sort.Strings(names)
+	for _, flagName := range names {
+		if fs.Lookup(flagName) == nil {
+			return fmt.Errorf("%s sets unknown flag -%s", source, flagName)
+		}
+		if explicit[flagName] {
+			continue
+		}
+		if err := fs.Set(flagName, string(values[flagName])); err != nil {
+			return fmt.Errorf("%s: %w", source, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"cncf-language-stats/stats"
+	"encoding/csv"
+	"os"
+	"strconv"
+	"sync"
+)
+
+func init() {
+	registerResultFormat("csv", marshalResultCSV)
+}
+
+// csvDetailHeader The columns of the detail file, unit naming the column of the languages' bytes or lines
+func csvDetailHeader(unit string) []string {
+	return []string{"group", "project", "owner", "repo", "language", unit, "rank"}
+}
+
+// CSVDetailWriter Appends one row per language of every processed repo to a CSV file.
+// Rows are written after every repo so interrupted runs still leave usable data behind.
+// Each repo's rows are appended with a single write while holding an exclusive file lock,
+// so several runs sharing the file interleave whole rows but never tear them.
+type CSVDetailWriter struct {
+	mu sync.Mutex
+	f  *os.File
+}
+
+// OpenCSVDetail Opens the CSV file for appending, writing the header if the file is new or empty. unit is the
+// stats.Result Unit of the languages written, "bytes" or "lines".
+func OpenCSVDetail(path, unit string, perm os.FileMode) (*CSVDetailWriter, error) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, perm)
+	if err != nil {
+		return nil, err
+	}
+	if err := f.Chmod(perm); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	c := &CSVDetailWriter{f: f}
+	err = c.append(func(size int64) [][]string {
+		if size > 0 {
+			return nil
+		}
+		return [][]string{csvDetailHeader(unit)}
+	})
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// WriteRepo Writes a row for each language of the repo, ranked in the order of l
+func (c *CSVDetailWriter) WriteRepo(repoGroup, project, owner, repo string, l stats.LanguageLinesList) error {
+	return c.append(func(int64) [][]string {
+		rows := make([][]string, len(l))
+		for i, language := range l {
+			rows[i] = []string{repoGroup, project, owner, repo, language.Language, strconv.Itoa(language.Lines), strconv.Itoa(i + 1)}
+		}
+		return rows
+	})
+}
+
+// append Locks the file and appends the rows returned by rows, which is given the current file size
+func (c *CSVDetailWriter) append(rows func(size int64) [][]string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := lockFile(c.f); err != nil {
+		return err
+	}
+	defer unlockFile(c.f)
+
+	info, err := c.f.Stat()
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.WriteAll(rows(info.Size())); err != nil {
+		return err
+	}
+	if buf.Len() == 0 {
+		return nil
+	}
+	_, err = c.f.Write(buf.Bytes())
+	return err
+}
+
+func (c *CSVDetailWriter) Close() error {
+	return c.f.Close()
+}
+
+// marshalResultCSV Encodes the top language counts into the result file and the total bytes or lines into a
+// second file
+func marshalResultCSV(repoGroup string, result stats.Result, keyNames map[string]string) ([]resultFile, error) {
+	topLanguage, err := marshalCountsCSV(repoGroup, "count", result.TopLanguage)
+	if err != nil {
+		return nil, err
+	}
+	totals, err := marshalCountsCSV(repoGroup, result.Unit(), result.Totals())
+	if err != nil {
+		return nil, err
+	}
+	return []resultFile{
+		{suffix: "-top-language.csv", data: topLanguage},
+		{suffix: "-total-" + result.Unit() + ".csv", data: totals},
+	}, nil
+}
+
+// marshalCountsCSV Encodes a map of languages to values as CSV rows of the group, language, value and the value's
+// percentage of all values, sorted descending by value. valueColumn names the value column.
+func marshalCountsCSV(repoGroup, valueColumn string, counts map[string]int) ([]byte, error) {
+	var total int
+	for _, v := range counts {
+		total += v
+	}
+	var b bytes.Buffer
+	w := csv.NewWriter(&b)
+	if err := w.Write([]string{"group", "language", valueColumn, "percentage"}); err != nil {
+		return nil, err
+	}
+	for _, l := range stats.SortLanguageMap(counts) {
+		var pct float64
+		if total > 0 {
+			pct = float64(l.Lines) * 100 / float64(total)
+		}
+		row := []string{repoGroup, l.Language, strconv.Itoa(l.Lines), strconv.FormatFloat(pct, 'f', 2, 64)}
+		if err := w.Write(row); err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+	return b.Bytes(), w.Error()
+}
@@ -0,0 +1,103 @@
+//go:build unix
+
+package main
+
+import (
+	"cncf-language-stats/stats"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+// TestCSVDetailConcurrentWriters Appends to one file from several writers at once, as several runs sharing
+// -csv-detail do, and checks that the file has a single header and every repo's rows in one piece
+func TestCSVDetailConcurrentWriters(t *testing.T) {
+	const writers, repos = 8, 50
+	path := filepath.Join(t.TempDir(), "detail.csv")
+	languages := stats.LanguageLinesList{
+		{Language: "Go", Lines: 3000},
+		{Language: "Shell", Lines: 200},
+		{Language: "Makefile", Lines: 10},
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, writers)
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			w, err := OpenCSVDetail(path, "bytes", 0644)
+			if err != nil {
+				errs <- err
+				return
+			}
+			defer w.Close()
+			for j := 0; j < repos; j++ {
+				if err := w.WriteRepo("graduated", fmt.Sprintf("project%d", i), fmt.Sprintf("owner%d", i), fmt.Sprintf("repo%d", j), languages); err != nil {
+					errs <- err
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	rows, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatalf("reading the CSV: %v", err)
+	}
+	if want := 1 + writers*repos*len(languages); len(rows) != want {
+		t.Fatalf("got %d rows, want %d", len(rows), want)
+	}
+	if want := csvDetailHeader("bytes"); fmt.Sprint(rows[0]) != fmt.Sprint(want) {
+		t.Fatalf("got header %v, want %v", rows[0], want)
+	}
+
+	seen := make(map[string]bool)
+	for i := 1; i < len(rows); i += len(languages) {
+		repo := rows[i][2] + "/" + rows[i][3]
+		if seen[repo] {
+			t.Fatalf("row %d: rows of %s are split up", i, repo)
+		}
+		seen[repo] = true
+		for k, language := range languages {
+			row := rows[i+k]
+			want := []string{"graduated", rows[i][1], rows[i][2], rows[i][3], language.Language, strconv.Itoa(language.Lines), strconv.Itoa(k + 1)}
+			if fmt.Sprint(row) != fmt.Sprint(want) {
+				t.Fatalf("row %d: got %v, want %v", i+k, row, want)
+			}
+		}
+	}
+	if len(seen) != writers*repos {
+		t.Fatalf("got the rows of %d repos, want %d", len(seen), writers*repos)
+	}
+}
+
+func TestCSVDetailHeaderUnit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "detail.csv")
+	w, err := OpenCSVDetail(path, "lines", 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "group,project,owner,repo,language,lines,rank\n"; string(b) != want {
+		t.Errorf("got %q, want %q", b, want)
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"cncf-language-stats/stats"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// customComparisonRows Number of languages in every table of the Markdown comparison
+const customComparisonRows = 15
+
+// customComparison The language shares of every collected group compared with those of the custom projects,
+// saved as <date>-custom-comparison.json and .md
+type customComparison struct {
+	Date string `json:"date"`
+	// Unit Unit of the totals the shares are of, "bytes" or "lines"
+	Unit string `json:"unit"`
+	// Projects The number of custom projects counted in the reference
+	Projects int                     `json:"projects"`
+	Groups   []customGroupComparison `json:"groups"`
+}
+
+type customGroupComparison struct {
+	Group string `json:"group"`
+	// Distance The percentage of the group's totals distributed differently than the custom projects', see
+	// stats.ShareDistance
+	Distance  float64               `json:"distance"`
+	Languages []stats.LanguageShare `json:"languages"`
+}
+
+func newCustomComparison(names []string, results []stats.Result, custom stats.Result) customComparison {
+	c := customComparison{Date: resultDate(), Unit: custom.Unit(), Groups: make([]customGroupComparison, len(names))}
+	for _, count := range custom.TopLanguage {
+		c.Projects += count
+	}
+	for i, name := range names {
+		shares := stats.CompareShares(results[i], custom)
+		c.Groups[i] = customGroupComparison{Group: name, Distance: stats.ShareDistance(shares), Languages: shares}
+	}
+	return c
+}
+
+// markdown Renders a table of the largest languages of every group next to their share of the custom projects
+func (c customComparison) markdown() string {
+	var b strings.Builder
+	b.WriteString("# CNCF compared with the custom projects\n\n")
+	fmt.Fprintf(&b, "Shares of the total %s and of the projects by top language on %s, against %d custom projects.\n", c.Unit, c.Date, c.Projects)
+	for _, g := range c.Groups {
+		fmt.Fprintf(&b, "\n## %s\n\n", g.Group)
+		fmt.Fprintf(&b, "%.1f%% of the %s are distributed differently.\n\n", g.Distance, c.Unit)
+		fmt.Fprintf(&b, "| Language | %s | Custom | Difference | Top language %s | Top language custom |\n", g.Group, g.Group)
+		b.WriteString("| --- | ---: | ---: | ---: | ---: | ---: |\n")
+		for i, s := range g.Languages {
+			if i == customComparisonRows {
+				break
+			}
+			fmt.Fprintf(&b, "| %s | %.2f%% | %.2f%% | %+.2f | %.1f%% | %.1f%% |\n",
+				s.Language, s.Percent, s.ReferencePercent, s.Difference, s.TopLanguage, s.ReferenceTopLanguage)
+		}
+	}
+	return b.String()
+}
+
+// writeCustomComparison Saves the comparison next to the results and returns the paths of the written files
+func writeCustomComparison(out resultWriter, c customComparison) ([]string, error) {
+	b, err := json.MarshalIndent(c, "", " ")
+	if err != nil {
+		return nil, err
+	}
+	files := []string{out.resultPath("custom-comparison", ".json"), out.resultPath("custom-comparison", ".md")}
+	if err := writeFile(files[0], b, out.fileMode); err != nil {
+		return nil, err
+	}
+	if err := writeFile(files[1], []byte(c.markdown()), out.fileMode); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"cncf-language-stats/stats"
+	"flag"
+	"fmt"
+	"os"
+)
+
+// runDiff Prints how two result files differ: the languages that gained or lost bytes or lines and the
+// projects whose top language changed
+func runDiff(args []string) error {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: cncf-language-stats diff <baseline.json> <current.json>")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 2 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	baseline, err := loadBaseline(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	current, err := loadBaseline(fs.Arg(1))
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Total %s from %s to %s:\n", current.Unit(), fs.Arg(0), fs.Arg(1))
+	sizes := stats.CompareSizes(baseline, current)
+	if len(sizes) == 0 {
+		fmt.Println("  no changes")
+	}
+	for _, c := range sizes {
+		fmt.Println(" ", c)
+	}
+
+	fmt.Println("Top language changes:")
+	if baseline.Projects == nil || current.Projects == nil {
+		fmt.Println("  unknown, both results need the project details saved with -detailed")
+		return nil
+	}
+	tops := stats.CompareTopLanguages(baseline, current)
+	if len(tops) == 0 {
+		fmt.Println("  no changes")
+	}
+	for _, c := range tops {
+		fmt.Println(" ", c)
+	}
+	return nil
+}
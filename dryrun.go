@@ -0,0 +1,127 @@
+package main
+
+import (
+	"cncf-language-stats/stats"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// errRepoProblems Reported when the projects have malformed or duplicate entries
+var errRepoProblems = errors.New("the projects have problems")
+
+// dryRun Validates the projects and prints the repos a run would fetch and the API requests it would take at
+// least, without sending any. The projects come from the landscape when landscapeSource is set and from the
+// repos file otherwise, whose malformed entries are all reported with their lines.
+func dryRun(opts options, landscapeSource string, graphQL, clone, checkRepos, contributors, commitActivity, goModules, releases bool) error {
+	var repos stats.Repos
+	var problems []error
+	if landscapeSource != "" {
+		var err error
+		if repos, err = loadLandscape(landscapeSource); err != nil {
+			return err
+		}
+		problems = repos.Duplicates()
+	} else {
+		b, err := os.ReadFile(opts.reposPath)
+		if err != nil {
+			return err
+		}
+		repos, problems = stats.CheckRepos(b)
+	}
+
+	groups := []struct {
+		name     string
+		selected bool
+		projects map[string]stats.Project
+	}{
+		{"graduated", opts.graduated, repos.Graduated},
+		{"incubating", opts.incubating, repos.Incubating},
+		{"sandbox", opts.sandbox, repos.Sandbox},
+		// The custom projects are collected whole, -project and -match only select the groups' projects
+		{"custom", opts.custom && opts.customRepos == "", repos.Custom},
+	}
+	var repoURLs, orgURLs int
+	for _, g := range groups {
+		if !g.selected {
+			continue
+		}
+		projects := g.projects
+		if g.name != "custom" {
+			projects = opts.selectProjects(projects)
+		}
+		names := make([]string, 0, len(projects))
+		for name := range projects {
+			names = append(names, name)
+		}
+		sort.Slice(names, func(i, j int) bool {
+			return strings.ToLower(names[i]) < strings.ToLower(names[j])
+		})
+
+		var lines []string
+		var groupRepos, groupOrgs int
+		for _, name := range names {
+			for _, u := range projects[name].URLs {
+				if stats.IsOrgURL(u) {
+					groupOrgs++
+					lines = append(lines, fmt.Sprintf("  %s: %s (org, its repos are listed when run)", name, u))
+				} else {
+					groupRepos++
+					lines = append(lines, fmt.Sprintf("  %s: %s", name, u))
+				}
+			}
+		}
+		fmt.Printf("%s: %d projects, %d repo URLs and %d org URLs\n", g.name, len(projects), groupRepos, groupOrgs)
+		for _, l := range lines {
+			fmt.Println(l)
+		}
+		repoURLs += groupRepos
+		orgURLs += groupOrgs
+	}
+
+	// Org listings take a request per 100 repos, the requests for the repos of orgs are not known ahead
+	requests := orgURLs
+	var plan string
+	switch {
+	case clone:
+		plan = fmt.Sprintf("%d org listings and %d clones", orgURLs, repoURLs)
+	case graphQL:
+		queries := (repoURLs + 49) / 50
+		requests += queries
+		plan = fmt.Sprintf("%d org listings and %d GraphQL queries", orgURLs, queries)
+	default:
+		requests += repoURLs
+		plan = fmt.Sprintf("%d org listings and %d language requests", orgURLs, repoURLs)
+		if checkRepos {
+			requests += repoURLs
+			plan += fmt.Sprintf(" and %d repo lookups", repoURLs)
+		}
+	}
+	if contributors {
+		requests += repoURLs
+		plan += fmt.Sprintf(" and %d contributor counts", repoURLs)
+	}
+	if releases {
+		requests += repoURLs
+		plan += fmt.Sprintf(" and %d release listings", repoURLs)
+	}
+	if goModules {
+		plan += " and a go.mod request per Go repo"
+	}
+	if commitActivity {
+		requests += repoURLs
+		plan += fmt.Sprintf(" and %d commit activity requests", repoURLs)
+	}
+	fmt.Printf("Plan: at least %d API requests, %s\n", requests, plan)
+
+	if len(problems) > 0 {
+		fmt.Printf("%d problems:\n", len(problems))
+		for _, p := range problems {
+			fmt.Println(" ", p)
+		}
+		return fmt.Errorf("%w, %d found", errRepoProblems, len(problems))
+	}
+	return nil
+}
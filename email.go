@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bytes"
+	"cncf-language-stats/stats"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// mailer Emails the report of every run through an SMTP server
+type mailer struct {
+	// addr host:port of the SMTP server, which is asked to STARTTLS when it offers it
+	addr string
+	from string
+	to   []string
+	// auth Logs in with PLAIN auth when set, which net/smtp only sends over TLS or to localhost
+	auth smtp.Auth
+}
+
+// newMailer Creates a mailer sending from from to the recipients through the server at addr, logging in
+// when username is set
+func newMailer(addr, from string, to []string, username, password string) (*mailer, error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid -smtp-addr %q, must be host:port", addr)
+	}
+	if from == "" {
+		from = username
+	}
+	if from == "" {
+		return nil, fmt.Errorf("-email-to needs -email-from or SMTP_USERNAME to send from")
+	}
+	m := &mailer{addr: addr, from: from, to: to}
+	if username != "" {
+		m.auth = smtp.PlainAuth("", username, password, host)
+	}
+	return m, nil
+}
+
+// Send Emails the Markdown report of the groups with the HTML dashboard as an alternative
+func (m *mailer) Send(names []string, results []stats.Result, colors languageColors) error {
+	msg, err := m.message(names, results, colors, time.Now())
+	if err != nil {
+		return err
+	}
+	if err := smtp.SendMail(m.addr, m.auth, m.from, m.to, msg); err != nil {
+		return fmt.Errorf("emailing the report: %w", err)
+	}
+	return nil
+}
+
+// message Renders the email as a multipart/alternative message of the Markdown tables of the groups with the
+// swatches of the colors and the HTML dashboard
+func (m *mailer) message(names []string, results []stats.Result, colors languageColors, now time.Time) ([]byte, error) {
+	var text strings.Builder
+	for i, name := range names {
+		if i > 0 {
+			text.WriteString("\n")
+		}
+		text.Write(marshalMarkdown(name, results[i], colors))
+	}
+	html, err := renderHTMLReport(names, results, colors)
+	if err != nil {
+		return nil, err
+	}
+
+	var body bytes.Buffer
+	parts := multipart.NewWriter(&body)
+	for _, part := range []struct {
+		contentType string
+		content     []byte
+	}{
+		{"text/plain; charset=utf-8", []byte(text.String())},
+		{"text/html; charset=utf-8", html},
+	} {
+		w, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		// Quoted-printable keeps the lines under SMTP's limit of 998 characters
+		qp := quotedprintable.NewWriter(w)
+		qp.Write(part.content)
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := parts.Close(); err != nil {
+		return nil, err
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", m.from)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(m.to, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", "State of CNCF languages, "+now.UTC().Format("2006-01-02")))
+	fmt.Fprintf(&msg, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&msg, "Content-Type: multipart/alternative; boundary=%s\r\n\r\n", parts.Boundary())
+	msg.Write(body.Bytes())
+	return msg.Bytes(), nil
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"cncf-language-stats/stats"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The built-in exporters of -export. Custom sinks register theirs the same way in an init function of
+// a file added to this package.
+func init() {
+	stats.RegisterExporter("stdout", openStdoutExporter)
+	stats.RegisterExporter("file", openFileExporter)
+	stats.RegisterExporter("s3", openBucketExporter)
+	stats.RegisterExporter("gs", openBucketExporter)
+	stats.RegisterExporter("http", openWebhookExporter)
+	stats.RegisterExporter("https", openWebhookExporter)
+}
+
+// fileExportMode Permissions of the files written to file: destinations, set from -file-mode before the exporters
+// are opened
+var fileExportMode os.FileMode = 0644
+
+// exportedResult The JSON document exporters send, a group's result and the date it was collected on
+type exportedResult struct {
+	Group  string       `json:"group"`
+	Date   string       `json:"date"`
+	Result stats.Result `json:"result"`
+}
+
+func marshalExport(group string, result stats.Result) ([]byte, error) {
+	return json.Marshal(exportedResult{Group: group, Date: resultDate(), Result: result})
+}
+
+// stdoutExporter Prints every result as a line of JSON
+type stdoutExporter struct {
+	mu  sync.Mutex
+	out io.Writer
+}
+
+func openStdoutExporter(dest string) (stats.Exporter, error) {
+	if dest != "stdout" {
+		return nil, fmt.Errorf("invalid export destination %q, must be stdout", dest)
+	}
+	return &stdoutExporter{out: os.Stdout}, nil
+}
+
+func (e *stdoutExporter) Export(_ context.Context, group string, result stats.Result) error {
+	b, err := marshalExport(group, result)
+	if err != nil {
+		return err
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	_, err = fmt.Fprintf(e.out, "%s\n", b)
+	return err
+}
+
+// openFileExporter Writes every result to <date>-<group>.json in the directory of a file:<dir> destination
+func openFileExporter(dest string) (stats.Exporter, error) {
+	dir := strings.TrimPrefix(strings.TrimPrefix(dest, "file:"), "//")
+	if dir == "" {
+		return nil, fmt.Errorf("invalid export destination %q, must be file:<dir>", dest)
+	}
+	return stats.ExporterFunc(func(_ context.Context, group string, result stats.Result) error {
+		b, err := marshalResult(result, nil)
+		if err != nil {
+			return err
+		}
+		return writeFile(filepath.Join(dir, resultDate()+"-"+group+".json"), b, fileExportMode)
+	}), nil
+}
+
+// openBucketExporter Puts every result as <date>-<group>.json into the bucket of a s3:// or gs:// destination,
+// with the credentials of -upload
+func openBucketExporter(dest string) (stats.Exporter, error) {
+	up, err := newUploader(dest)
+	if err != nil {
+		return nil, err
+	}
+	return stats.ExporterFunc(func(ctx context.Context, group string, result stats.Result) error {
+		b, err := marshalResult(result, nil)
+		if err != nil {
+			return err
+		}
+		return up.put(ctx, resultDate()+"-"+group+".json", b)
+	}), nil
+}
+
+// openWebhookExporter POSTs every result as JSON to a http(s) URL, failing on non 2xx responses
+func openWebhookExporter(dest string) (stats.Exporter, error) {
+	client := &http.Client{Timeout: 30 * time.Second}
+	return stats.ExporterFunc(func(ctx context.Context, group string, result stats.Result) error {
+		b, err := marshalExport(group, result)
+		if err != nil {
+			return err
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, dest, bytes.NewReader(b))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+			return fmt.Errorf("exporting %s to %s: %s %s", group, dest, resp.Status, bytes.TrimSpace(msg))
+		}
+		return nil
+	}), nil
+}
+
+// exportResult Exports the group's result to every exporter, stopping at the first failure
+func exportResult(ctx context.Context, exporters []stats.Exporter, group string, result stats.Result) error {
+	for _, e := range exporters {
+		if err := e.Export(ctx, group, result); err != nil {
+			return fmt.Errorf("exporting %s: %w", group, err)
+		}
+	}
+	return nil
+}
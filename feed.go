@@ -0,0 +1,167 @@
+package main
+
+import (
+	"cncf-language-stats/stats"
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// feedEntries The number of the latest runs listed in the feed
+const feedEntries = 50
+
+// atomFeed An Atom feed, see RFC 4287
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Author  atomAuthor  `xml:"author"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomEntry struct {
+	ID      string     `xml:"id"`
+	Title   string     `xml:"title"`
+	Updated string     `xml:"updated"`
+	Links   []atomLink `xml:"link"`
+	Summary string     `xml:"summary"`
+}
+
+// feedRun The results of the groups saved on a date
+type feedRun struct {
+	date  string
+	files map[string]string
+	lines []string
+}
+
+// writeFeed Writes an Atom feed of the latest runs saved in dir to dir/feed.xml, an entry per date summarizing
+// the top language of every group and how the ranks changed since the group's previous result. Entries link
+// to the result files under baseURL when set.
+func writeFeed(dir, baseURL string, groups []string, perm os.FileMode) (string, error) {
+	runs := make(map[string]*feedRun)
+	for _, group := range groups {
+		files, err := groupResultFiles(dir, group)
+		if err != nil {
+			return "", err
+		}
+		if len(files) > feedEntries+1 {
+			files = files[len(files)-feedEntries-1:]
+		}
+		var previous *stats.Result
+		for _, file := range files {
+			result, err := loadBaseline(file)
+			if err != nil {
+				return "", err
+			}
+			date := resultFileDate(file)
+			run, ok := runs[date]
+			if !ok {
+				run = &feedRun{date: date, files: make(map[string]string)}
+				runs[date] = run
+			}
+			run.files[group] = filepath.Base(file)
+			run.lines = append(run.lines, summarizeRun(group, result, previous))
+			previous = &result
+		}
+	}
+
+	dates := make([]string, 0, len(runs))
+	for date := range runs {
+		dates = append(dates, date)
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(dates)))
+	if len(dates) > feedEntries {
+		dates = dates[:feedEntries]
+	}
+
+	feed := atomFeed{
+		ID:     "urn:cncf-language-stats:feed",
+		Title:  "CNCF Programming Language Statistics",
+		Author: atomAuthor{Name: "cncf-language-stats"},
+	}
+	if baseURL != "" {
+		feed.Links = []atomLink{{Href: feedURL(baseURL, "feed.xml"), Rel: "self", Type: "application/atom+xml"}}
+	}
+	for _, date := range dates {
+		run := runs[date]
+		updated := date + "T00:00:00Z"
+		if feed.Updated == "" {
+			feed.Updated = updated
+		}
+		entry := atomEntry{
+			ID:      "urn:cncf-language-stats:run:" + date,
+			Title:   "Language stats of " + date,
+			Updated: updated,
+			Summary: strings.Join(run.lines, "\n"),
+		}
+		if baseURL != "" {
+			for _, group := range groups {
+				if file, ok := run.files[group]; ok {
+					entry.Links = append(entry.Links, atomLink{Href: feedURL(baseURL, file), Rel: "related", Type: "application/json"})
+				}
+			}
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+	if feed.Updated == "" {
+		feed.Updated = time.Now().UTC().Format(time.RFC3339)
+	}
+
+	b, err := xml.MarshalIndent(feed, "", " ")
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, "feed.xml")
+	return path, writeFile(path, append([]byte(xml.Header), b...), perm)
+}
+
+// summarizeRun Describes the group's top languages and the changes of their ranks since previous, when set
+func summarizeRun(group string, result stats.Result, previous *stats.Result) string {
+	var projects int
+	for _, count := range result.TopLanguage {
+		projects += count
+	}
+	top := "none"
+	if leaders := stats.Leaders(result.TopLanguage); len(leaders) > 0 {
+		top = strings.Join(leaders, ", ")
+	}
+	s := fmt.Sprintf("%s: %d projects, top language %s", group, projects, top)
+	if previous == nil {
+		return s + "."
+	}
+	changes := stats.CompareRanks(*previous, result, "count")
+	if len(changes) == 0 {
+		return s + ", no rank changes."
+	}
+	var moved []string
+	for i, c := range changes {
+		if i == 5 {
+			moved = append(moved, fmt.Sprintf("%d more", len(changes)-i))
+			break
+		}
+		moved = append(moved, c.String())
+	}
+	return s + ". " + strings.Join(moved, "; ") + "."
+}
+
+// feedURL The URL of the file in the hosted results directory
+func feedURL(baseURL, file string) string {
+	return strings.TrimSuffix(baseURL, "/") + "/" + url.PathEscape(file)
+}
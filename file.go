@@ -0,0 +1,75 @@
+package main
+
+import (
+	"cncf-language-stats/stats"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// envOr The value of the environment variable key, fallback when it is unset or empty
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+// splitList Splits a comma separated flag value, leaving out empty items
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// fileModeFlag A flag.Value holding file permissions written in octal, e.g. 0640
+type fileModeFlag os.FileMode
+
+func (m *fileModeFlag) String() string {
+	return fmt.Sprintf("%04o", uint32(*m))
+}
+
+func (m *fileModeFlag) Set(s string) error {
+	mode, err := strconv.ParseUint(s, 8, 32)
+	if err != nil {
+		return fmt.Errorf("%q is not an octal file mode", s)
+	}
+	if os.FileMode(mode) & ^os.ModePerm != 0 {
+		return fmt.Errorf("%q has bits outside of the permission bits 0777", s)
+	}
+	*m = fileModeFlag(mode)
+	return nil
+}
+
+// writeFile Writes data to the named file and sets its permissions to perm,
+// regardless of the umask or whether the file already existed. Missing parent directories are created with
+// stats.DirMode of perm.
+// The data goes to a temporary file renamed over name, so that an interrupted write never leaves it torn.
+func writeFile(name string, data []byte, perm os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(name), stats.DirMode(perm)); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(name), "."+filepath.Base(name)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Chmod(perm); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), name)
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"cncf-language-stats/stats"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// resultFile A file a format encodes a group's result into
+type resultFile struct {
+	// suffix Follows the date and group in the file name, e.g. ".json" or "-top-language.csv"
+	suffix string
+	data   []byte
+}
+
+// resultEncoder Encodes a group's result into its result files, the result file first. keyNames renames
+// top level keys of formats that have them, e.g. "topLanguage" to "top_language".
+type resultEncoder func(repoGroup string, result stats.Result, keyNames map[string]string) ([]resultFile, error)
+
+// resultFormats The encoders of the -format names. Every format registers itself with registerResultFormat
+// in an init function of its own file, adding a format needs nothing else.
+var resultFormats = make(map[string]resultEncoder)
+
+// registerResultFormat Makes the encoder available as -format name
+func registerResultFormat(name string, encode resultEncoder) {
+	if _, ok := resultFormats[name]; ok {
+		panic("result format registered twice: " + name)
+	}
+	resultFormats[name] = encode
+}
+
+// resultFormatNames The sorted and quoted registered format names as a list for flag help and errors
+func resultFormatNames() string {
+	names := make([]string, 0, len(resultFormats))
+	for name := range resultFormats {
+		names = append(names, fmt.Sprintf("%q", name))
+	}
+	sort.Strings(names)
+	if len(names) < 2 {
+		return strings.Join(names, "")
+	}
+	return strings.Join(names[:len(names)-1], ", ") + " or " + names[len(names)-1]
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"bytes"
+	"cncf-language-stats/stats"
+	"fmt"
+	"html/template"
+	"math"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	// htmlBars Number of languages drawn as bars before the rest is summed up as other
+	htmlBars = 10
+	// htmlBarHeight Height of a bar row in pixels
+	htmlBarHeight = 24
+	// htmlBarWidth Length of the largest bar in pixels
+	htmlBarWidth = 400
+)
+
+// htmlReport The content of the HTML dashboard
+type htmlReport struct {
+	Date   string
+	Groups []htmlGroup
+}
+
+// htmlGroup The charts of a group on the HTML dashboard
+type htmlGroup struct {
+	Name                string
+	Projects, Languages int
+	// Unit Unit of the bars, "bytes" or "lines"
+	Unit string
+	// Slices Pie chart of the number of projects each language is the top language of
+	Slices []htmlSlice
+	// Bars Bar chart of the languages' shares of the group's total lines
+	Bars       []htmlBar
+	BarsHeight int
+}
+
+type htmlSlice struct {
+	Language, Color string
+	Count           int
+	Percent         float64
+	// Path SVG path of the slice, empty when the slice is the whole pie
+	Path string
+}
+
+type htmlBar struct {
+	Language, Color string
+	Lines           int
+	Percent         float64
+	Y, Width        float64
+}
+
+var htmlTemplate = template.Must(template.New("index.html").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>CNCF Programming Language Statistics</title>
+{{template "style"}}
+</head>
+<body>
+<h1>CNCF Programming Language Statistics</h1>
+<p>Generated {{.Date}}.</p>
+{{range .Groups}}
+<section>
+<h2>{{.Name}}</h2>
+<p>{{.Projects}} projects, {{.Languages}} languages.</p>
+{{template "charts" .}}
+</section>
+{{end}}
+</body>
+</html>
+{{define "charts"}}<div>
+<h3>Top language of projects</h3>
+<svg width="200" height="200" viewBox="0 0 200 200" role="img">
+{{range .Slices}}{{if .Path}}<path d="{{.Path}}" fill="{{.Color}}"><title>{{.Language}}: {{.Count}}</title></path>{{else}}<circle cx="100" cy="100" r="90" fill="{{.Color}}"><title>{{.Language}}: {{.Count}}</title></circle>{{end}}
+{{end}}</svg>
+<ul>
+{{range .Slices}}<li><svg width="10" height="10"><rect width="10" height="10" fill="{{.Color}}"/></svg> {{.Language}}: {{.Count}} ({{printf "%.1f" .Percent}}%)</li>
+{{end}}</ul>
+</div>
+<div>
+<h3>Share of total {{.Unit}}</h3>
+<svg width="640" height="{{.BarsHeight}}" viewBox="0 0 640 {{.BarsHeight}}" role="img">
+{{range .Bars}}<text x="0" y="{{printf "%.0f" .Y}}" dy="16">{{.Language}}</text>
+<rect x="130" y="{{printf "%.0f" .Y}}" width="{{printf "%.1f" .Width}}" height="20" fill="{{.Color}}"><title>{{.Language}}: {{.Lines}} {{$.Unit}}</title></rect>
+<text x="{{printf "%.1f" .Width}}" y="{{printf "%.0f" .Y}}" dx="136" dy="16">{{printf "%.1f" .Percent}}%</text>
+{{end}}</svg>
+</div>{{end}}{{define "style"}}<style>
+body { font-family: sans-serif; margin: 2em auto; max-width: 1000px; color: #24292f; }
+section { display: flex; flex-wrap: wrap; gap: 2em; align-items: flex-start; margin-bottom: 3em; }
+h2 { width: 100%; margin-bottom: 0; }
+ul { list-style: none; padding: 0; font-size: 0.9em; }
+text { font-size: 12px; }
+</style>{{end}}`))
+
+// writeHTMLReport Renders a self-contained dashboard charting the results of every group to path
+func writeHTMLReport(path string, groupNames []string, results []stats.Result, colors languageColors, perm os.FileMode) error {
+	b, err := renderHTMLReport(groupNames, results, colors)
+	if err != nil {
+		return err
+	}
+	return writeFile(path, b, perm)
+}
+
+// renderHTMLReport Renders the dashboard charting the results of every group
+func renderHTMLReport(groupNames []string, results []stats.Result, colors languageColors) ([]byte, error) {
+	report := htmlReport{Date: time.Now().UTC().Format("2006-01-02")}
+	for i, result := range results {
+		report.Groups = append(report.Groups, newHTMLGroup(groupNames[i], result, colors))
+	}
+
+	var b bytes.Buffer
+	if err := htmlTemplate.Execute(&b, report); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+func newHTMLGroup(repoGroup string, result stats.Result, colors languageColors) htmlGroup {
+	g := htmlGroup{
+		Name:      strings.ToUpper(repoGroup[:1]) + repoGroup[1:],
+		Languages: len(result.Totals()),
+		Unit:      result.Unit(),
+	}
+
+	for _, count := range result.TopLanguage {
+		g.Projects += count
+	}
+	angle := -math.Pi / 2
+	for _, l := range stats.SortLanguageMap(result.TopLanguage) {
+		if l.Lines == 0 {
+			continue
+		}
+		s := htmlSlice{Language: l.Language, Color: colors.color(l.Language), Count: l.Lines}
+		s.Percent = float64(l.Lines) * 100 / float64(g.Projects)
+		if l.Lines < g.Projects {
+			end := angle + 2*math.Pi*float64(l.Lines)/float64(g.Projects)
+			s.Path = pieSlicePath(100, 100, 90, angle, end)
+			angle = end
+		}
+		g.Slices = append(g.Slices, s)
+	}
+
+	var total, other, longest int
+	sorted := stats.SortLanguageMap(result.Totals())
+	for i, l := range sorted {
+		total += l.Lines
+		if i >= htmlBars {
+			other += l.Lines
+		}
+	}
+	if len(sorted) > htmlBars {
+		sorted = append(sorted[:htmlBars:htmlBars], stats.LanguageLines{Language: "Other", Lines: other})
+	}
+	for _, l := range sorted {
+		if l.Lines > longest {
+			longest = l.Lines
+		}
+	}
+	for i, l := range sorted {
+		bar := htmlBar{Language: l.Language, Color: colors.color(l.Language), Lines: l.Lines, Y: float64(i * htmlBarHeight)}
+		if i == htmlBars {
+			bar.Color = "#cccccc"
+		}
+		if total > 0 {
+			bar.Percent = float64(l.Lines) * 100 / float64(total)
+		}
+		if longest > 0 {
+			bar.Width = float64(l.Lines) * htmlBarWidth / float64(longest)
+		}
+		g.Bars = append(g.Bars, bar)
+	}
+	g.BarsHeight = len(g.Bars) * htmlBarHeight
+	return g
+}
+
+// pieSlicePath The SVG path of the slice of the circle at cx, cy with radius r from angle start to end in radians
+func pieSlicePath(cx, cy, r, start, end float64) string {
+	var large int
+	if end-start > math.Pi {
+		large = 1
+	}
+	return fmt.Sprintf("M %.2f %.2f L %.2f %.2f A %.2f %.2f 0 %d 1 %.2f %.2f Z",
+		cx, cy, cx+r*math.Cos(start), cy+r*math.Sin(start), r, r, large, cx+r*math.Cos(end), cy+r*math.Sin(end))
+}
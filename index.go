@@ -0,0 +1,117 @@
+package main
+
+import (
+	"cncf-language-stats/stats"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// IndexEntry Describes a generated result file in index.json
+type IndexEntry struct {
+	// File Path of the result file relative to the index
+	File      string `json:"file"`
+	Group     string `json:"group"`
+	Date      string `json:"date"`
+	SHA256    string `json:"sha256"`
+	Projects  int    `json:"projects"`
+	Languages int    `json:"languages"`
+}
+
+func newIndexEntry(file, repoGroup string, result stats.Result) (IndexEntry, error) {
+	f, err := os.ReadFile(file)
+	if err != nil {
+		return IndexEntry{}, err
+	}
+	sum := sha256.Sum256(f)
+
+	var projects int
+	for _, count := range result.TopLanguage {
+		projects += count
+	}
+	return IndexEntry{
+		File:      file,
+		Group:     repoGroup,
+		Date:      resultFileDate(file),
+		SHA256:    hex.EncodeToString(sum[:]),
+		Projects:  projects,
+		Languages: len(result.Totals()),
+	}, nil
+}
+
+// updateIndex Adds the entries to the index at path, replacing existing entries for the same files
+func updateIndex(path string, entries []IndexEntry, perm os.FileMode) error {
+	var index []IndexEntry
+	f, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if err == nil {
+		if err := json.Unmarshal(f, &index); err != nil {
+			return fmt.Errorf("parsing index %s: %w", path, err)
+		}
+	}
+
+	dir, err := filepath.Abs(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	files := make(map[string]int, len(index))
+	for i, entry := range index {
+		files[entry.File] = i
+	}
+	for _, entry := range entries {
+		file, err := filepath.Abs(entry.File)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, file)
+		if err != nil {
+			return err
+		}
+		entry.File = filepath.ToSlash(rel)
+		if i, ok := files[entry.File]; ok {
+			index[i] = entry
+			continue
+		}
+		files[entry.File] = len(index)
+		index = append(index, entry)
+	}
+
+	sort.Slice(index, func(i, j int) bool {
+		if index[i].Date != index[j].Date {
+			return index[i].Date < index[j].Date
+		}
+		return index[i].Group < index[j].Group
+	})
+	b, err := json.MarshalIndent(index, "", " ")
+	if err != nil {
+		return err
+	}
+	return writeFile(path, b, perm)
+}
+
+// resultFileDate The YYYY-MM-DD date a result file name starts with
+func resultFileDate(file string) string {
+	base := filepath.Base(file)
+	if len(base) < len("2006-01-02") {
+		return ""
+	}
+	return base[:len("2006-01-02")]
+}
+
+// resultFileGroup The group of a <date>-<group>.json result file name
+func resultFileGroup(file string) string {
+	base := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+	if len(base) <= len("2006-01-02-") {
+		return ""
+	}
+	return base[len("2006-01-02-"):]
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"cncf-language-stats/stats"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// interruptedError Reports a run stopped by a signal or the run timeout and the projects of every group it did
+// not get to
+type interruptedError struct {
+	// err The context's error, context.DeadlineExceeded once the run timed out
+	err error
+	// remaining The names of the remaining projects of every group
+	remaining map[string][]string
+	// urls The URLs of every project of the interrupted groups
+	urls map[string]map[string]stats.Project
+}
+
+func (e *interruptedError) Error() string {
+	var n int
+	for _, names := range e.remaining {
+		n += len(names)
+	}
+	if errors.Is(e.err, context.DeadlineExceeded) {
+		return fmt.Sprintf("timed out with %d projects remaining", n)
+	}
+	return fmt.Sprintf("interrupted with %d projects remaining", n)
+}
+
+func (e *interruptedError) Unwrap() error {
+	return e.err
+}
+
+// add Records the remaining projects of a group from its collection error, a group not collected for another
+// reason remains whole
+func (e *interruptedError) add(group string, projects map[string]stats.Project, err error, collected bool) {
+	var remaining []string
+	var collectErr *stats.InterruptedError
+	switch {
+	case errors.As(err, &collectErr):
+		remaining = collectErr.Remaining
+	case !collected:
+		for name := range projects {
+			remaining = append(remaining, name)
+		}
+		sort.Strings(remaining)
+	}
+	if len(remaining) > 0 {
+		e.remaining[group] = remaining
+		e.urls[group] = projects
+	}
+}
+
+// printRemaining Lists the remaining projects of every group with their URLs
+func (e *interruptedError) printRemaining(w io.Writer) {
+	groups := make([]string, 0, len(e.remaining))
+	for group := range e.remaining {
+		groups = append(groups, group)
+	}
+	sort.Strings(groups)
+	fmt.Fprintf(w, "%s:\n", strings.ToUpper(e.Error()[:1])+e.Error()[1:])
+	for _, group := range groups {
+		for _, name := range e.remaining[group] {
+			fmt.Fprintf(w, "  %s/%s: %s\n", group, name, strings.Join(e.urls[group][name].URLs, ", "))
+		}
+	}
+}
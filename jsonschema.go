@@ -0,0 +1,337 @@
+package main
+
+import (
+	"cncf-language-stats/stats"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+//go:generate go run . validate -write-schemas schemas
+
+// schemaFiles The JSON Schemas of the result files and repos.yaml, generated from the types by go generate so that
+// consumers can code against them
+//
+//go:embed schemas/*.schema.json
+var schemaFiles embed.FS
+
+// schemaBaseURL The base of the $id of every schema
+const schemaBaseURL = "https://github.com/sgloutnikov/cncf-language-stats/schemas/"
+
+// schemas The schema documents by the name validate -schema takes
+var schemas = map[string]func() map[string]interface{}{
+	"result": resultSchema,
+	"repos":  reposSchema,
+}
+
+// resultSchema The JSON Schema of stats.Result, derived from its json tags
+func resultSchema() map[string]interface{} {
+	s := typeSchema(reflect.TypeOf(stats.Result{}))
+	s["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	s["$id"] = schemaBaseURL + "result.schema.json"
+	s["title"] = "CNCF language stats result"
+	s["description"] = fmt.Sprintf("The aggregated language stats of a project group, schema version %d", stats.SchemaVersion)
+	props := s["properties"].(map[string]interface{})
+	props["schemaVersion"] = map[string]interface{}{"type": "integer", "minimum": 1, "maximum": stats.SchemaVersion}
+	return s
+}
+
+// reposSchema The JSON Schema of repos.yaml, which stats.Project decodes itself
+func reposSchema() map[string]interface{} {
+	url := map[string]interface{}{"type": "string", "pattern": "^https://(github\\.com|gitlab\\.com)/[^/]+(/.+)?$"}
+	urls := map[string]interface{}{"oneOf": []interface{}{
+		url,
+		map[string]interface{}{"type": "array", "items": url, "minItems": 1},
+	}}
+	project := map[string]interface{}{"oneOf": []interface{}{
+		urls,
+		map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"url":          urls,
+				"maturityDate": map[string]interface{}{"type": "string", "pattern": "^[0-9]{4}-[0-9]{2}-[0-9]{2}$"},
+			},
+			"required":             []interface{}{"url"},
+			"additionalProperties": false,
+		},
+	}}
+	group := map[string]interface{}{"type": []interface{}{"object", "null"}, "additionalProperties": project}
+	return map[string]interface{}{
+		"$schema":     "https://json-schema.org/draft/2020-12/schema",
+		"$id":         schemaBaseURL + "repos.schema.json",
+		"title":       "CNCF language stats repos.yaml",
+		"description": "The projects of every CNCF maturity group, the custom projects they are compared with and the weights of their repos",
+		"type":        "object",
+		"properties": map[string]interface{}{
+			"Graduated":  group,
+			"Incubating": group,
+			"Sandbox":    group,
+			"Custom":     group,
+			"weights": map[string]interface{}{
+				"type":                 "object",
+				"propertyNames":        map[string]interface{}{"pattern": "^[^/]+/.+$"},
+				"additionalProperties": map[string]interface{}{"type": "number", "minimum": 0},
+			},
+		},
+		"additionalProperties": false,
+	}
+}
+
+// typeSchema The JSON Schema of the JSON encoding/json writes for a value of type t
+func typeSchema(t reflect.Type) map[string]interface{} {
+	switch t.Kind() {
+	case reflect.Pointer:
+		s := typeSchema(t.Elem())
+		if kind, ok := s["type"].(string); ok {
+			s["type"] = []interface{}{kind, "null"}
+		}
+		return s
+	case reflect.Struct:
+		props := make(map[string]interface{})
+		var required []interface{}
+		structProperties(t, props, &required)
+		s := map[string]interface{}{"type": "object", "properties": props}
+		if len(required) > 0 {
+			s["required"] = required
+		}
+		return s
+	case reflect.Map:
+		return map[string]interface{}{"type": []interface{}{"object", "null"}, "additionalProperties": typeSchema(t.Elem())}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": []interface{}{"array", "null"}, "items": typeSchema(t.Elem())}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	}
+	return map[string]interface{}{}
+}
+
+// structProperties Adds the schemas of the struct's fields by their JSON key to props, including those of
+// embedded structs. Fields without omitempty are required.
+func structProperties(t reflect.Type, props map[string]interface{}, required *[]interface{}) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if !f.IsExported() || tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			structProperties(f.Type, props, required)
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		props[name] = typeSchema(f.Type)
+		if !strings.Contains(options, "omitempty") {
+			*required = append(*required, name)
+		}
+	}
+}
+
+// loadSchema The embedded schema of the name
+func loadSchema(name string) (map[string]interface{}, error) {
+	b, err := schemaFiles.ReadFile("schemas/" + name + ".schema.json")
+	if err != nil {
+		return nil, err
+	}
+	var s map[string]interface{}
+	if err := json.Unmarshal(b, &s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// validateSchema Checks the decoded JSON or YAML value against the schema and returns every violation with the
+// path of the offending value. It implements the keywords the schemas of this package use.
+func validateSchema(schema map[string]interface{}, value interface{}, path string) []string {
+	var violations []string
+	fail := func(format string, a ...interface{}) {
+		violations = append(violations, path+": "+fmt.Sprintf(format, a...))
+	}
+
+	if alternatives, ok := schema["oneOf"].([]interface{}); ok {
+		var matched int
+		// closest The violations of the alternative of the value's type with the fewest, reported when none matches
+		var closest []string
+		for _, alt := range alternatives {
+			alt := alt.(map[string]interface{})
+			altViolations := validateSchema(alt, value, path)
+			if len(altViolations) == 0 {
+				matched++
+				continue
+			}
+			if !acceptsType(alt, value) {
+				continue
+			}
+			if closest == nil || len(altViolations) < len(closest) {
+				closest = altViolations
+			}
+		}
+		switch {
+		case matched == 0 && closest != nil:
+			return closest
+		case matched == 0:
+			fail("is %s, which none of the %d alternatives allows", jsonType(value), len(alternatives))
+		case matched > 1:
+			fail("must match exactly one of %d alternatives, matches %d", len(alternatives), matched)
+		}
+		return violations
+	}
+	if types, ok := schema["type"]; ok && !matchesType(types, value) {
+		fail("is %s, must be %s", jsonType(value), formatTypes(types))
+		return violations
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		props, _ := schema["properties"].(map[string]interface{})
+		for _, name := range schemaStrings(schema["required"]) {
+			if _, ok := v[name]; !ok {
+				fail("is missing %q", name)
+			}
+		}
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if names, ok := schema["propertyNames"].(map[string]interface{}); ok {
+				violations = append(violations, validateSchema(names, key, path+"."+key)...)
+			}
+			if prop, ok := props[key].(map[string]interface{}); ok {
+				violations = append(violations, validateSchema(prop, v[key], path+"."+key)...)
+				continue
+			}
+			switch extra := schema["additionalProperties"].(type) {
+			case bool:
+				if !extra {
+					fail("has the unknown key %q", key)
+				}
+			case map[string]interface{}:
+				violations = append(violations, validateSchema(extra, v[key], path+"."+key)...)
+			}
+		}
+	case []interface{}:
+		if min, ok := schema["minItems"].(float64); ok && float64(len(v)) < min {
+			fail("has %d items, must have at least %v", len(v), min)
+		}
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range v {
+				violations = append(violations, validateSchema(items, item, fmt.Sprintf("%s[%d]", path, i))...)
+			}
+		}
+	case string:
+		if pattern, ok := schema["pattern"].(string); ok {
+			if re, err := regexp.Compile(pattern); err != nil || !re.MatchString(v) {
+				fail("%q does not match %s", v, pattern)
+			}
+		}
+	default:
+		if n, ok := number(value); ok {
+			if min, ok := schema["minimum"].(float64); ok && n < min {
+				fail("is %v, must be at least %v", n, min)
+			}
+			if max, ok := schema["maximum"].(float64); ok && n > max {
+				fail("is %v, must be at most %v", n, max)
+			}
+		}
+	}
+	return violations
+}
+
+// acceptsType Reports whether the schema, or one of its alternatives, allows values of the value's type
+func acceptsType(schema map[string]interface{}, value interface{}) bool {
+	if alternatives, ok := schema["oneOf"].([]interface{}); ok {
+		for _, alt := range alternatives {
+			if acceptsType(alt.(map[string]interface{}), value) {
+				return true
+			}
+		}
+		return false
+	}
+	types, ok := schema["type"]
+	return !ok || matchesType(types, value)
+}
+
+// matchesType Reports whether the value is of the schema type or one of the schema types
+func matchesType(types interface{}, value interface{}) bool {
+	for _, t := range schemaStrings(types) {
+		switch actual := jsonType(value); {
+		case t == actual, t == "number" && actual == "integer":
+			return true
+		}
+	}
+	return false
+}
+
+// jsonType The JSON Schema type of a decoded JSON or YAML value
+func jsonType(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	}
+	if n, ok := number(value); ok {
+		if n == math.Trunc(n) && !math.IsInf(n, 0) {
+			return "integer"
+		}
+		return "number"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// number The value of a decoded JSON or YAML number
+func number(value interface{}) (float64, bool) {
+	switch n := value.(type) {
+	case float64:
+		return n, true
+	case int:
+		return float64(n), true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// schemaStrings The strings of a schema keyword that is a string or an array of strings
+func schemaStrings(v interface{}) []string {
+	switch v := v.(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		s := make([]string, 0, len(v))
+		for _, item := range v {
+			if str, ok := item.(string); ok {
+				s = append(s, str)
+			}
+		}
+		return s
+	}
+	return nil
+}
+
+func formatTypes(types interface{}) string {
+	return strings.Join(schemaStrings(types), " or ")
+}
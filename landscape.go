@@ -0,0 +1,107 @@
+package main
+
+import (
+	"cncf-language-stats/stats"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// syncLandscape Reads the projects from a CNCF landscape.yml file or http(s) URL, prints how they differ from
+// the projects in reposPath and, when write is set, replaces reposPath with them
+func syncLandscape(source, reposPath string, write bool, perm os.FileMode) error {
+	landscape, err := loadLandscape(source)
+	if err != nil {
+		return err
+	}
+
+	current, err := stats.LoadRepos(reposPath)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	printProjectChanges("Graduated", current.Graduated, landscape.Graduated)
+	printProjectChanges("Incubating", current.Incubating, landscape.Incubating)
+	printProjectChanges("Sandbox", current.Sandbox, landscape.Sandbox)
+
+	if !write {
+		return nil
+	}
+	// The landscape lists neither the reference projects nor the weights, which are kept
+	landscape.Custom, landscape.Weights = current.Custom, current.Weights
+	b, err := stats.MarshalRepos(landscape, time.Now())
+	if err != nil {
+		return err
+	}
+	return writeFile(reposPath, b, perm)
+}
+
+// loadLandscape Reads the projects from a CNCF landscape.yml file or http(s) URL
+func loadLandscape(source string) (stats.Repos, error) {
+	var f []byte
+	var err error
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		f, err = fetchURL(source)
+	} else {
+		f, err = os.ReadFile(source)
+	}
+	if err != nil {
+		return stats.Repos{}, err
+	}
+	return stats.ParseLandscape(f)
+}
+
+// fixRepoURLs Replaces the URLs of renamed repos in reposPath with their current URLs
+func fixRepoURLs(reposPath string, renamed map[string]string, perm os.FileMode) error {
+	if len(renamed) == 0 {
+		return nil
+	}
+	repos, err := stats.LoadRepos(reposPath)
+	if err != nil {
+		return err
+	}
+	n := repos.ReplaceURLs(renamed)
+	if n == 0 {
+		return nil
+	}
+	b, err := stats.MarshalRepos(repos, time.Now())
+	if err != nil {
+		return err
+	}
+	slog.Info("Replacing renamed repo URLs", "count", n, "path", reposPath)
+	return writeFile(reposPath, b, perm)
+}
+
+// printProjectChanges Prints the projects of a group that were added, removed or changed their URLs
+func printProjectChanges(repoGroup string, current, landscape map[string]stats.Project) {
+	var changes []string
+	for name, p := range landscape {
+		c, ok := current[name]
+		switch {
+		case !ok:
+			changes = append(changes, fmt.Sprintf("+ %s: %s", name, strings.Join(p.URLs, ", ")))
+		case strings.Join(c.URLs, " ") != strings.Join(p.URLs, " "):
+			changes = append(changes, fmt.Sprintf("~ %s: %s → %s", name, strings.Join(c.URLs, ", "), strings.Join(p.URLs, ", ")))
+		}
+	}
+	for name, p := range current {
+		if _, ok := landscape[name]; !ok {
+			changes = append(changes, fmt.Sprintf("- %s: %s", name, strings.Join(p.URLs, ", ")))
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i][2:] < changes[j][2:]
+	})
+
+	fmt.Printf("Landscape changes for %s:\n", repoGroup)
+	if len(changes) == 0 {
+		fmt.Println("  no changes")
+	}
+	for _, c := range changes {
+		fmt.Println(" ", c)
+	}
+}
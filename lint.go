@@ -0,0 +1,173 @@
+package main
+
+import (
+	"cncf-language-stats/stats"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// runLintRepos Checks a repos.yaml for malformed entries and duplicates, repos that cannot be reached, URLs
+// outside GitHub and projects missing from the CNCF landscape, returning an error when it finds any
+func runLintRepos(args []string) error {
+	fs := flag.NewFlagSet("lint-repos", flag.ExitOnError)
+	reposPath := fs.String("repos", envOr("CNCF_STATS_REPOS", "repos.yaml"), "Path of the repos.yaml to check, or set CNCF_STATS_REPOS")
+	landscapeURL := fs.String("landscape-url", stats.LandscapeURL, "CNCF landscape.yml file or http(s) URL the projects are checked against, empty to skip")
+	reachable := fs.Bool("reachable", true, "Check that every repo can be reached with a HEAD request to its URL")
+	githubOnly := fs.Bool("github-only", false, "Report URLs outside github.com, e.g. GitLab repos")
+	workers := fs.Int("workers", 8, "Number of concurrent HEAD requests")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: cncf-language-stats lint-repos [flags]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	if *workers < 1 {
+		*workers = 1
+	}
+
+	b, err := os.ReadFile(*reposPath)
+	if err != nil {
+		return err
+	}
+	repos, errs := stats.CheckRepos(b)
+	var problems []string
+	for _, err := range errs {
+		problems = append(problems, err.Error())
+	}
+
+	type entry struct {
+		group, name string
+		project     stats.Project
+	}
+	var entries []entry
+	for _, g := range []struct {
+		name     string
+		projects map[string]stats.Project
+	}{
+		{"Graduated", repos.Graduated},
+		{"Incubating", repos.Incubating},
+		{"Sandbox", repos.Sandbox},
+	} {
+		for name, p := range g.projects {
+			entries = append(entries, entry{g.name, name, p})
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return strings.ToLower(entries[i].name) < strings.ToLower(entries[j].name)
+	})
+
+	if *githubOnly {
+		for _, e := range entries {
+			for _, u := range e.project.URLs {
+				if parsed, err := url.Parse(u); err == nil && parsed.Host != "github.com" {
+					problems = append(problems, fmt.Sprintf("%s: %s is not on GitHub", e.name, u))
+				}
+			}
+		}
+	}
+
+	if *landscapeURL != "" {
+		landscape, err := loadLandscape(*landscapeURL)
+		if err != nil {
+			return err
+		}
+		groups := make(map[string]string)
+		for group, projects := range map[string]map[string]stats.Project{
+			"Graduated":  landscape.Graduated,
+			"Incubating": landscape.Incubating,
+			"Sandbox":    landscape.Sandbox,
+		} {
+			for name := range projects {
+				groups[strings.ToLower(name)] = group
+			}
+		}
+		for _, e := range entries {
+			switch group, ok := groups[strings.ToLower(e.name)]; {
+			case !ok:
+				problems = append(problems, fmt.Sprintf("%s is not a CNCF project in the landscape", e.name))
+			case group != e.group:
+				problems = append(problems, fmt.Sprintf("%s is listed in %s but is %s in the landscape", e.name, e.group, group))
+			}
+		}
+	}
+
+	if *reachable {
+		var urls []string
+		names := make(map[string]string)
+		for _, e := range entries {
+			for _, u := range e.project.URLs {
+				if _, ok := names[u]; !ok {
+					urls = append(urls, u)
+					names[u] = e.name
+				}
+			}
+		}
+		for i, problem := range checkReachable(urls, *workers) {
+			if problem != "" {
+				problems = append(problems, fmt.Sprintf("%s: %s %s", names[urls[i]], urls[i], problem))
+			}
+		}
+	}
+
+	if len(problems) == 0 {
+		fmt.Printf("%s has no problems\n", *reposPath)
+		return nil
+	}
+	fmt.Printf("%d problems in %s:\n", len(problems), *reposPath)
+	for _, p := range problems {
+		fmt.Println(" ", p)
+	}
+	return fmt.Errorf("%w, %d found", errRepoProblems, len(problems))
+}
+
+// checkReachable Sends a HEAD request to every URL with workers concurrent requests and describes why each URL
+// cannot be reached, empty for reachable URLs. Redirects are not followed, a renamed repo is reported as moved.
+func checkReachable(urls []string, workers int) []string {
+	client := &http.Client{
+		Timeout: 30 * time.Second,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	problems := make([]string, len(urls))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				resp, err := client.Head(urls[i])
+				if err != nil {
+					problems[i] = fmt.Sprintf("cannot be reached: %v", err)
+					continue
+				}
+				resp.Body.Close()
+				switch {
+				case resp.StatusCode >= 300 && resp.StatusCode < 400:
+					problems[i] = "moved to " + resp.Header.Get("Location")
+				case resp.StatusCode == http.StatusNotFound:
+					problems[i] = "does not exist or is private"
+				case resp.StatusCode >= 400:
+					problems[i] = "cannot be reached: " + resp.Status
+				}
+			}
+		}()
+	}
+	for i := range urls {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return problems
+}
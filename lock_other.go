@@ -0,0 +1,14 @@
+//go:build !unix
+
+package main
+
+import "os"
+
+// lockFile Advisory locks are only used on unix, elsewhere appends rely on O_APPEND alone
+func lockFile(f *os.File) error {
+	return nil
+}
+
+func unlockFile(f *os.File) error {
+	return nil
+}
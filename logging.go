@@ -0,0 +1,20 @@
+package main
+
+import (
+	"io"
+	"log/slog"
+)
+
+// setupLogging Logs at debug level when verbose and as JSON lines for the "json" format, both the slog
+// records and the output of the log package. The "text" format keeps the log package's lines.
+func setupLogging(verbose bool, format string, w io.Writer) {
+	level := slog.LevelInfo
+	if verbose {
+		level = slog.LevelDebug
+	}
+	if format == "json" {
+		slog.SetDefault(slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level})))
+		return
+	}
+	slog.SetLogLoggerLevel(level)
+}
@@ -1,167 +1,1195 @@
 package main
 
 import (
+	"cncf-language-stats/stats"
 	"context"
 	"encoding/json"
+	"errors"
 	"flag"
-	"github.com/google/go-github/v47/github"
+	"fmt"
+	"github.com/robfig/cron/v3"
 	"golang.org/x/oauth2"
-	"gopkg.in/yaml.v3"
 	"log"
+	"log/slog"
 	"os"
+	"os/signal"
+	"path"
+	"path/filepath"
+	"regexp"
 	"sort"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
 )
 
-var GitHubToken string
+// options Command line options that select what is processed and reported on each run
+type options struct {
+	graduated, incubating, sandbox bool
+	// all Processes every group and also saves the combined result of all projects
+	all                   bool
+	compare, rankBy       string
+	watch, parallelGroups bool
+	index                 string
+	uniqueLanguages       bool
+	failOnLeaderChange    bool
+	// html Writes the dashboard of the processed groups, drawing languages with colors
+	html   bool
+	colors languageColors
+	// badges Writes shields.io endpoint badges of the processed groups
+	badges bool
+	// summary Writes summary.json holding the results of the processed groups and their combination
+	summary bool
+	// feed Writes an Atom feed of the saved runs, feedURL is the URL the results are hosted at
+	feed    bool
+	feedURL string
+	// upload Uploads the written files to object storage when set
+	upload *uploader
+	// exporters Receive the result of every group, see stats.RegisterExporter
+	exporters []stats.Exporter
+	// notify Posts a summary of every run to a webhook when set
+	notify *notifier
+	// email Emails the report of every run when set
+	email *mailer
+	// reposPath Path of the repos.yaml listing the projects
+	reposPath string
+	// fixRepos Replaces the URLs of renamed repos in the repos file
+	fixRepos bool
+	// orgs The GitHub orgs collected as groups of their repos instead of the repos file groups
+	orgs []string
+	// custom Also collects the custom projects, of the repos file's Custom section or of every section of
+	// customRepos when set, and compares the groups with them
+	custom      bool
+	customRepos string
+	// projects, match Restrict the processed projects to those named, compared case-insensitively,
+	// or matching the pattern when either is set
+	projects map[string]bool
+	match    *regexp.Regexp
+}
 
-func init() {
-	token, ok := os.LookupEnv("GITHUB_TOKEN")
-	if !ok {
-		log.Fatal("GITHUB_TOKEN ENV variable required")
+// selects Reports whether the project is processed
+func (o options) selects(project string) bool {
+	if o.projects == nil && o.match == nil {
+		return true
 	}
-	GitHubToken = token
+	return o.projects[strings.ToLower(project)] || o.match != nil && o.match.MatchString(project)
 }
 
-type Repos struct {
-	Graduated  map[string]string `yaml:"Graduated"`
-	Incubating map[string]string `yaml:"Incubating"`
-	Sandbox    map[string]string `yaml:"Sandbox"`
+// selectProjects The projects of a group that are processed
+func (o options) selectProjects(projects map[string]stats.Project) map[string]stats.Project {
+	if o.projects == nil && o.match == nil {
+		return projects
+	}
+	selected := make(map[string]stats.Project)
+	for name, p := range projects {
+		if o.selects(name) {
+			selected[name] = p
+		}
+	}
+	return selected
 }
 
-type RepoStats struct {
-	GitHubClient *github.Client
-	Throttle     time.Duration
-	JSONResult
+// groupNames The names of the selected groups
+func (o options) groupNames() []string {
+	var names []string
+	if o.graduated {
+		names = append(names, "graduated")
+	}
+	if o.incubating {
+		names = append(names, "incubating")
+	}
+	if o.sandbox {
+		names = append(names, "sandbox")
+	}
+	return names
 }
 
-type JSONResult struct {
-	TopLanguage map[string]int `json:"topLanguage"`
-	TotalLines  map[string]int `json:"totalLines"`
+// subcommands The commands run instead of collecting when named as the first argument, given the remaining ones.
+// Every one has its own flags, collect takes the flags also accepted without a command.
+var subcommands = map[string]func(args []string) error{
+	"backfill":   runBackfill,
+	"diff":       runDiff,
+	"lint-repos": runLintRepos,
+	"migrate":    runMigrate,
+	"report":     runReport,
+	"serve":      runServe,
+	"site":       runSite,
+	"trend":      runTrend,
+	"validate":   runValidate,
 }
 
-type LanguageLines struct {
-	Language string
-	Lines    int
+// usage Lists the commands before the flags of collect
+func usage() {
+	names := []string{"collect"}
+	for name := range subcommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "usage: cncf-language-stats [command] [flags]\n\ncommands: %s\n", strings.Join(names, ", "))
+	fmt.Fprintln(out, "run \"cncf-language-stats <command> -h\" for the flags of a command\n\nflags of collect, the default command:")
+	flag.PrintDefaults()
 }
 
-// LanguageLinesList A slice of LanguageLinesList that implements sort.Interface to sort by values
-type LanguageLinesList []LanguageLines
-
-func (l LanguageLinesList) Len() int           { return len(l) }
-func (l LanguageLinesList) Swap(i, j int)      { l[i], l[j] = l[j], l[i] }
-func (l LanguageLinesList) Less(i, j int) bool { return l[i].Lines < l[j].Lines }
-
 func main() {
-	var graduated, incubating, sandbox bool
-	flag.BoolVar(&graduated, "graduated", false, "Process graduated projects")
-	flag.BoolVar(&incubating, "incubating", false, "Process incubating projects")
-	flag.BoolVar(&sandbox, "sandbox", false, "Process sandbox projects")
-	flag.Parse()
+	if len(os.Args) > 1 {
+		if subcommand, ok := subcommands[os.Args[1]]; ok {
+			if err := subcommand(os.Args[2:]); err != nil {
+				log.Fatal(err)
+			}
+			return
+		}
+		if os.Args[1] == "collect" {
+			os.Args = append(os.Args[:1:1], os.Args[2:]...)
+		}
+	}
+	flag.Usage = usage
 
-	ts := oauth2.StaticTokenSource(
-		&oauth2.Token{AccessToken: GitHubToken},
-	)
-	tc := oauth2.NewClient(context.Background(), ts)
-	results := RepoStats{
-		GitHubClient: github.NewClient(tc),
-		Throttle:     3 * time.Second,
+	var opts options
+	var concentration, detailed, logRequests, topLanguages, validateOutput, normalize, latest bool
+	var keyTopLanguage, keyTotalBytes, keyTotalLines string
+	var maxLanguages, workers, minLines, topN int
+	var aggregation string
+	var minPercent, presencePercent float64
+	var languagePresence, languageProjects bool
+	var maxAttempts int
+	var retryBackoff, requestTimeout, runTimeout, throttle time.Duration
+	var maxRequestsPerHour, reserveQuota int
+	var concurrencyPerHost, maxRequestsPerHost string
+	var retryJitter float64
+	var csvDetail string
+	var rolling int
+	var landscape, writeRepos, trend, dryRunOnly bool
+	var landscapeURL string
+	var languageColorsPath, serveAddr string
+	var cronSchedule string
+	var checkpointPath, storeSpec, cacheDir string
+	var recordDir, replayDir, incrementalPath string
+	var force bool
+	var emailTo, emailFrom, smtpAddr string
+	var exportDests string
+	var uploadDest, notifyWebhook, api, mode, cloneDir, progressMode string
+	var outDir, projectNames, match, cloneExcludes, excludeRepos string
+	var skipForks, skipMirrors, skipArchived bool
+	var maxRepoSize int
+	var appID, appInstallationID, appKeyPath string
+	var resume, checkRepos, metadata, contributors, commitActivity, goModules, releases, licenses, verbose bool
+	var logFormat string
+	var configPath, profile, format, tokenFile string
+	var printVersion bool
+	fileMode := fileModeFlag(0644)
+	flag.StringVar(&opts.reposPath, "repos", envOr("CNCF_STATS_REPOS", "repos.yaml"), "Path of the repos.yaml listing the projects, or set CNCF_STATS_REPOS")
+	flag.StringVar(&outDir, "out", envOr("CNCF_STATS_OUT", "results"), "Directory the results are saved in and read from, created when missing, or set CNCF_STATS_OUT")
+	flag.StringVar(&projectNames, "project", "", "Only process the comma separated projects, e.g. kubernetes,envoy")
+	flag.StringVar(&match, "match", "", "Only process the projects whose name matches the regular expression, e.g. 'arg.*'")
+	flag.BoolVar(&opts.graduated, "graduated", false, "Process graduated projects")
+	flag.BoolVar(&opts.incubating, "incubating", false, "Process incubating projects")
+	flag.BoolVar(&opts.sandbox, "sandbox", false, "Process sandbox projects")
+	flag.Func("org", "Comma separated GitHub orgs whose every repo is collected as a project, each org saved as <date>-<org>.json, instead of the repos file groups", func(s string) error {
+		opts.orgs = append(opts.orgs, splitList(s)...)
+		return nil
+	})
+	flag.BoolVar(&opts.all, "all", false, "Process every group and save their combined result as <date>-all.json, the default without a group flag")
+	flag.StringVar(&opts.compare, "compare", "", "Baseline result file or http(s) URL to compare against, {group} is replaced with the group name")
+	flag.BoolVar(&opts.failOnLeaderChange, "fail-on-leader-change", false, "Exit with status 3 when a group's top language differs from the -compare baseline")
+	flag.StringVar(&opts.rankBy, "rank-by", "count", "Rank languages by project \"count\" or total \"bytes\" when comparing")
+	flag.BoolVar(&opts.watch, "watch", false, "Re-run whenever the -repos or -config file changes until interrupted, reloading the config before every rerun")
+	flag.StringVar(&cronSchedule, "schedule", "", "Keep running and collect on this cron schedule, e.g. \"0 3 * * 1\" for Mondays at 03:00")
+	flag.StringVar(&opts.index, "index", "", "Path of an index.json listing the generated result files, updated after every run")
+	flag.BoolVar(&opts.uniqueLanguages, "unique-languages", false, "Save the languages found in only one of the processed groups")
+	flag.BoolVar(&opts.parallelGroups, "parallel-groups", false, "Process the selected groups concurrently")
+	flag.BoolVar(&concentration, "concentration", false, "Report the share of each language's bytes coming from its largest project")
+	flag.BoolVar(&normalize, "normalize", false, "Report the share of projects containing each language and its average bytes or lines per project, which compare across groups")
+	flag.BoolVar(&detailed, "detailed", false, "Include per project details with their language bytes and percentages in the results")
+	flag.StringVar(&aggregation, "aggregation", "", "Also score the languages with a methodology, "+strings.Join(stats.AggregatorNames(), ", "))
+	flag.IntVar(&topN, "top", 1, "Record the top N languages of every project and score languages by rank among them, 1/n for the n-th")
+	flag.BoolVar(&languagePresence, "language-presence", false, "Count the projects containing each language at all in languagePresence, not just those it is the top language of")
+	flag.BoolVar(&languageProjects, "language-projects", false, "List the projects of every top language in projectsByLanguage and, with -language-presence, those containing every language in projectsContaining")
+	flag.Float64Var(&presencePercent, "presence-percent", 0, "Only count a language in languagePresence with at least this percentage of a project")
+	flag.Float64Var(&minPercent, "min-percent", 0, "Sum up the languages below this percentage of a project as \"Other\"")
+	flag.IntVar(&minLines, "min-lines", 0, "Sum up the languages below this many bytes, or lines with -mode clone, of a project as \"Other\"")
+	flag.IntVar(&workers, "workers", 1, "Fetch N projects concurrently from a host without a -concurrency-per-host cap, every capped host has as many workers as requests in flight")
+	flag.StringVar(&mode, "mode", "api", "How languages are measured, \"api\" for the bytes GitHub reports or \"clone\" to count code lines in shallow clones")
+	flag.StringVar(&cloneDir, "clone-dir", "", "Directory repos are cloned into with -mode clone, the temporary directory when empty")
+	flag.StringVar(&cloneExcludes, "clone-exclude", strings.Join(stats.DefaultCloneExcludes, ","), "Comma separated globs of vendored and generated files left out with -mode clone, overridden by linguist attributes in .gitattributes")
+	flag.StringVar(&tokenFile, "token-file", "", "File holding the GitHub token, or several separated by commas or lines, used instead of GITHUB_TOKEN")
+	flag.StringVar(&appID, "app-id", os.Getenv("GITHUB_APP_ID"), "Authenticate as this GitHub App instead of with GITHUB_TOKEN, or set GITHUB_APP_ID")
+	flag.StringVar(&appInstallationID, "app-installation-id", os.Getenv("GITHUB_APP_INSTALLATION_ID"), "Installation of the -app-id app, needed when it has several, or set GITHUB_APP_INSTALLATION_ID")
+	flag.StringVar(&appKeyPath, "app-private-key", os.Getenv("GITHUB_APP_PRIVATE_KEY_FILE"), "PEM file of the -app-id app's private key, or set GITHUB_APP_PRIVATE_KEY_FILE")
+	flag.StringVar(&api, "api", "rest", "GitHub API fetching the languages, \"rest\" with a request per repo or \"graphql\" batching 50 repos per query")
+	flag.IntVar(&maxAttempts, "max-attempts", 3, "Attempts per GitHub request failing with 5xx responses or network errors")
+	flag.DurationVar(&retryBackoff, "retry-backoff", time.Second, "Wait before retrying a failed request, doubled for every further retry")
+	flag.Float64Var(&retryJitter, "retry-jitter", 0.2, "Randomize retry waits by up to this fraction")
+	flag.DurationVar(&requestTimeout, "request-timeout", time.Minute, "Cancel and retry an API request attempt taking longer, 0 for no limit")
+	flag.DurationVar(&throttle, "throttle", 0, "Wait at least this long between GitHub API requests, 0 for no wait")
+	flag.IntVar(&maxRequestsPerHour, "max-requests-per-hour", 0, "Send at most N GitHub API requests an hour, spread evenly, 0 for no cap")
+	flag.StringVar(&concurrencyPerHost, "concurrency-per-host", "github.com=10,gitlab.com=5", "Comma separated host=N caps of the API requests in flight at once to each host")
+	flag.StringVar(&maxRequestsPerHost, "max-requests-per-hour-per-host", "", "Comma separated host=N caps of the API requests an hour to each host, e.g. gitlab.com=2000, overriding -max-requests-per-hour for github.com")
+	flag.IntVar(&reserveQuota, "reserve-quota", 0, "Pause until the rate limit resets once only N requests remain, leaving them to other jobs sharing the token")
+	flag.DurationVar(&runTimeout, "run-timeout", 0, "Stop a run taking longer like an interrupted one, 0 for no limit")
+	flag.IntVar(&maxLanguages, "max-languages-per-repo", 0, "Keep only the N largest languages of each repo, 0 for unlimited")
+	flag.BoolVar(&topLanguages, "top-language-map", false, "Save only a map of each project to its top language")
+	flag.BoolVar(&validateOutput, "validate-output", false, "Check results for inconsistencies before saving them")
+	flag.BoolVar(&logRequests, "log-requests", false, "Log every GitHub API request with its status and remaining rate limit")
+	flag.StringVar(&configPath, "config", "config.yaml", "Path of the config file")
+	flag.StringVar(&profile, "profile", "", "Apply the flag values of this profile from the config file")
+	flag.StringVar(&format, "format", "json", "Encoding of the result files, "+resultFormatNames())
+	flag.IntVar(&rolling, "rolling", 0, "Instead of collecting, average the last N saved results of each selected group")
+	flag.BoolVar(&landscape, "landscape", false, "Instead of collecting, print how the projects in the CNCF landscape differ from the -repos file")
+	flag.StringVar(&landscapeURL, "landscape-url", stats.LandscapeURL, "CNCF landscape.yml file or http(s) URL read by -landscape")
+	flag.BoolVar(&dryRunOnly, "dry-run", false, "Instead of collecting, validate the -repos file, or the landscape with -landscape, and print what would be fetched without any API request")
+	flag.BoolVar(&writeRepos, "write-repos", false, "With -landscape, replace the -repos file with the landscape's projects")
+	flag.BoolVar(&trend, "trend", false, "Deprecated, use the trend command")
+	flag.StringVar(&serveAddr, "serve", "", "Deprecated, use the serve command with -addr")
+	flag.BoolVar(&opts.html, "html", false, "Write an index.html dashboard charting the processed groups next to the results")
+	flag.BoolVar(&opts.feed, "feed", false, "Write a feed.xml Atom feed of the saved runs and their rank changes next to the results")
+	flag.StringVar(&opts.feedURL, "feed-url", "", "URL the results are hosted at, linked from the -feed entries")
+	flag.BoolVar(&opts.summary, "summary", true, "Write summary.json holding the results of the processed groups and of all of them combined next to the results")
+	flag.BoolVar(&latest, "latest", true, "Also write the results and summary.json to latest/ in -out under names without the date, e.g. latest/graduated.json")
+	flag.BoolVar(&opts.badges, "badges", false, "Write shields.io endpoint badges of the top languages and projects of the processed groups to badges/ next to the results")
+	flag.StringVar(&languageColorsPath, "language-colors", "", "JSON file mapping languages to #rrggbb colors overriding the GitHub linguist colors used by -html, -serve, -badges, -email-to and -format markdown")
+	flag.StringVar(&storeSpec, "store", "", "Also save a row per language of every group and project to a database, sqlite:<path>, postgres:<dsn> or a postgres:// URL")
+	flag.StringVar(&notifyWebhook, "notify-webhook", os.Getenv("CNCF_STATS_NOTIFY_WEBHOOK"), "Slack or Discord incoming webhook URL every run posts a summary to, or set CNCF_STATS_NOTIFY_WEBHOOK")
+	flag.StringVar(&emailTo, "email-to", "", "Comma separated recipients the Markdown and HTML report of every run is emailed to")
+	flag.StringVar(&emailFrom, "email-from", os.Getenv("SMTP_FROM"), "Sender of the emailed report, SMTP_USERNAME by default, or set SMTP_FROM")
+	flag.StringVar(&smtpAddr, "smtp-addr", envOr("SMTP_ADDR", "localhost:25"), "host:port of the SMTP server the report is emailed through, or set SMTP_ADDR. "+
+		"SMTP_USERNAME and SMTP_PASSWORD log in to it.")
+	flag.StringVar(&exportDests, "export", "", "Comma separated destinations every group's result is exported to: stdout, file:<dir>, "+
+		"s3://bucket/prefix, gs://bucket/prefix or an http(s) URL the result is POSTed to")
+	flag.StringVar(&uploadDest, "upload", "", "Upload the files written by every run to s3://bucket/prefix or gs://bucket/prefix")
+	flag.StringVar(&checkpointPath, "checkpoint", "", "File recording every fetched repo until a run completes, e.g. .checkpoint.jsonl, so that even a crashed run can -resume")
+	flag.StringVar(&recordDir, "record", "", "Save every API response to a fixture file in this directory for -replay")
+	flag.StringVar(&replayDir, "replay", "", "Answer the API requests with the fixtures -record saved in this directory, without network access or a token")
+	flag.StringVar(&cacheDir, "cache-dir", "", "Directory caching GitHub responses to send conditional requests, which do not count against the rate limit when nothing changed, e.g. .etag-cache; none when empty")
+	flag.BoolVar(&skipForks, "skip-forks", false, "Leave forks out of orgs and projects listing several repos")
+	flag.BoolVar(&skipMirrors, "skip-mirrors", false, "Leave mirrors out of orgs and projects listing several repos")
+	flag.BoolVar(&skipArchived, "skip-archived", false, "Leave archived repos out of orgs and projects listing several repos")
+	flag.IntVar(&maxRepoSize, "max-repo-size", 0, "Cap the bytes, or lines with -mode clone, any repo adds to the group totals, 0 for no cap. Projects still report their repos in full")
+	flag.StringVar(&excludeRepos, "exclude-repos", "", "Comma separated owner/repo globs, e.g. */website, left out of orgs and projects listing several repos")
+	flag.BoolVar(&checkRepos, "check-repos", false, "Look up every repo given by URL to detect renamed and archived repos, a request per repo unless -api graphql")
+	flag.BoolVar(&contributors, "contributors", false, "Also count the contributors of every project and group, a request per repo")
+	flag.BoolVar(&commitActivity, "commit-activity", false, "Also count the commits of the last year of every project and top language, a request per repo")
+	flag.BoolVar(&goModules, "go-modules", false, "Also record the Go version and direct dependencies in the go.mod of every repo whose top language is Go")
+	flag.BoolVar(&releases, "releases", false, "Also record the releases per year and days since the last release of every project")
+	flag.BoolVar(&licenses, "licenses", false, "Also count the projects of every SPDX license, a request per repo given by URL unless -api graphql")
+	flag.BoolVar(&metadata, "metadata", false, "Also record the stars, forks, open issues, license, default branch and last push of every project")
+	flag.BoolVar(&opts.fixRepos, "fix-repos", false, "Replace the URLs of renamed repos in the -repos file, implies -check-repos")
+	flag.BoolVar(&opts.custom, "custom", false, "Also collect the projects in the Custom section of the -repos file and compare the groups' languages with theirs")
+	flag.StringVar(&opts.customRepos, "custom-repos", "", "Repos file whose projects of every section are collected as the custom projects the groups are compared with, implies -custom")
+	flag.StringVar(&incrementalPath, "incremental", "", "File recording the last push and languages of every repo, later runs reuse the languages of repos not pushed to since")
+	flag.BoolVar(&force, "force", false, "Fetch every repo with -incremental, even those not pushed to since")
+	flag.BoolVar(&resume, "resume", false, "Continue an interrupted run with the repos recorded in the -checkpoint file")
+	flag.BoolVar(&verbose, "v", false, "Log every API request with its latency and rate limit state and every repo with its timing")
+	flag.StringVar(&logFormat, "log-format", "text", "Format of the log, \"text\" or \"json\" lines")
+	flag.StringVar(&progressMode, "progress", "auto", "Show the progress as a bar, \"bar\", as a log line every tenth of a group, \"log\", or \"off\"; \"auto\" shows a bar on a terminal")
+	flag.StringVar(&csvDetail, "csv-detail", "", "Append a CSV row per language of every processed repo with its bytes, or lines with -mode clone, to this file")
+	flag.Var(&fileMode, "file-mode", "Octal permissions of written result files")
+	flag.StringVar(&keyTopLanguage, "key-topLanguage", "topLanguage", "JSON key name of the top language counts")
+	flag.StringVar(&keyTotalBytes, "key-totalBytes", "totalBytes", "JSON key name of the total bytes")
+	flag.StringVar(&keyTotalLines, "key-totalLines", "totalLines", "JSON key name of the total lines counted with -mode clone")
+	flag.BoolVar(&printVersion, "version", false, "Print the version, git commit and build date and exit")
+	flag.Parse()
+	if printVersion {
+		fmt.Println(formatBuild(toolBuild()))
+		return
 	}
 
-	var repos Repos
-	f, err := os.ReadFile("repos.yaml")
+	// explicit The flags given on the command line, which override the config file
+	explicit := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = true
+	})
+	cfg, err := loadConfig(configPath, explicit["config"] || profile != "")
 	if err != nil {
 		log.Fatal(err)
 	}
-	if err := yaml.Unmarshal(f, &repos); err != nil {
+	if err := applyConfig(flag.CommandLine, cfg, profile, explicit); err != nil {
 		log.Fatal(err)
 	}
 
-	if graduated {
-		results.ProcessProjects(repos.Graduated)
-		results.SaveResultsToFile("graduated")
+	// groupFlags The flags selecting the groups, which resolveOptions sets when none is, reset to what the config
+	// and command line give before every reload
+	groupFlags := []string{"all", "graduated", "incubating", "sandbox", "custom"}
+	var hostLimits map[string]stats.HostLimit
+	var aggregator stats.Aggregator
+	// resolveOptions Validates the flag values and derives the options from them, at startup and again after every
+	// reload of the config
+	resolveOptions := func() error {
+		if opts.customRepos != "" {
+			opts.custom = true
+		}
+		if len(opts.orgs) > 0 {
+			if opts.graduated || opts.incubating || opts.sandbox {
+				return errors.New("-org cannot be combined with -graduated, -incubating or -sandbox")
+			}
+			if opts.fixRepos || landscape {
+				return errors.New("-org cannot be combined with -fix-repos or -landscape, which change the repos file")
+			}
+			if opts.custom && opts.customRepos == "" {
+				return errors.New("-custom with -org needs -custom-repos, the orgs have no Custom section")
+			}
+			for _, org := range opts.orgs {
+				if isGroup(org) || org == "all" || org == "custom" {
+					return fmt.Errorf("invalid -org %q, it is the name of a group", org)
+				}
+			}
+		} else if opts.all || !opts.graduated && !opts.incubating && !opts.sandbox {
+			opts.all, opts.graduated, opts.incubating, opts.sandbox = true, true, true, true
+		}
+		opts.projects, opts.match = nil, nil
+		if projectNames != "" {
+			opts.projects = make(map[string]bool)
+			for _, name := range splitList(projectNames) {
+				opts.projects[strings.ToLower(name)] = true
+			}
+		}
+		if match != "" {
+			var err error
+			if opts.match, err = regexp.Compile("(?i)^(?:" + match + ")$"); err != nil {
+				return fmt.Errorf("invalid -match %q: %v", match, err)
+			}
+		}
+		if opts.rankBy != "count" && opts.rankBy != "bytes" {
+			return fmt.Errorf("invalid -rank-by %q, must be \"count\" or \"bytes\"", opts.rankBy)
+		}
+		if _, ok := resultFormats[format]; !ok {
+			return fmt.Errorf("invalid -format %q, must be %s", format, resultFormatNames())
+		}
+		if minPercent < 0 || minPercent > 100 {
+			return fmt.Errorf("invalid -min-percent %v, must be within [0, 100]", minPercent)
+		}
+		if presencePercent < 0 || presencePercent > 100 {
+			return fmt.Errorf("invalid -presence-percent %v, must be within [0, 100]", presencePercent)
+		}
+		if topN < 1 {
+			return fmt.Errorf("invalid -top %d, must be at least 1", topN)
+		}
+		if minLines < 0 {
+			return fmt.Errorf("invalid -min-lines %d, must not be negative", minLines)
+		}
+		if maxLanguages < 0 {
+			return fmt.Errorf("invalid -max-languages-per-repo %d, must not be negative", maxLanguages)
+		}
+		if keyTopLanguage == keyTotalBytes || keyTopLanguage == keyTotalLines || keyTotalBytes == keyTotalLines {
+			return fmt.Errorf("-key-topLanguage, -key-totalBytes and -key-totalLines must differ, got %q, %q and %q",
+				keyTopLanguage, keyTotalBytes, keyTotalLines)
+		}
+		if workers < 1 {
+			return fmt.Errorf("invalid -workers %d, must be at least 1", workers)
+		}
+		if rolling < 0 {
+			return fmt.Errorf("invalid -rolling %d, must not be negative", rolling)
+		}
+		if api != "rest" && api != "graphql" {
+			return fmt.Errorf("invalid -api %q, must be \"rest\" or \"graphql\"", api)
+		}
+		if mode != "api" && mode != "clone" {
+			return fmt.Errorf("invalid -mode %q, must be \"api\" or \"clone\"", mode)
+		}
+		if logFormat != "text" && logFormat != "json" {
+			return fmt.Errorf("invalid -log-format %q, must be \"text\" or \"json\"", logFormat)
+		}
+		if progressMode != "auto" && progressMode != "bar" && progressMode != "log" && progressMode != "off" {
+			return fmt.Errorf("invalid -progress %q, must be \"auto\", \"bar\", \"log\" or \"off\"", progressMode)
+		}
+		if logFormat == "json" {
+			// The bar is only redrawn around the text log, JSON logs get the progress as log lines
+			if progressMode == "bar" {
+				return errors.New("-progress bar cannot be combined with -log-format json")
+			}
+			if progressMode == "auto" {
+				progressMode = "log"
+			}
+		}
+		if mode == "clone" && api == "graphql" {
+			return errors.New("-api graphql cannot be combined with -mode clone, which does not fetch languages from the API")
+		}
+		if recordDir != "" && replayDir != "" {
+			return errors.New("-record cannot be combined with -replay")
+		}
+		if replayDir != "" && mode == "clone" {
+			return errors.New("-replay cannot be combined with -mode clone, which clones the repos instead of requesting the API")
+		}
+		if recordDir != "" || replayDir != "" {
+			// Fixtures hold complete responses, not the 304 responses of conditional requests
+			cacheDir = ""
+		}
+		if maxAttempts < 1 {
+			return fmt.Errorf("invalid -max-attempts %d, must be at least 1", maxAttempts)
+		}
+		if retryJitter < 0 || retryJitter > 1 {
+			return fmt.Errorf("invalid -retry-jitter %v, must be within [0, 1]", retryJitter)
+		}
+		if requestTimeout < 0 {
+			return fmt.Errorf("invalid -request-timeout %v, must not be negative", requestTimeout)
+		}
+		if runTimeout < 0 {
+			return fmt.Errorf("invalid -run-timeout %v, must not be negative", runTimeout)
+		}
+		for _, pattern := range splitList(excludeRepos) {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("invalid -exclude-repos pattern %q: %v", pattern, err)
+			}
+		}
+		if maxRepoSize < 0 {
+			return fmt.Errorf("invalid -max-repo-size %d, must not be negative", maxRepoSize)
+		}
+		if throttle < 0 {
+			return fmt.Errorf("invalid -throttle %v, must not be negative", throttle)
+		}
+		if maxRequestsPerHour < 0 {
+			return fmt.Errorf("invalid -max-requests-per-hour %d, must not be negative", maxRequestsPerHour)
+		}
+		if reserveQuota < 0 {
+			return fmt.Errorf("invalid -reserve-quota %d, must not be negative", reserveQuota)
+		}
+		var err error
+		if hostLimits, err = parseHostLimits(throttle, maxRequestsPerHour, concurrencyPerHost, maxRequestsPerHost); err != nil {
+			return err
+		}
+		aggregator, err = parseAggregation(aggregation)
+		return err
 	}
-	if incubating {
-		results.ProcessProjects(repos.Incubating)
-		results.SaveResultsToFile("incubating")
+	if err := resolveOptions(); err != nil {
+		log.Fatal(err)
 	}
-	if sandbox {
-		results.ProcessProjects(repos.Sandbox)
-		results.SaveResultsToFile("sandbox")
+	setupLogging(verbose, logFormat, os.Stderr)
+	var cronSched cron.Schedule
+	if cronSchedule != "" {
+		var err error
+		if cronSched, err = cron.ParseStandard(cronSchedule); err != nil {
+			log.Fatalf("invalid -schedule %q: %v", cronSchedule, err)
+		}
+		if opts.watch {
+			log.Fatal("-schedule and -watch cannot be combined")
+		}
 	}
-}
 
-func (r *RepoStats) ProcessProjects(projects map[string]string) {
-	// Reset counts for each project group
-	r.JSONResult = JSONResult{
-		TopLanguage: make(map[string]int),
-		TotalLines:  make(map[string]int),
+	if opts.html || opts.badges || emailTo != "" || serveAddr != "" || format == "markdown" {
+		var err error
+		if opts.colors, err = loadLanguageColors(languageColorsPath); err != nil {
+			log.Fatal(err)
+		}
+		markdownColors = opts.colors
 	}
-	for name, ghUrl := range projects {
-		log.Println("Getting language stats for", name)
-		owner, repo := getOwnerAndRepo(ghUrl)
-		repoLanguages, _, err := r.GitHubClient.Repositories.ListLanguages(context.Background(), owner, repo)
-		if err != nil {
+	if serveAddr != "" {
+		slog.Warn("-serve is deprecated, use \"cncf-language-stats serve -addr " + serveAddr + "\"")
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		if err := serve(ctx, serveAddr, outDir, opts.colors); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if dryRunOnly {
+		var source string
+		if landscape {
+			source = landscapeURL
+		}
+		if err := dryRun(opts, source, api == "graphql", mode == "clone", checkRepos || opts.fixRepos || metadata || licenses, contributors, commitActivity, goModules, releases); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if landscape {
+		if err := syncLandscape(landscapeURL, opts.reposPath, writeRepos, os.FileMode(fileMode)); err != nil {
 			log.Fatal(err)
 		}
+		return
+	}
+	if trend {
+		slog.Warn("-trend is deprecated, use \"cncf-language-stats trend\"")
+		for _, repoGroup := range opts.groupNames() {
+			if err := saveTrendReport(outDir, repoGroup, os.FileMode(fileMode)); err != nil {
+				log.Fatal(err)
+			}
+		}
+		return
+	}
 
-		if len(repoLanguages) == 0 {
-			log.Println(name, "does not contain any language stats")
-			continue
+	// newResultWriter The resultWriter of the flag values, called again when -watch reloads the config
+	newResultWriter := func() resultWriter {
+		return resultWriter{
+			detailed:       detailed,
+			topLanguageMap: topLanguages,
+			validate:       validateOutput,
+			format:         format,
+			fileMode:       os.FileMode(fileMode),
+			dir:            outDir,
+			latest:         latest,
+			keyNames: map[string]string{
+				"topLanguage": keyTopLanguage,
+				"totalBytes":  keyTotalBytes,
+				"totalLines":  keyTotalLines,
+			},
 		}
+	}
+	out := newResultWriter()
+	if rolling > 0 {
+		for _, repoGroup := range opts.groupNames() {
+			if err := saveRollingResult(out, repoGroup, rolling); err != nil {
+				log.Fatal(err)
+			}
+		}
+		return
+	}
 
-		l := sortLanguageMap(repoLanguages)
+	var token string
+	var tokens []string
+	var tokenSource oauth2.TokenSource
+	if replayDir != "" {
+		// Replayed requests are never sent, they need no credentials
+	} else if appID != "" {
+		if tokenSource, err = newAppTokenSource(appID, appInstallationID, appKeyPath); err != nil {
+			log.Fatal(err)
+		}
+	} else {
+		// Several comma separated tokens are rotated
+		tokens = splitList(envOr("GITHUB_TOKENS", os.Getenv("GITHUB_TOKEN")))
+		if tokenFile != "" && (explicit["token-file"] || len(tokens) == 0) {
+			b, err := os.ReadFile(tokenFile)
+			if err != nil {
+				log.Fatal(err)
+			}
+			tokens = splitList(strings.ReplaceAll(string(b), "\n", ","))
+		}
+		if len(tokens) == 0 {
+			log.Fatal("GITHUB_TOKEN ENV variable or -token-file required")
+		}
+		if token = tokens[0]; len(tokens) == 1 {
+			tokens = nil
+		}
+	}
 
-		// Process repo language statistics
-		r.processTopLanguageStats(l)
-		r.processTotalLinesStats(l)
+	// statsOptions The collector options of the flag values and config, called again when -watch reloads the config
+	statsOptions := func() stats.Options {
+		return stats.Options{
+			Token:                token,
+			GitLabToken:          os.Getenv("GITLAB_TOKEN"),
+			Tokens:               tokens,
+			TokenSource:          tokenSource,
+			ComputeConcentration: concentration,
+			Normalize:            normalize,
+			MaxLanguagesPerRepo:  maxLanguages,
+			Workers:              workers,
+			GraphQL:              api == "graphql",
+			Clone:                mode == "clone",
+			CloneDir:             cloneDir,
+			CloneExcludes:        splitList(cloneExcludes),
+			Aliases:              cfg.Aliases,
+			MinPercent:           minPercent,
+			LanguagePresence:     languagePresence,
+			PresencePercent:      presencePercent,
+			LanguageProjects:     languageProjects,
+			MinLines:             minLines,
+			TopN:                 topN,
+			Aggregator:           aggregator,
+			CheckRepos:           checkRepos || opts.fixRepos,
+			SkipForks:            skipForks,
+			SkipMirrors:          skipMirrors,
+			SkipArchived:         skipArchived,
+			ExcludeRepos:         splitList(excludeRepos),
+			MaxRepoSize:          maxRepoSize,
+			Metadata:             metadata,
+			Licenses:             licenses,
+			Contributors:         contributors,
+			CommitActivity:       commitActivity,
+			GoModules:            goModules,
+			Releases:             releases,
+			Retry:                stats.Retry{MaxAttempts: maxAttempts, Backoff: retryBackoff, Jitter: retryJitter},
+			RequestTimeout:       requestTimeout,
+			Build:                toolBuild(),
+			HostLimits:           hostLimits,
+			ReserveQuota:         reserveQuota,
+		}
+	}
+	statsOpts := statsOptions()
+	switch {
+	case recordDir != "":
+		statsOpts.BaseTransport = stats.RecordTransport{Dir: recordDir, Perm: out.fileMode}
+	case replayDir != "":
+		statsOpts.BaseTransport = stats.ReplayTransport{Dir: replayDir}
+	}
+	if logRequests {
+		statsOpts.BaseTransport = LoggingTransport{Base: statsOpts.BaseTransport}
+	}
+	if cacheDir != "" {
+		statsOpts.BaseTransport = stats.ETagTransport{Dir: cacheDir, Perm: out.fileMode, Base: statsOpts.BaseTransport}
+	}
+	if csvDetail != "" {
+		unit := "bytes"
+		if statsOpts.Clone {
+			unit = "lines"
+		}
+		w, err := OpenCSVDetail(csvDetail, unit, out.fileMode)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer w.Close()
+		statsOpts.RepoHook = func(repoGroup, project, owner, repo string, l stats.LanguageLinesList) {
+			if err := w.WriteRepo(repoGroup, project, owner, repo, l); err != nil {
+				slog.Error("Writing the CSV detail failed", "error", err)
+			}
+		}
+	}
+	if progress := newProgressReporter(progressMode, os.Stderr); progress != nil {
+		if progress.bar {
+			log.SetOutput(progress)
+		}
+		statsOpts.Progress = progress.update
+	}
+	if uploadDest != "" {
+		var err error
+		if opts.upload, err = newUploader(uploadDest); err != nil {
+			log.Fatal(err)
+		}
+	}
+	fileExportMode = out.fileMode
+	for _, dest := range splitList(exportDests) {
+		e, err := stats.OpenExporter(dest)
+		if err != nil {
+			log.Fatal(err)
+		}
+		opts.exporters = append(opts.exporters, e)
+	}
+	if notifyWebhook != "" {
+		var err error
+		if opts.notify, err = newNotifier(notifyWebhook); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if to := splitList(emailTo); len(to) > 0 {
+		var err error
+		if opts.email, err = newMailer(smtpAddr, emailFrom, to, os.Getenv("SMTP_USERNAME"), os.Getenv("SMTP_PASSWORD")); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if storeSpec != "" {
+		var err error
+		if out.store, err = openStore(storeSpec); err != nil {
+			log.Fatal(err)
+		}
+		defer out.store.Close()
+	}
+	if incrementalPath != "" {
+		state, err := stats.OpenRepoState(incrementalPath, out.fileMode)
+		if err != nil {
+			log.Fatal(err)
+		}
+		statsOpts.Incremental = state
+		statsOpts.Force = force
+	}
+	if checkpointPath != "" {
+		checkpoint, err := stats.OpenCheckpoint(checkpointPath, resume, out.fileMode)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer checkpoint.Close()
+		statsOpts.Checkpoint = checkpoint
+	} else if !opts.watch && cronSched == nil {
+		// Kept in memory to be saved when the single run is interrupted
+		statsOpts.Checkpoint = stats.NewCheckpoint()
+	}
+	collector := stats.NewCollector(statsOpts)
+	// reloadCollector Reads the config again, validates and derives the options like at startup and rebuilds the
+	// collector with its flag values and aliases, keeping the transports, hooks, checkpoint and repo state set up
+	// at startup
+	reloadCollector := func() error {
+		for _, name := range groupFlags {
+			if !explicit[name] {
+				flag.Set(name, flag.Lookup(name).DefValue)
+			}
+		}
+		reloaded, err := reloadConfig(flag.CommandLine, configPath, cfg, profile, explicit, &opts.orgs)
+		if err != nil {
+			return err
+		}
+		cfg = reloaded
+		if err := resolveOptions(); err != nil {
+			return err
+		}
+		store := out.store
+		out = newResultWriter()
+		out.store = store
+		o := statsOptions()
+		o.BaseTransport, o.RepoHook, o.Progress = statsOpts.BaseTransport, statsOpts.RepoHook, statsOpts.Progress
+		o.Checkpoint, o.Incremental = statsOpts.Checkpoint, statsOpts.Incremental
+		if o.Incremental != nil {
+			o.Force = force
+		}
+		statsOpts = o
+		collector = stats.NewCollector(statsOpts)
+		return nil
+	}
+	// runs The runs started so far, every -watch run after the first reloads the config
+	var runs int
+	// runOnce Runs and forgets the checkpointed repos once the run collected every group
+	runOnce := func(ctx context.Context) error {
+		if runs++; opts.watch && runs > 1 {
+			if err := reloadCollector(); err != nil {
+				return fmt.Errorf("reloading %s: %w", configPath, err)
+			}
+		}
+		if runTimeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, runTimeout)
+			defer cancel()
+		}
+		err := run(ctx, collector, out, opts)
+		if state := statsOpts.Incremental; state != nil {
+			slog.Info("Reused the languages of repos not pushed to since the last run", "repos", state.Skipped())
+			if err := state.Save(); err != nil {
+				slog.Error("Saving the repo state failed", "error", err)
+			}
+		}
+		if statsOpts.Checkpoint != nil && (err == nil || errors.Is(err, errLeaderChanged)) {
+			if err := statsOpts.Checkpoint.Reset(); err != nil {
+				slog.Error("Resetting the checkpoint failed", "error", err)
+			}
+		}
+		return err
+	}
 
-		// Some sort of throttle
-		time.Sleep(r.Throttle)
+	if opts.watch {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		err := watch(ctx, []string{opts.reposPath, configPath}, 500*time.Millisecond, runOnce)
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
 	}
-}
 
-func (r *RepoStats) SaveResultsToFile(repoGroup string) {
-	jsonResult, err := json.MarshalIndent(r.JSONResult, "", " ")
+	if cronSched != nil {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		err := schedule(ctx, cronSched, runOnce)
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	err = runOnce(ctx)
+	// A second signal kills the process
+	stop()
+	var interrupted *interruptedError
+	if errors.As(err, &interrupted) {
+		// A signal exits like the shell reports a process stopped by SIGINT, a timeout like any failed run
+		code := 130
+		if errors.Is(err, context.DeadlineExceeded) {
+			code = 1
+		}
+		interrupted.printRemaining(os.Stderr)
+		if checkpointPath == "" {
+			checkpointPath = filepath.Join(outDir, "interrupted.checkpoint")
+			n, err := statsOpts.Checkpoint.Save(checkpointPath, out.fileMode)
+			if err != nil {
+				slog.Error("Saving the checkpoint failed", "error", err)
+				os.Exit(code)
+			}
+			slog.Info("Saved the fetched repos to a checkpoint", "repos", n, "path", checkpointPath)
+		}
+		statsOpts.Checkpoint.Close()
+		slog.Info("Stopped early, resume with -checkpoint " + checkpointPath + " -resume")
+		os.Exit(code)
+	}
 	if err != nil {
-		log.Println(err)
+		if errors.Is(err, errLeaderChanged) {
+			slog.Warn(err.Error())
+			os.Exit(3)
+		}
+		slog.Error(err.Error())
+		os.Exit(1)
 	}
-	os.WriteFile(getResultFilePath(repoGroup), jsonResult, 0644)
 }
 
-func (r *RepoStats) processTopLanguageStats(l LanguageLinesList) {
-	r.TopLanguage[l[0].Language]++
+// parseAggregation The aggregator registered as -aggregation, nil when it is empty
+func parseAggregation(name string) (stats.Aggregator, error) {
+	if name == "" {
+		return nil, nil
+	}
+	aggregator, err := stats.LookupAggregator(name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid -aggregation %q, must be one of %s", name, strings.Join(stats.AggregatorNames(), ", "))
+	}
+	return aggregator, nil
 }
 
-func (r *RepoStats) processTotalLinesStats(l LanguageLinesList) {
-	for _, language := range l {
-		r.TotalLines[language.Language] += language.Lines
+// run Processes and saves every selected group of the projects in the repos file
+func run(ctx context.Context, collector *stats.Collector, out resultWriter, opts options) error {
+	type group struct {
+		name     string
+		projects map[string]stats.Project
+		result   stats.Result
+		files    []string
+		err      error
+		// collected Whether the group's projects were collected
+		collected bool
+	}
+	var groups []*group
+	// source The projects of every group before -project and -match select some, and where they come from
+	var source []map[string]stats.Project
+	// customProjects The projects the groups are compared with for -custom
+	var customProjects map[string]stats.Project
+	sourceName := opts.reposPath
+	if len(opts.orgs) > 0 {
+		sourceName = "the orgs"
+		for _, org := range opts.orgs {
+			projects, err := collector.OrgProjects(ctx, org)
+			if err != nil {
+				if ctx.Err() != nil {
+					return &interruptedError{err: ctx.Err(), remaining: make(map[string][]string), urls: make(map[string]map[string]stats.Project)}
+				}
+				return err
+			}
+			slog.Info("Listed the repos of the org", "org", org, "repos", len(projects))
+			groups = append(groups, &group{name: org, projects: opts.selectProjects(projects)})
+			source = append(source, projects)
+		}
+	} else {
+		repos, err := stats.LoadRepos(opts.reposPath)
+		if err != nil {
+			return err
+		}
+		collector.Weights = repos.Weights
+		if opts.graduated {
+			groups = append(groups, &group{name: "graduated", projects: opts.selectProjects(repos.Graduated)})
+		}
+		if opts.incubating {
+			groups = append(groups, &group{name: "incubating", projects: opts.selectProjects(repos.Incubating)})
+		}
+		if opts.sandbox {
+			groups = append(groups, &group{name: "sandbox", projects: opts.selectProjects(repos.Sandbox)})
+		}
+		source = []map[string]stats.Project{repos.Graduated, repos.Incubating, repos.Sandbox}
+		customProjects = repos.Custom
+	}
+	if opts.customRepos != "" {
+		repos, err := stats.LoadRepos(opts.customRepos)
+		if err != nil {
+			return err
+		}
+		customProjects = repos.Projects()
+	}
+	if opts.projects != nil || opts.match != nil {
+		// Leave out the groups without a selected project rather than saving empty results
+		var selected []*group
+		for _, g := range groups {
+			if len(g.projects) > 0 {
+				selected = append(selected, g)
+			} else {
+				slog.Info("No project of the group is selected by -project or -match, skipping", "group", g.name)
+			}
+		}
+		groups = selected
+		known := make(map[string]bool)
+		for _, projects := range source {
+			for name := range projects {
+				known[strings.ToLower(name)] = true
+			}
+		}
+		for name := range opts.projects {
+			if !known[name] {
+				slog.Warn("-project is not in "+sourceName, "project", name)
+			}
+		}
+		if len(groups) == 0 {
+			return fmt.Errorf("no project in %s is selected by -project or -match", sourceName)
+		}
 	}
-}
 
-func getOwnerAndRepo(repoUrl string) (string, string) {
-	// https://github.com/containerd/containerd
-	ownerRepo := strings.Split(repoUrl, "/")
-	return ownerRepo[len(ownerRepo)-2], ownerRepo[len(ownerRepo)-1]
-}
+	process := func(g *group, collector *stats.Collector) {
+		g.result, g.err = collector.Collect(ctx, g.name, g.projects)
+		if g.err != nil {
+			return
+		}
+		g.collected = true
+		var err error
+		if g.files, err = out.SaveResultsToFile(g.name, g.result); err != nil {
+			slog.Error("Saving the results failed", "group", g.name, "error", err)
+		} else if err := out.SaveResultsToStore(g.name, g.result); err != nil {
+			slog.Error("Saving the results to the store failed", "group", g.name, "error", err)
+		}
+	}
+	if opts.parallelGroups {
+		// Every group gets its own share of the rate and concurrency of every host
+		collectors := collector.Split(len(groups))
+		var wg sync.WaitGroup
+		for i, g := range groups {
+			wg.Add(1)
+			go func(g *group, collector *stats.Collector) {
+				defer wg.Done()
+				process(g, collector)
+			}(g, collectors[i])
+		}
+		wg.Wait()
+	} else {
+		for _, g := range groups {
+			process(g, collector)
+			if g.err != nil {
+				break
+			}
+		}
+	}
 
-func sortLanguageMap(repoLanguages map[string]int) LanguageLinesList {
-	l := make(LanguageLinesList, len(repoLanguages))
-	var i int
-	for lang, lines := range repoLanguages {
-		l[i] = LanguageLines{Language: lang, Lines: lines}
-		i++
+	if ctx.Err() != nil {
+		interrupted := &interruptedError{err: ctx.Err(), remaining: make(map[string][]string), urls: make(map[string]map[string]stats.Project)}
+		for _, g := range groups {
+			interrupted.add(g.name, g.projects, g.err, g.collected)
+		}
+		return interrupted
 	}
-	// Sort descending by number of lines
-	sort.Sort(sort.Reverse(l))
-	return l
-}
 
-func getResultFilePath(repoGroup string) string {
-	currentTime := time.Now().UTC()
-	date := currentTime.Format("2006-01-02")
-	basePath := "results/"
-	filename := date + "-" + repoGroup + ".json"
-	return basePath + filename
+	// written Every file written by the run, uploaded at the end
+	var written []string
+	var leaderChanged bool
+	for _, g := range groups {
+		written = append(written, g.files...)
+		if g.err != nil {
+			return g.err
+		}
+		if err := exportResult(ctx, opts.exporters, g.name, g.result); err != nil {
+			return err
+		}
+		if opts.watch {
+			printSummary(g.name, g.result)
+		}
+		if opts.compare != "" {
+			baseline, err := loadBaseline(strings.ReplaceAll(opts.compare, "{group}", g.name))
+			if err != nil {
+				return err
+			}
+			printRankChanges(g.name, stats.CompareRanks(baseline, g.result, opts.rankBy))
+
+			changes := stats.CompareLeaders(baseline, g.result)
+			printLeaderChanges(g.name, changes)
+			for _, c := range changes {
+				leaderChanged = leaderChanged || c.Changed()
+			}
+		}
+	}
+
+	if opts.fixRepos {
+		renamed := make(map[string]string)
+		for _, g := range groups {
+			for old, current := range g.result.Renamed {
+				renamed[old] = current
+			}
+		}
+		if err := fixRepoURLs(opts.reposPath, renamed, out.fileMode); err != nil {
+			return err
+		}
+	}
+
+	// indexed The groups whose result files are listed in the index, with all and custom
+	indexed := append([]*group(nil), groups...)
+	if opts.all {
+		results := make([]stats.Result, len(groups))
+		for i, g := range groups {
+			results[i] = g.result
+		}
+		combined := stats.Combine(results)
+		files, err := out.SaveResultsToFile("all", combined)
+		if err != nil {
+			return err
+		}
+		written = append(written, files...)
+		indexed = append(indexed, &group{name: "all", result: combined, files: files})
+		if err := exportResult(ctx, opts.exporters, "all", combined); err != nil {
+			return err
+		}
+		if err := out.SaveResultsToStore("all", combined); err != nil {
+			slog.Error("Saving the results to the store failed", "group", "all", "error", err)
+		}
+	}
+
+	if opts.custom {
+		if len(customProjects) == 0 {
+			slog.Warn("-custom has no custom projects to compare with, skipping", "repos", opts.reposPath)
+		} else {
+			slog.Info("Collecting the custom projects", "projects", len(customProjects))
+			custom, err := collector.Collect(ctx, "custom", customProjects)
+			if err != nil {
+				if ctx.Err() != nil {
+					interrupted := &interruptedError{err: ctx.Err(), remaining: make(map[string][]string), urls: make(map[string]map[string]stats.Project)}
+					interrupted.add("custom", customProjects, err, false)
+					return interrupted
+				}
+				return err
+			}
+			files, err := out.SaveResultsToFile("custom", custom)
+			if err != nil {
+				return err
+			}
+			written = append(written, files...)
+			indexed = append(indexed, &group{name: "custom", result: custom, files: files})
+			if err := out.SaveResultsToStore("custom", custom); err != nil {
+				slog.Error("Saving the results to the store failed", "group", "custom", "error", err)
+			}
+			if err := exportResult(ctx, opts.exporters, "custom", custom); err != nil {
+				return err
+			}
+
+			names := make([]string, len(groups))
+			results := make([]stats.Result, len(groups))
+			for i, g := range groups {
+				names[i], results[i] = g.name, g.result
+			}
+			if len(groups) > 1 {
+				names, results = append(names, "all"), append(results, stats.Combine(results))
+			}
+			files, err = writeCustomComparison(out, newCustomComparison(names, results, custom))
+			if err != nil {
+				return err
+			}
+			written = append(written, files...)
+			slog.Info("Saved the comparison with the custom projects", "path", files[0])
+		}
+	}
+
+	if opts.uniqueLanguages {
+		if len(groups) < 2 {
+			slog.Warn("-unique-languages needs at least two groups to compare, skipping")
+		} else {
+			groupResults := make(map[string]stats.Result, len(groups))
+			for _, g := range groups {
+				groupResults[g.name] = g.result
+			}
+			b, err := json.MarshalIndent(stats.UniqueLanguages(groupResults), "", " ")
+			if err != nil {
+				return err
+			}
+			path := out.resultPath("unique-languages", ".json")
+			if err := writeFile(path, b, out.fileMode); err != nil {
+				return err
+			}
+			written = append(written, path)
+		}
+	}
+
+	if opts.summary {
+		names := make([]string, len(groups))
+		results := make([]stats.Result, len(groups))
+		for i, g := range groups {
+			names[i], results[i] = g.name, g.result
+		}
+		paths := []string{filepath.Join(out.dir, "summary.json")}
+		if out.latest {
+			paths = append(paths, out.latestPath("summary.json"))
+		}
+		if err := writeSummary(paths, names, results, time.Now(), out); err != nil {
+			return err
+		}
+		written = append(written, paths...)
+	}
+
+	if opts.html {
+		names := make([]string, len(groups))
+		results := make([]stats.Result, len(groups))
+		for i, g := range groups {
+			names[i], results[i] = g.name, g.result
+		}
+		path := filepath.Join(out.dir, "index.html")
+		if err := writeHTMLReport(path, names, results, opts.colors, out.fileMode); err != nil {
+			return err
+		}
+		written = append(written, path)
+	}
+
+	if opts.badges {
+		names := make([]string, len(groups))
+		results := make([]stats.Result, len(groups))
+		for i, g := range groups {
+			names[i], results[i] = g.name, g.result
+		}
+		if opts.all {
+			names, results = append(names, "all"), append(results, stats.Combine(results))
+		}
+		files, err := writeBadges(out.dir, names, results, opts.colors, out.fileMode)
+		if err != nil {
+			return err
+		}
+		written = append(written, files...)
+	}
+
+	if opts.feed {
+		path, err := writeFeed(out.dir, opts.feedURL, []string{"graduated", "incubating", "sandbox", "all"}, out.fileMode)
+		if err != nil {
+			return err
+		}
+		written = append(written, path)
+	}
+
+	if opts.index != "" {
+		var entries []IndexEntry
+		latest := out.latestPath("")
+		for _, g := range indexed {
+			for _, file := range g.files {
+				// The copies in latest/ are replaced every run, the index lists the dated files
+				if filepath.Dir(file) == latest {
+					continue
+				}
+				entry, err := newIndexEntry(file, g.name, g.result)
+				if err != nil {
+					return err
+				}
+				entries = append(entries, entry)
+			}
+		}
+		if err := updateIndex(opts.index, entries, out.fileMode); err != nil {
+			return err
+		}
+		written = append(written, opts.index)
+	}
+
+	if opts.upload != nil {
+		for _, file := range written {
+			if err := opts.upload.Upload(ctx, file); err != nil {
+				return err
+			}
+		}
+	}
+
+	if opts.notify != nil {
+		summaries := make([]groupSummary, len(groups))
+		for i, g := range groups {
+			summaries[i] = groupSummary{name: g.name, result: g.result}
+			var err error
+			if summaries[i].previous, err = previousResult(out.dir, g.name); err != nil {
+				slog.Warn("Loading the previous result failed, notifying without changes", "group", g.name, "error", err)
+			}
+		}
+		if err := opts.notify.Notify(ctx, summaries); err != nil {
+			slog.Error("Notifying the webhook failed", "error", err)
+		}
+	}
+
+	if opts.email != nil {
+		names := make([]string, len(groups))
+		results := make([]stats.Result, len(groups))
+		for i, g := range groups {
+			names[i], results[i] = g.name, g.result
+		}
+		if err := opts.email.Send(names, results, opts.colors); err != nil {
+			slog.Error("Emailing the report failed", "error", err)
+		}
+	}
+
+	var failed []string
+	for _, g := range groups {
+		for project, err := range g.result.Errors {
+			failed = append(failed, fmt.Sprintf("%s/%s: %s", g.name, project, err))
+		}
+	}
+	if len(failed) > 0 {
+		sort.Strings(failed)
+		return fmt.Errorf("%w, %d failed:\n  %s", errProjectsFailed, len(failed), strings.Join(failed, "\n  "))
+	}
+
+	if leaderChanged && opts.failOnLeaderChange {
+		return errLeaderChanged
+	}
+	return nil
 }
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"cncf-language-stats/stats"
+	"fmt"
+	"strings"
+)
+
+// markdownColors The language colors of the swatches in -format markdown result files, set by main before the
+// results are saved
+var markdownColors languageColors
+
+func init() {
+	registerResultFormat("markdown", func(repoGroup string, result stats.Result, keyNames map[string]string) ([]resultFile, error) {
+		return []resultFile{{suffix: ".md", data: marshalMarkdown(repoGroup, result, markdownColors)}}, nil
+	})
+}
+
+// marshalMarkdown Renders the result as a Markdown table of the group's languages ranked by total bytes or lines,
+// with the number of projects each is the top language of and its share of the group's total, and the share of
+// projects containing it and its average per project when normalized. Results with language presence get a
+// table of the number of projects containing every language, results with Go module stats one of the most used
+// modules and results with licenses one of the licenses. A line on how the result was collected ends it. With
+// colors every language of the first table is preceded by a swatch of its color.
+func marshalMarkdown(repoGroup string, result stats.Result, colors languageColors) []byte {
+	var projects int
+	for _, count := range result.TopLanguage {
+		projects += count
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "## %s projects\n\n", strings.ToUpper(repoGroup[:1])+repoGroup[1:])
+	fmt.Fprintf(&b, "%d projects, %d languages.\n\n", projects, len(result.Totals()))
+	fmt.Fprintf(&b, "| Rank | Language | Top language of | Total %s | Share |", result.Unit())
+	if result.Scores != nil {
+		fmt.Fprintf(&b, " Score (%s) |", result.Aggregation)
+	}
+	if result.Normalized != nil {
+		fmt.Fprintf(&b, " In projects | %s per project |", strings.ToUpper(result.Unit()[:1])+result.Unit()[1:])
+	}
+	b.WriteString("\n| ---: | --- | ---: | ---: | ---: |")
+	if result.Scores != nil {
+		b.WriteString(" ---: |")
+	}
+	if result.Normalized != nil {
+		b.WriteString(" ---: | ---: |")
+	}
+	b.WriteString("\n")
+	percentages := stats.LanguagePercentages(stats.SortLanguageMap(result.Totals()))
+	for i, l := range stats.SortLanguageMap(result.Totals()) {
+		language := escapeMarkdown(l.Language)
+		if colors != nil {
+			language = colors.swatch(l.Language) + " " + language
+		}
+		fmt.Fprintf(&b, "| %d | %s | %d | %d | %.2f%% |", i+1, language, result.TopLanguage[l.Language], l.Lines, percentages[l.Language])
+		if result.Scores != nil {
+			fmt.Fprintf(&b, " %.2f |", result.Scores[l.Language])
+		}
+		if result.Normalized != nil {
+			n := result.Normalized[l.Language]
+			fmt.Fprintf(&b, " %.2f%% | %d |", n.ProjectShare, n.PerProject)
+		}
+		b.WriteString("\n")
+	}
+	if len(result.LanguagePresence) > 0 {
+		b.WriteString("\n### Language presence\n\n")
+		b.WriteString("| Language | Contained in projects | Top language of |\n")
+		b.WriteString("| --- | ---: | ---: |\n")
+		for _, l := range stats.SortLanguageMap(result.LanguagePresence) {
+			fmt.Fprintf(&b, "| %s | %d | %d |\n", escapeMarkdown(l.Language), l.Lines, result.TopLanguage[l.Language])
+		}
+	}
+	if len(result.Licenses) > 0 {
+		b.WriteString("\n### Licenses\n\n")
+		b.WriteString("| License | Projects |\n")
+		b.WriteString("| --- | ---: |\n")
+		for _, l := range stats.SortLanguageMap(result.Licenses) {
+			fmt.Fprintf(&b, "| %s | %d |\n", escapeMarkdown(l.Language), l.Lines)
+		}
+	}
+	if len(result.GoModules) > 0 {
+		fmt.Fprintf(&b, "\n### Most used Go modules\n\n")
+		fmt.Fprintf(&b, "%d Go projects declare a Go version in their go.mod.\n\n", len(result.GoVersions))
+		b.WriteString("| Rank | Module | Required by projects |\n")
+		b.WriteString("| ---: | --- | ---: |\n")
+		for i, m := range stats.TopGoModules(result, 25) {
+			fmt.Fprintf(&b, "| %d | %s | %d |\n", i+1, escapeMarkdown(m.Path), m.Projects)
+		}
+	}
+	if m := result.Meta; m != nil {
+		fmt.Fprintf(&b, "\n_Collected %s in %s mode with %d API requests, %d of %d projects skipped and %d failed",
+			m.CollectedAt, m.API, m.Requests, m.Skipped, m.Projects, m.Failed)
+		if m.CappedRepos > 0 {
+			fmt.Fprintf(&b, ", %d repos capped at %d languages", m.CappedRepos, m.MaxLanguagesPerRepo)
+		}
+		if m.Version != "" {
+			fmt.Fprintf(&b, ", version %s", m.Version)
+		}
+		if m.Commit != "" {
+			fmt.Fprintf(&b, " at commit %s", m.Commit)
+		}
+		b.WriteString("._\n")
+	}
+	return []byte(b.String())
+}
+
+// escapeMarkdown Escapes the characters that would break a Markdown table cell
+func escapeMarkdown(s string) string {
+	return strings.NewReplacer("|", "\\|", "*", "\\*", "_", "\\_").Replace(s)
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"cncf-language-stats/stats"
+	"encoding/json"
+	"fmt"
+)
+
+func init() {
+	registerResultFormat("json", func(repoGroup string, result stats.Result, keyNames map[string]string) ([]resultFile, error) {
+		b, err := marshalResult(result, keyNames)
+		return []resultFile{{suffix: ".json", data: b}}, err
+	})
+}
+
+// marshalResult Encodes the result as indented JSON with its top level keys renamed according to keyNames
+func marshalResult(result stats.Result, keyNames map[string]string) ([]byte, error) {
+	if !renamesKeys(keyNames) {
+		return json.MarshalIndent(result, "", " ")
+	}
+
+	b, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return nil, err
+	}
+
+	renamed := make(map[string]json.RawMessage, len(fields))
+	for key, value := range fields {
+		if name, ok := keyNames[key]; ok && name != "" {
+			key = name
+		}
+		if _, ok := renamed[key]; ok {
+			return nil, fmt.Errorf("more than one result field is named %q", key)
+		}
+		renamed[key] = value
+	}
+	return json.MarshalIndent(renamed, "", " ")
+}
+
+func renamesKeys(keyNames map[string]string) bool {
+	for key, name := range keyNames {
+		if name != "" && name != key {
+			return true
+		}
+	}
+	return false
+}
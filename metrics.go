@@ -0,0 +1,48 @@
+package main
+
+import (
+	"cncf-language-stats/stats"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// writeMetrics Writes the results in the Prometheus text exposition format, one series per group and language
+func writeMetrics(w io.Writer, groupNames []string, results []stats.Result) error {
+	metrics := []struct {
+		name, help string
+		values     func(stats.Result) map[string]int
+	}{
+		{"cncf_language_total_lines", "Bytes of the language, or code lines when counted in clones, summed across the group's projects.",
+			func(r stats.Result) map[string]int { return r.Totals() }},
+		{"cncf_language_top_repo_count", "Number of the group's projects the language is the top language of.",
+			func(r stats.Result) map[string]int { return r.TopLanguage }},
+	}
+	for _, m := range metrics {
+		if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n", m.name, m.help, m.name); err != nil {
+			return err
+		}
+		for i, result := range results {
+			values := m.values(result)
+			languages := make([]string, 0, len(values))
+			for lang := range values {
+				languages = append(languages, lang)
+			}
+			sort.Strings(languages)
+			for _, lang := range languages {
+				_, err := fmt.Fprintf(w, "%s{group=\"%s\",language=\"%s\"} %d\n",
+					m.name, escapeLabel(groupNames[i]), escapeLabel(lang), values[lang])
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// escapeLabel Escapes a Prometheus label value
+func escapeLabel(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
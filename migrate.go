@@ -0,0 +1,82 @@
+package main
+
+import (
+	"cncf-language-stats/stats"
+	"flag"
+	"fmt"
+	"os"
+)
+
+// runMigrate Upgrades result files saved with an older schema version to the current one in place, the result
+// files of every group in a directory unless files are given
+func runMigrate(args []string) error {
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+	dir := fs.String("dir", envOr("CNCF_STATS_OUT", "results"), "Directory whose <date>-<group>.json result files are migrated when no files are given, or set CNCF_STATS_OUT")
+	dryRun := fs.Bool("dry-run", false, "Only print the files that would be migrated")
+	index := fs.String("index", "", "Path of an index.json whose entries of the migrated files are updated")
+	fileMode := fileModeFlag(0644)
+	fs.Var(&fileMode, "file-mode", "Octal permissions of the updated index, the migrated files keep theirs")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: cncf-language-stats migrate [flags] [result.json...]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	files := fs.Args()
+	if len(files) == 0 {
+		for _, repoGroup := range []string{"graduated", "incubating", "sandbox", "all"} {
+			groupFiles, err := groupResultFiles(*dir, repoGroup)
+			if err != nil {
+				return err
+			}
+			files = append(files, groupFiles...)
+		}
+	}
+
+	var entries []IndexEntry
+	var migrated int
+	for _, file := range files {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		result, version, err := stats.MigrateResult(b)
+		if err != nil {
+			return fmt.Errorf("migrating %s: %w", file, err)
+		}
+		if version == stats.SchemaVersion {
+			continue
+		}
+		fmt.Printf("%s: schema version %d to %d\n", file, version, stats.SchemaVersion)
+		migrated++
+		if *dryRun {
+			continue
+		}
+		info, err := os.Stat(file)
+		if err != nil {
+			return err
+		}
+		data, err := marshalResult(result, nil)
+		if err != nil {
+			return err
+		}
+		if err := writeFile(file, data, info.Mode().Perm()); err != nil {
+			return err
+		}
+		if *index != "" {
+			entry, err := newIndexEntry(file, resultFileGroup(file), result)
+			if err != nil {
+				return err
+			}
+			entries = append(entries, entry)
+		}
+	}
+
+	if len(entries) > 0 {
+		if err := updateIndex(*index, entries, os.FileMode(fileMode)); err != nil {
+			return err
+		}
+	}
+	fmt.Printf("%d of %d result files migrated to schema version %d\n", migrated, len(files), stats.SchemaVersion)
+	return nil
+}
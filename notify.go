@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bytes"
+	"cncf-language-stats/stats"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// notifier Posts a summary of every run to a Slack or Discord incoming webhook
+type notifier struct {
+	webhook string
+	// discord Posts Discord's message format instead of Slack's
+	discord bool
+	client  *http.Client
+}
+
+// newNotifier Creates a notifier for the webhook URL, Discord's when it is hosted by discord.com
+func newNotifier(webhook string) (*notifier, error) {
+	u, err := url.Parse(webhook)
+	if err != nil || u.Scheme != "https" && u.Scheme != "http" || u.Host == "" {
+		return nil, fmt.Errorf("invalid -notify-webhook %q, must be an http(s) URL", webhook)
+	}
+	host := strings.ToLower(u.Hostname())
+	return &notifier{
+		webhook: webhook,
+		discord: host == "discord.com" || host == "discordapp.com" || strings.HasSuffix(host, ".discord.com"),
+		client:  &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+// groupSummary What a notification reports of a group
+type groupSummary struct {
+	name   string
+	result stats.Result
+	// previous The result of the group's previous run, nil without one
+	previous *stats.Result
+}
+
+// message Renders the top 5 languages of every group with their changes since the previous run and the
+// projects that failed, bold text marked up for Slack or Discord
+func (n *notifier) message(groups []groupSummary) string {
+	bold := "*"
+	if n.discord {
+		bold = "**"
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%sCNCF language stats collected%s\n", bold, bold)
+	var failed []string
+	for _, g := range groups {
+		var projects int
+		for _, count := range g.result.TopLanguage {
+			projects += count
+		}
+		fmt.Fprintf(&b, "\n%s%s%s, %d projects\n", bold, g.name, bold, projects)
+		for i, l := range stats.SortLanguageMap(g.result.TopLanguage) {
+			if i == 5 {
+				break
+			}
+			fmt.Fprintf(&b, "%d. %s: %d projects", i+1, l.Language, l.Lines)
+			if g.previous != nil {
+				if delta := l.Lines - g.previous.TopLanguage[l.Language]; delta != 0 {
+					fmt.Fprintf(&b, " (%+d)", delta)
+				}
+			}
+			b.WriteString("\n")
+		}
+		for project, err := range g.result.Errors {
+			failed = append(failed, fmt.Sprintf("%s/%s: %s", g.name, project, err))
+		}
+	}
+	if len(failed) > 0 {
+		sort.Strings(failed)
+		fmt.Fprintf(&b, "\n%s%d projects failed%s\n", bold, len(failed), bold)
+		for i, f := range failed {
+			if i == 10 {
+				fmt.Fprintf(&b, "and %d more\n", len(failed)-i)
+				break
+			}
+			fmt.Fprintf(&b, "- %s\n", f)
+		}
+	}
+	return b.String()
+}
+
+// Notify Posts the summary of the groups, failing on non 2xx responses
+func (n *notifier) Notify(ctx context.Context, groups []groupSummary) error {
+	text := n.message(groups)
+	payload := map[string]string{"text": text}
+	if n.discord {
+		// Discord refuses messages over 2000 characters
+		if runes := []rune(text); len(runes) > 2000 {
+			text = string(runes[:1997]) + "..."
+		}
+		payload = map[string]string{"content": text}
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.webhook, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("posting to the webhook: %s %s", resp.Status, strings.TrimSpace(string(b)))
+	}
+	return nil
+}
+
+// previousResult The newest saved result of the group dated before today, nil when there is none
+func previousResult(dir, repoGroup string) (*stats.Result, error) {
+	files, err := groupResultFiles(dir, repoGroup)
+	if err != nil {
+		return nil, err
+	}
+	for i := len(files) - 1; i >= 0; i-- {
+		if resultFileDate(files[i]) < resultDate() {
+			result, err := loadBaseline(files[i])
+			if err != nil {
+				return nil, err
+			}
+			return &result, nil
+		}
+	}
+	return nil, nil
+}
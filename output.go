@@ -0,0 +1,116 @@
+package main
+
+import (
+	"cncf-language-stats/stats"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// resultWriter Saves group results the way the command line selected
+type resultWriter struct {
+	// detailed Includes the per project details in saved results
+	detailed bool
+	// topLanguageMap Saves only a map of each project to its top language instead of the full result
+	topLanguageMap bool
+	// validate Refuses to save results violating the invariants checked by stats.Validate
+	validate bool
+	// format Encoding of the saved results, one of resultFormats
+	format   string
+	fileMode os.FileMode
+	// keyNames Renames top level keys of the saved JSON, e.g. "topLanguage" to "top_language"
+	keyNames map[string]string
+	// store Additionally saves every result to a database when set
+	store resultStore
+	// dir Directory the result files are saved in, created when missing
+	dir string
+	// latest Also saves every result under the group's name without the date in latest/ in dir, at a URL that
+	// stays the same across runs
+	latest bool
+}
+
+// SaveResultsToFile Writes the result to the group's dated result file, and to its file in latest/ with latest, and
+// returns the paths of the written files, the result file first
+func (w resultWriter) SaveResultsToFile(repoGroup string, result stats.Result) ([]string, error) {
+	return w.saveResult(repoGroup, repoGroup, resultDate(), result)
+}
+
+// saveResult Writes the group's result to the <date>-<name> result files, and to the <name> files in latest/ with
+// latest, in the selected format and with the renamed keys
+func (w resultWriter) saveResult(repoGroup, name, date string, result stats.Result) ([]string, error) {
+	result, err := w.filter(repoGroup, result)
+	if err != nil {
+		return nil, err
+	}
+
+	var encoded []resultFile
+	if w.topLanguageMap {
+		data, err := json.MarshalIndent(stats.TopLanguageMap(result), "", " ")
+		if err != nil {
+			return nil, err
+		}
+		encoded = []resultFile{{suffix: "-top-languages.json", data: data}}
+	} else {
+		var err error
+		if encoded, err = resultFormats[w.format](repoGroup, result, w.keyNames); err != nil {
+			return nil, err
+		}
+	}
+
+	files := make([]string, len(encoded))
+	for i, f := range encoded {
+		files[i] = filepath.Join(w.dir, date+"-"+name+f.suffix)
+		if err := writeFile(files[i], f.data, w.fileMode); err != nil {
+			return nil, err
+		}
+	}
+	if w.latest {
+		for _, f := range encoded {
+			path := w.latestPath(name + f.suffix)
+			if err := writeFile(path, f.data, w.fileMode); err != nil {
+				return nil, err
+			}
+			files = append(files, path)
+		}
+	}
+	return files, nil
+}
+
+// filter The result as it is saved, validated with validate and without the per project details unless detailed
+func (w resultWriter) filter(repoGroup string, result stats.Result) (stats.Result, error) {
+	if w.validate {
+		if err := stats.Validate(result); err != nil {
+			return stats.Result{}, fmt.Errorf("not saving %s: %w", repoGroup, err)
+		}
+	}
+	if !w.detailed {
+		result.Projects = nil
+	}
+	return result, nil
+}
+
+// SaveResultsToStore Saves the full result to the store, if there is one
+func (w resultWriter) SaveResultsToStore(repoGroup string, result stats.Result) error {
+	if w.store == nil {
+		return nil
+	}
+	return w.store.Save(resultDate(), repoGroup, result)
+}
+
+// resultPath The path of the group's result file dated today, suffix follows the group in the file name
+func (w resultWriter) resultPath(repoGroup, suffix string) string {
+	filename := resultDate() + "-" + repoGroup + suffix
+	return filepath.Join(w.dir, filename)
+}
+
+// latestPath The path of the file with the name in latest/
+func (w resultWriter) latestPath(name string) string {
+	return filepath.Join(w.dir, "latest", name)
+}
+
+// resultDate The date results collected now are saved under
+func resultDate() string {
+	return time.Now().UTC().Format("2006-01-02")
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"cncf-language-stats/stats"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// progressReporter Shows the progress of the collected groups, either as a bar redrawn in place on a terminal
+// or as log lines every tenth of a group
+type progressReporter struct {
+	mu  sync.Mutex
+	out io.Writer
+	// bar Draws a bar on the last line of out, which must be a terminal, instead of logging
+	bar bool
+	// width Columns of the terminal the bar is cut to
+	width int
+	// groups The progress of the groups being collected, a group is dropped once done
+	groups map[string]stats.Progress
+	// logged The tenths of every group already logged
+	logged map[string]int
+	// drawn Whether the bar is on the last line
+	drawn bool
+}
+
+// newProgressReporter Creates a reporter for the -progress mode, nil for "off". With a bar, log output
+// must go through the reporter so that log lines are written above the bar.
+func newProgressReporter(mode string, out *os.File) *progressReporter {
+	if mode == "off" {
+		return nil
+	}
+	p := &progressReporter{
+		out:    out,
+		bar:    mode == "bar" || mode == "auto" && isTerminal(out),
+		width:  80,
+		groups: make(map[string]stats.Progress),
+		logged: make(map[string]int),
+	}
+	if n, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && n > 0 {
+		p.width = n
+	}
+	return p
+}
+
+// isTerminal Reports whether f is a terminal rather than a file or pipe
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// update Records the progress of a group, used as stats.Options.Progress
+func (p *progressReporter) update(progress stats.Progress) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.bar {
+		p.log(progress)
+		return
+	}
+	if progress.Done == progress.Total {
+		delete(p.groups, progress.Group)
+	} else {
+		p.groups[progress.Group] = progress
+	}
+	p.clear()
+	p.draw()
+}
+
+// log Logs the progress the first time the group reaches another tenth of its projects
+func (p *progressReporter) log(progress stats.Progress) {
+	if progress.Done == 0 {
+		delete(p.logged, progress.Group)
+		return
+	}
+	tenth := 10 * progress.Done / progress.Total
+	if tenth <= p.logged[progress.Group] {
+		return
+	}
+	p.logged[progress.Group] = tenth
+	slog.Info("Progress", "group", progress.Group, "done", progress.Done, "total", progress.Total, "repos", progress.Repos,
+		"last", progress.Project, "rateRemaining", progress.RateRemaining, "eta", progress.ETA().Round(time.Second))
+}
+
+// Write Writes log output above the bar
+func (p *progressReporter) Write(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.clear()
+	n, err := p.out.Write(b)
+	p.draw()
+	return n, err
+}
+
+// clear Removes the bar from the last line
+func (p *progressReporter) clear() {
+	if p.drawn {
+		fmt.Fprint(p.out, "\r\033[K")
+		p.drawn = false
+	}
+}
+
+// draw Draws the bar of every group in progress on the last line
+func (p *progressReporter) draw() {
+	if len(p.groups) == 0 {
+		return
+	}
+	names := make([]string, 0, len(p.groups))
+	for name := range p.groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var parts []string
+	for _, name := range names {
+		progress := p.groups[name]
+		parts = append(parts, fmt.Sprintf("%s %s %s", name, progressBar(progress.Done, progress.Total, 20), describeProgress(progress)))
+	}
+	line := []rune(strings.Join(parts, " | "))
+	if len(line) > p.width-1 {
+		line = line[:p.width-1]
+	}
+	fmt.Fprint(p.out, string(line))
+	p.drawn = true
+}
+
+// progressBar Draws done out of total as a bar of width characters
+func progressBar(done, total, width int) string {
+	filled := width
+	if total > 0 {
+		filled = width * done / total
+	}
+	return "[" + strings.Repeat("#", filled) + strings.Repeat("-", width-filled) + "]"
+}
+
+// describeProgress Describes the processed projects and repos, the last project, the remaining rate limit and the ETA
+func describeProgress(progress stats.Progress) string {
+	s := fmt.Sprintf("%d/%d projects, %d repos", progress.Done, progress.Total, progress.Repos)
+	if progress.Project != "" {
+		s += ", last " + progress.Project
+	}
+	if progress.RateRemaining >= 0 {
+		s += fmt.Sprintf(", %d requests left", progress.RateRemaining)
+	}
+	if eta := progress.ETA(); eta > 0 {
+		s += ", ETA " + eta.Round(time.Second).String()
+	}
+	return s
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"cncf-language-stats/resultpb"
+	"cncf-language-stats/stats"
+	"google.golang.org/protobuf/proto"
+)
+
+func init() {
+	registerResultFormat("protobuf", func(repoGroup string, result stats.Result, keyNames map[string]string) ([]resultFile, error) {
+		b, err := proto.MarshalOptions{Deterministic: true}.Marshal(resultToProto(result))
+		return []resultFile{{suffix: ".pb", data: b}}, err
+	})
+}
+
+func resultToProto(result stats.Result) *resultpb.Result {
+	pb := &resultpb.Result{
+		SchemaVersion:       int32(result.SchemaVersion),
+		TopLanguage:         make(map[string]int64, len(result.TopLanguage)),
+		TotalLines:          toInt64Map(result.TotalLines),
+		TotalBytes:          toInt64Map(result.TotalBytes),
+		Percentages:         result.Percentages,
+		WeightedTopLanguage: result.WeightedTopLanguage,
+		Aggregation:         result.Aggregation,
+		Scores:              result.Scores,
+		Gini:                result.Gini,
+		Errors:              result.Errors,
+		Renamed:             result.Renamed,
+		Archived:            result.Archived,
+		EstimatedBytes:      result.EstimatedBytes,
+		Contributors:        toInt64Map(result.Contributors),
+		TotalContributors:   int64(result.TotalContributors),
+		Commits:             toInt64Map(result.Commits),
+		CommitsByLanguage:   toInt64Map(result.CommitsByLanguage),
+		GoVersions:          result.GoVersions,
+		GoModules:           toInt64Map(result.GoModules),
+		Licenses:            toInt64Map(result.Licenses),
+		LanguagePresence:    toInt64Map(result.LanguagePresence),
+		Weights:             result.Weights,
+	}
+	for lang, count := range result.TopLanguage {
+		pb.TopLanguage[lang] = int64(count)
+	}
+	if result.Concentration != nil {
+		pb.Concentration = make(map[string]*resultpb.Concentration, len(result.Concentration))
+		for lang, c := range result.Concentration {
+			pb.Concentration[lang] = &resultpb.Concentration{Project: c.Project, Share: c.Share}
+		}
+	}
+	pb.ProjectsByLanguage = toProjectLists(result.ProjectsByLanguage)
+	pb.ProjectsContaining = toProjectLists(result.ProjectsContaining)
+	if result.Normalized != nil {
+		pb.Normalized = make(map[string]*resultpb.NormalizedLanguage, len(result.Normalized))
+		for lang, n := range result.Normalized {
+			pb.Normalized[lang] = &resultpb.NormalizedLanguage{
+				Projects:         int64(n.Projects),
+				ProjectShare:     n.ProjectShare,
+				TopLanguageShare: n.TopLanguageShare,
+				PerProject:       int64(n.PerProject),
+			}
+		}
+	}
+	if result.Releases != nil {
+		pb.Releases = make(map[string]*resultpb.ProjectReleases, len(result.Releases))
+		for name, p := range result.Releases {
+			pb.Releases[name] = &resultpb.ProjectReleases{
+				PerYear:              int64(p.PerYear),
+				LastRelease:          p.LastRelease,
+				DaysSinceLastRelease: int64(p.DaysSinceLastRelease),
+			}
+		}
+	}
+	if m := result.Meta; m != nil {
+		pb.Meta = &resultpb.RunMeta{
+			CollectedAt:         m.CollectedAt,
+			Version:             m.Version,
+			Projects:            int64(m.Projects),
+			Skipped:             int64(m.Skipped),
+			Failed:              int64(m.Failed),
+			Api:                 m.API,
+			Requests:            int64(m.Requests),
+			Commit:              m.Commit,
+			BuildDate:           m.BuildDate,
+			MaxLanguagesPerRepo: int64(m.MaxLanguagesPerRepo),
+			CappedRepos:         int64(m.CappedRepos),
+		}
+	}
+	if c := result.ReleaseCadence; c != nil {
+		pb.ReleaseCadence = &resultpb.ReleaseCadence{
+			Projects:                   int64(c.Projects),
+			MeanReleasesPerYear:        c.MeanReleasesPerYear,
+			MedianDaysSinceLastRelease: c.MedianDaysSinceLastRelease,
+		}
+	}
+	if result.Metadata != nil {
+		pb.Metadata = make(map[string]*resultpb.Metadata, len(result.Metadata))
+		for name, m := range result.Metadata {
+			pb.Metadata[name] = &resultpb.Metadata{
+				Stars:         int64(m.Stars),
+				Forks:         int64(m.Forks),
+				OpenIssues:    int64(m.OpenIssues),
+				License:       m.License,
+				DefaultBranch: m.DefaultBranch,
+				PushedAt:      m.PushedAt,
+			}
+		}
+	}
+	if result.Projects != nil {
+		pb.Projects = make(map[string]*resultpb.Project, len(result.Projects))
+		for name, p := range result.Projects {
+			pp := &resultpb.Project{Url: p.URL, TopLanguage: p.TopLanguage, MaturityDate: p.MaturityDate, Percentages: p.Percentages, Repos: p.Repos, TopLanguages: p.TopLanguages}
+			pp.Languages = toInt64Map(p.Languages)
+			pb.Projects[name] = pp
+		}
+	}
+	return pb
+}
+
+// toInt64Map Converts the values of the map, keeping nil maps nil
+func toInt64Map(m map[string]int) map[string]int64 {
+	if m == nil {
+		return nil
+	}
+	converted := make(map[string]int64, len(m))
+	for k, v := range m {
+		converted[k] = int64(v)
+	}
+	return converted
+}
+
+// toProjectLists Converts the project names of every language, keeping nil maps nil
+func toProjectLists(m map[string][]string) map[string]*resultpb.ProjectList {
+	if m == nil {
+		return nil
+	}
+	lists := make(map[string]*resultpb.ProjectList, len(m))
+	for lang, names := range m {
+		lists[lang] = &resultpb.ProjectList{Projects: names}
+	}
+	return lists
+}
@@ -0,0 +1,4 @@
+// Package resultpb Protocol buffer types of the result files written with -format protobuf
+package resultpb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative result.proto
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"cncf-language-stats/stats"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// groupResultFiles The dated result files of the group in dir, oldest first
+func groupResultFiles(dir, repoGroup string) ([]string, error) {
+	pattern := regexp.MustCompile(`^\d{4}-\d{2}-\d{2}-` + regexp.QuoteMeta(repoGroup) + `\.json$`)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, entry := range entries {
+		if !entry.IsDir() && pattern.MatchString(entry.Name()) {
+			files = append(files, filepath.Join(dir, entry.Name()))
+		}
+	}
+	// The names start with the date, so they sort chronologically
+	sort.Strings(files)
+	return files, nil
+}
+
+// saveRollingResult Averages the last window results of the group in the writer's directory and saves the average
+// like a result as <date>-<group>-rolling-<window>.json, or in the writer's format, dated with the newest result in
+// the window. Nothing is written to latest/, which holds the results of the latest run.
+func saveRollingResult(out resultWriter, repoGroup string, window int) error {
+	dir := out.dir
+	files, err := groupResultFiles(dir, repoGroup)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no %s results in %s to average", repoGroup, dir)
+	}
+	if len(files) < window {
+		slog.Warn("Averaging fewer results than the window", "group", repoGroup, "results", len(files), "window", window)
+	} else {
+		files = files[len(files)-window:]
+	}
+
+	results := make([]stats.Result, len(files))
+	for i, file := range files {
+		if results[i], err = loadBaseline(file); err != nil {
+			return err
+		}
+	}
+	// An average has no projects to map to their top languages
+	out.topLanguageMap = false
+	out.latest = false
+	saved, err := out.saveResult(repoGroup, repoGroup+"-rolling-"+strconv.Itoa(window), resultFileDate(files[len(files)-1]), stats.Average(results))
+	if err != nil {
+		return err
+	}
+	slog.Info("Averaged results", "group", repoGroup, "results", len(files), "path", saved[0])
+	return nil
+}
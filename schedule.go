@@ -0,0 +1,29 @@
+package main
+
+import (
+	"context"
+	"github.com/robfig/cron/v3"
+	"log/slog"
+	"time"
+)
+
+// schedule Calls fn at every time of the cron schedule until ctx is cancelled. Runs that fail are logged and
+// the schedule continues. Times that pass while a run is still in progress are skipped, the next run is the first
+// time of the schedule after it finishes.
+func schedule(ctx context.Context, s cron.Schedule, fn func(context.Context) error) error {
+	for {
+		next := s.Next(time.Now())
+		slog.Info("Next run scheduled", "at", next.Format(time.RFC3339))
+		t := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			slog.Info("Stopped the schedule")
+			return nil
+		case <-t.C:
+		}
+		if err := fn(ctx); err != nil && ctx.Err() == nil {
+			slog.Error("Run failed", "error", err)
+		}
+	}
+}
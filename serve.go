@@ -0,0 +1,187 @@
+package main
+
+import (
+	"bytes"
+	"cncf-language-stats/stats"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// allGroups The names of every project group
+var allGroups = []string{"graduated", "incubating", "sandbox"}
+
+// historyEntry A saved result of a group with the date it was collected
+type historyEntry struct {
+	Date   string       `json:"date"`
+	Result stats.Result `json:"result"`
+}
+
+// resultServer Serves the saved results from dir. Files are read on every request, so results saved
+// by other runs show up without a restart.
+type resultServer struct {
+	dir    string
+	colors languageColors
+}
+
+// serve Serves the results saved in dir on addr until ctx is cancelled
+func serve(ctx context.Context, addr, dir string, colors languageColors) error {
+	s := resultServer{dir: dir, colors: colors}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", s.handleDashboard)
+	mux.HandleFunc("/api/v1/history", s.handleHistory)
+	mux.HandleFunc("/api/v1/", s.handleLatest)
+	mux.HandleFunc("/metrics", s.handleMetrics)
+	server := &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+	slog.Info("Serving results", "addr", addr)
+	if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	slog.Info("Stopped serving")
+	return nil
+}
+
+// handleLatest Responds with the newest result of the group named in the path, /api/v1/<group>
+func (s resultServer) handleLatest(w http.ResponseWriter, r *http.Request) {
+	repoGroup := strings.TrimPrefix(r.URL.Path, "/api/v1/")
+	if !isGroup(repoGroup) {
+		http.NotFound(w, r)
+		return
+	}
+	files, err := groupResultFiles(s.dir, repoGroup)
+	if err != nil {
+		serverError(w, err)
+		return
+	}
+	if len(files) == 0 {
+		http.Error(w, "no "+repoGroup+" results", http.StatusNotFound)
+		return
+	}
+	result, err := loadBaseline(files[len(files)-1])
+	if err != nil {
+		serverError(w, err)
+		return
+	}
+	writeJSON(w, result)
+}
+
+// handleHistory Responds with every saved result of the group given by the group query parameter, oldest first
+func (s resultServer) handleHistory(w http.ResponseWriter, r *http.Request) {
+	repoGroup := r.URL.Query().Get("group")
+	if !isGroup(repoGroup) {
+		http.Error(w, "group must be one of "+strings.Join(allGroups, ", "), http.StatusBadRequest)
+		return
+	}
+	files, err := groupResultFiles(s.dir, repoGroup)
+	if err != nil {
+		serverError(w, err)
+		return
+	}
+	history := make([]historyEntry, len(files))
+	for i, file := range files {
+		history[i].Date = resultFileDate(file)
+		if history[i].Result, err = loadBaseline(file); err != nil {
+			serverError(w, err)
+			return
+		}
+	}
+	writeJSON(w, history)
+}
+
+// handleDashboard Responds with the dashboard of the newest result of every group
+func (s resultServer) handleDashboard(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	names, results, err := s.latestResults()
+	if err != nil {
+		serverError(w, err)
+		return
+	}
+	b, err := renderHTMLReport(names, results, s.colors)
+	if err != nil {
+		serverError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(b)
+}
+
+// handleMetrics Responds with the newest result of every group as Prometheus metrics
+func (s resultServer) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	names, results, err := s.latestResults()
+	if err != nil {
+		serverError(w, err)
+		return
+	}
+	var b bytes.Buffer
+	if err := writeMetrics(&b, names, results); err != nil {
+		serverError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	w.Write(b.Bytes())
+}
+
+// latestResults The newest result of every group that has one
+func (s resultServer) latestResults() ([]string, []stats.Result, error) {
+	return latestResults(s.dir)
+}
+
+// latestResults The newest result saved in dir of every group that has one
+func latestResults(dir string) ([]string, []stats.Result, error) {
+	var names []string
+	var results []stats.Result
+	for _, repoGroup := range allGroups {
+		files, err := groupResultFiles(dir, repoGroup)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(files) == 0 {
+			continue
+		}
+		result, err := loadBaseline(files[len(files)-1])
+		if err != nil {
+			return nil, nil, err
+		}
+		names = append(names, repoGroup)
+		results = append(results, result)
+	}
+	return names, results, nil
+}
+
+func isGroup(name string) bool {
+	for _, g := range allGroups {
+		if g == name {
+			return true
+		}
+	}
+	return false
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	b, err := json.MarshalIndent(v, "", " ")
+	if err != nil {
+		serverError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(b)
+}
+
+func serverError(w http.ResponseWriter, err error) {
+	slog.Error("Serving a request failed", "error", err)
+	http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+}
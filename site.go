@@ -0,0 +1,322 @@
+package main
+
+import (
+	"bytes"
+	"cncf-language-stats/stats"
+	"flag"
+	"fmt"
+	"html/template"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// siteTrendLanguages Number of languages of the newest result drawn in the trend graph of a group
+	siteTrendLanguages = 8
+	// siteTrendWidth, siteTrendHeight Size of the plot area of the trend graph in pixels
+	siteTrendWidth  = 560
+	siteTrendHeight = 240
+	// siteTrendMargin Space left of and below the plot area for the axis labels
+	siteTrendMargin = 40
+	// siteTrendPad Space above the plot area keeping the top label inside the graph
+	siteTrendPad = 8
+)
+
+// sitePage The content of a page of the static site
+type sitePage struct {
+	Title string
+	Date  string
+	// Groups The groups every page links to
+	Groups []siteLink
+}
+
+type siteLink struct {
+	Name, Href string
+}
+
+// siteIndex The landing page charting the newest result of every group
+type siteIndex struct {
+	sitePage
+	Charts []htmlGroup
+}
+
+// siteGroup The page of a group with its newest result, trend and saved results
+type siteGroup struct {
+	sitePage
+	Charts htmlGroup
+	// Trend The trend graph, nil with fewer than two results
+	Trend *siteTrend
+	// Files The saved results, newest first, linking to their copies in data/
+	Files []siteLink
+}
+
+// siteTrend The graph of the share of the total of the largest languages across the results of a group
+type siteTrend struct {
+	Unit          string
+	Width, Height int
+	// Left, Right The x of the first and last date
+	Left, Right int
+	First, Last string
+	Lines       []siteTrendLine
+	// Ticks The labels of the y axis in percent
+	Ticks []siteTick
+}
+
+type siteTrendLine struct {
+	Language, Color string
+	// Points The SVG polyline points of the language's share of each result
+	Points                    string
+	FirstPercent, LastPercent float64
+}
+
+type siteTick struct {
+	Y     float64
+	Label string
+}
+
+var siteTemplate = template.Must(template.Must(htmlTemplate.Clone()).Parse(`
+{{define "site-header"}}<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+{{template "style"}}
+</head>
+<body>
+<nav><a href="index.html">Overview</a>{{range .Groups}} · <a href="{{.Href}}">{{.Name}}</a>{{end}}</nav>
+<h1>{{.Title}}</h1>
+<p>Generated {{.Date}}.</p>
+{{end}}
+{{define "site-index"}}{{template "site-header" .}}
+{{range $i, $charts := .Charts}}
+<section>
+<h2><a href="{{(index $.Groups $i).Href}}">{{.Name}}</a></h2>
+<p>{{.Projects}} projects, {{.Languages}} languages.</p>
+{{template "charts" .}}
+</section>
+{{end}}
+</body>
+</html>
+{{end}}
+{{define "site-group"}}{{template "site-header" .}}
+<section>
+<p>{{.Charts.Projects}} projects, {{.Charts.Languages}} languages.</p>
+{{template "charts" .Charts}}
+</section>
+<section>
+<h2>Trend</h2>
+{{with .Trend}}<div>
+<h3>Share of total {{.Unit}} from {{.First}} to {{.Last}}</h3>
+<svg width="{{.Width}}" height="{{.Height}}" viewBox="0 0 {{.Width}} {{.Height}}" role="img">
+{{range .Ticks}}<line x1="{{$.Trend.Left}}" x2="{{$.Trend.Right}}" y1="{{printf "%.1f" .Y}}" y2="{{printf "%.1f" .Y}}" stroke="#d0d7de"/>
+<text x="0" y="{{printf "%.1f" .Y}}" dy="4">{{.Label}}</text>
+{{end}}<text x="{{.Left}}" y="{{.Height}}" dy="-8">{{.First}}</text>
+<text x="{{.Right}}" y="{{.Height}}" dy="-8" text-anchor="end">{{.Last}}</text>
+{{range .Lines}}<polyline points="{{.Points}}" fill="none" stroke="{{.Color}}" stroke-width="2"><title>{{.Language}}</title></polyline>
+{{end}}</svg>
+</div>
+<div>
+<table>
+<tr><th>Language</th><th>{{.First}}</th><th>{{.Last}}</th></tr>
+{{range .Lines}}<tr><td><svg width="10" height="10"><rect width="10" height="10" fill="{{.Color}}"/></svg> {{.Language}}</td><td>{{printf "%.1f" .FirstPercent}}%</td><td>{{printf "%.1f" .LastPercent}}%</td></tr>
+{{end}}</table>
+</div>{{else}}<p>A trend needs at least two results.</p>{{end}}
+</section>
+<section>
+<h2>Results</h2>
+<ul>
+{{range .Files}}<li><a href="{{.Href}}">{{.Name}}</a></li>
+{{end}}</ul>
+</section>
+</body>
+</html>
+{{end}}`))
+
+// runSite Builds a static website from every result saved in a directory, an index charting the newest result of
+// every group and a page per group with its trend, with copies of the result files in data/, ready to be published
+// with GitHub Pages
+func runSite(args []string) error {
+	fs := flag.NewFlagSet("site", flag.ExitOnError)
+	dir := fs.String("dir", envOr("CNCF_STATS_OUT", "results"), "Directory the results are read from, or set CNCF_STATS_OUT")
+	out := fs.String("out", "site", "Directory the site is written to")
+	groupList := fs.String("groups", strings.Join(allGroups, ","), "Comma separated groups of the site")
+	colorsPath := fs.String("language-colors", "", "JSON file mapping languages to #rrggbb colors overriding the GitHub linguist colors")
+	fileMode := fileModeFlag(0644)
+	fs.Var(&fileMode, "file-mode", "Octal permissions of the written files")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: cncf-language-stats site [flags]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	groups, err := parseGroups(*groupList)
+	if err != nil {
+		return err
+	}
+	colors, err := loadLanguageColors(*colorsPath)
+	if err != nil {
+		return err
+	}
+	perm := os.FileMode(fileMode)
+
+	page := sitePage{Title: "CNCF Programming Language Statistics", Date: time.Now().UTC().Format("2006-01-02")}
+	index := siteIndex{}
+	var pages []siteGroup
+	for _, repoGroup := range groups {
+		files, err := groupResultFiles(*dir, repoGroup)
+		if err != nil {
+			return err
+		}
+		if len(files) == 0 {
+			continue
+		}
+		dates := make([]string, len(files))
+		results := make([]stats.Result, len(files))
+		for i, file := range files {
+			dates[i] = resultFileDate(file)
+			if results[i], err = loadBaseline(file); err != nil {
+				return err
+			}
+			b, err := os.ReadFile(file)
+			if err != nil {
+				return err
+			}
+			if err := writeFile(filepath.Join(*out, "data", filepath.Base(file)), b, perm); err != nil {
+				return err
+			}
+		}
+		latest := results[len(results)-1]
+		b, err := marshalResult(latest, nil)
+		if err != nil {
+			return err
+		}
+		if err := writeFile(filepath.Join(*out, "data", repoGroup+".json"), b, perm); err != nil {
+			return err
+		}
+
+		charts := newHTMLGroup(repoGroup, latest, colors)
+		page.Groups = append(page.Groups, siteLink{Name: charts.Name, Href: repoGroup + ".html"})
+		index.Charts = append(index.Charts, charts)
+		p := siteGroup{Charts: charts, Trend: newSiteTrend(dates, results, colors)}
+		for i := len(files) - 1; i >= 0; i-- {
+			p.Files = append(p.Files, siteLink{Name: filepath.Base(files[i]), Href: "data/" + filepath.Base(files[i])})
+		}
+		pages = append(pages, p)
+	}
+	if len(pages) == 0 {
+		return fmt.Errorf("no results of %s in %s", strings.Join(groups, ", "), *dir)
+	}
+
+	index.sitePage = page
+	if err := writeSitePage(filepath.Join(*out, "index.html"), "site-index", index, perm); err != nil {
+		return err
+	}
+	for i, p := range pages {
+		p.sitePage = page
+		p.Title = p.Charts.Name + " projects"
+		if err := writeSitePage(filepath.Join(*out, page.Groups[i].Href), "site-group", p, perm); err != nil {
+			return err
+		}
+	}
+	// Pages would otherwise process the site with Jekyll, which leaves out files starting with _
+	if err := writeFile(filepath.Join(*out, ".nojekyll"), nil, perm); err != nil {
+		return err
+	}
+	fmt.Println(filepath.Join(*out, "index.html"))
+	return nil
+}
+
+func writeSitePage(path, name string, data interface{}, perm os.FileMode) error {
+	var b bytes.Buffer
+	if err := siteTemplate.ExecuteTemplate(&b, name, data); err != nil {
+		return err
+	}
+	return writeFile(path, b.Bytes(), perm)
+}
+
+// newSiteTrend The trend graph of the largest languages of the newest of the results, dated oldest first, placing
+// every result by its date. Nil with fewer than two results.
+func newSiteTrend(dates []string, results []stats.Result, colors languageColors) *siteTrend {
+	if len(results) < 2 {
+		return nil
+	}
+	first, err := time.Parse("2006-01-02", dates[0])
+	if err != nil {
+		return nil
+	}
+	last, err := time.Parse("2006-01-02", dates[len(dates)-1])
+	if err != nil || !last.After(first) {
+		return nil
+	}
+	totals := make([]int, len(results))
+	for i, r := range results {
+		for _, n := range r.Totals() {
+			totals[i] += n
+		}
+	}
+
+	t := &siteTrend{
+		Unit:   results[len(results)-1].Unit(),
+		Width:  siteTrendMargin + siteTrendWidth,
+		Height: siteTrendPad + siteTrendHeight + siteTrendMargin,
+		Left:   siteTrendMargin,
+		Right:  siteTrendMargin + siteTrendWidth,
+		First:  dates[0],
+		Last:   dates[len(dates)-1],
+	}
+	trends := stats.Trend(dates, results)
+	if len(trends) > siteTrendLanguages {
+		trends = trends[:siteTrendLanguages]
+	}
+	lines := make([][]float64, len(trends))
+	var largest float64
+	for i, trend := range trends {
+		lines[i] = make([]float64, len(trend.Points))
+		for j, p := range trend.Points {
+			if totals[j] > 0 {
+				lines[i][j] = float64(p.TotalLines) * 100 / float64(totals[j])
+			}
+			if lines[i][j] > largest {
+				largest = lines[i][j]
+			}
+		}
+	}
+	// The y axis goes up to the next tick above the largest share, ticks are 10 percent apart or 20 above 50
+	step := 10.0
+	if largest > 50 {
+		step = 20
+	}
+	top := math.Min(math.Ceil(largest/step)*step, 100)
+	if top == 0 {
+		top = step
+	}
+	y := func(percent float64) float64 {
+		return siteTrendPad + siteTrendHeight*(1-percent/top)
+	}
+	for p := 0.0; p <= top; p += step {
+		t.Ticks = append(t.Ticks, siteTick{Y: y(p), Label: fmt.Sprintf("%.0f%%", p)})
+	}
+
+	span := last.Sub(first).Hours()
+	for i, trend := range trends {
+		var points []string
+		for j := range trend.Points {
+			date, err := time.Parse("2006-01-02", dates[j])
+			if err != nil {
+				continue
+			}
+			x := siteTrendMargin + siteTrendWidth*date.Sub(first).Hours()/span
+			points = append(points, fmt.Sprintf("%.1f,%.1f", x, y(lines[i][j])))
+		}
+		t.Lines = append(t.Lines, siteTrendLine{
+			Language:     trend.Language,
+			Color:        colors.color(trend.Language),
+			Points:       strings.Join(points, " "),
+			FirstPercent: lines[i][0],
+			LastPercent:  lines[i][len(lines[i])-1],
+		})
+	}
+	return t
+}
@@ -0,0 +1,105 @@
+package stats
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Aggregator A methodology scoring the languages of a group by how its projects use them. The score of a
+// language in Result.Scores is the sum of the scores every project gives it.
+type Aggregator interface {
+	// Name Identifies the methodology in Result.Aggregation and -aggregation
+	Name() string
+	// Score The scores a project gives its languages, l sorted by bytes or lines largest first
+	Score(l LanguageLinesList) map[string]float64
+}
+
+var (
+	aggregatorsMu sync.Mutex
+	aggregators   = make(map[string]Aggregator)
+)
+
+func init() {
+	RegisterAggregator(topLanguageAggregator{})
+	RegisterAggregator(byteShareAggregator{})
+	RegisterAggregator(logScaledAggregator{})
+}
+
+// RegisterAggregator Makes the aggregator available by its name, it panics when the name is already registered
+func RegisterAggregator(a Aggregator) {
+	aggregatorsMu.Lock()
+	defer aggregatorsMu.Unlock()
+	if _, ok := aggregators[a.Name()]; ok {
+		panic("stats: RegisterAggregator called twice for " + a.Name())
+	}
+	aggregators[a.Name()] = a
+}
+
+// LookupAggregator The aggregator registered under name
+func LookupAggregator(name string) (Aggregator, error) {
+	aggregatorsMu.Lock()
+	a, ok := aggregators[name]
+	aggregatorsMu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown aggregation %q, must be one of %s", name, strings.Join(AggregatorNames(), ", "))
+	}
+	return a, nil
+}
+
+// AggregatorNames The sorted names of the registered aggregators
+func AggregatorNames() []string {
+	aggregatorsMu.Lock()
+	defer aggregatorsMu.Unlock()
+	names := make([]string, 0, len(aggregators))
+	for name := range aggregators {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// topLanguageAggregator Gives 1 to the project's top language, scoring languages like Result.TopLanguage
+type topLanguageAggregator struct{}
+
+func (topLanguageAggregator) Name() string { return "top-language" }
+
+func (topLanguageAggregator) Score(l LanguageLinesList) map[string]float64 {
+	return map[string]float64{l[0].Language: 1}
+}
+
+// byteShareAggregator Splits 1 across the project's languages by their share of its bytes or lines, so that
+// a project using several languages counts partly for each
+type byteShareAggregator struct{}
+
+func (byteShareAggregator) Name() string { return "byte-share" }
+
+func (byteShareAggregator) Score(l LanguageLinesList) map[string]float64 {
+	var total int
+	for _, language := range l {
+		total += language.Lines
+	}
+	scores := make(map[string]float64, len(l))
+	for _, language := range l {
+		if total > 0 {
+			scores[language.Language] = float64(language.Lines) / float64(total)
+		}
+	}
+	return scores
+}
+
+// logScaledAggregator Gives every language the base 10 logarithm of 1 plus its bytes or lines, so that large
+// projects weigh more than small ones without drowning them out
+type logScaledAggregator struct{}
+
+func (logScaledAggregator) Name() string { return "log-scaled" }
+
+func (logScaledAggregator) Score(l LanguageLinesList) map[string]float64 {
+	scores := make(map[string]float64, len(l))
+	for _, language := range l {
+		scores[language.Language] = math.Log10(1 + float64(language.Lines))
+	}
+	return scores
+}
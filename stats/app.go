@@ -0,0 +1,119 @@
+package stats
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"github.com/google/go-github/v47/github"
+	"golang.org/x/oauth2"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// appTokenSource Creates installation tokens of a GitHub App, authenticating as the app with a JWT
+type appTokenSource struct {
+	appID, installationID int64
+	key                   *rsa.PrivateKey
+	base                  http.RoundTripper
+}
+
+// NewAppTokenSource Creates a token source authenticating as the installation of the GitHub App with the PEM
+// encoded private key. Installation tokens expire after an hour and are created again shortly before.
+// When installationID is 0 the app must have exactly one installation, which is used. base sends the
+// requests creating the tokens, http.DefaultTransport when nil.
+func NewAppTokenSource(appID, installationID int64, privateKey []byte, base http.RoundTripper) (oauth2.TokenSource, error) {
+	key, err := parsePrivateKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	s := &appTokenSource{appID: appID, installationID: installationID, key: key, base: base}
+	return oauth2.ReuseTokenSource(nil, s), nil
+}
+
+func parsePrivateKey(b []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, errors.New("the GitHub App private key is not PEM encoded")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parsing the GitHub App private key: %w", err)
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("the GitHub App private key is not an RSA key")
+	}
+	return rsaKey, nil
+}
+
+// Token Creates an installation token
+func (s *appTokenSource) Token() (*oauth2.Token, error) {
+	jwt, err := s.jwt(time.Now())
+	if err != nil {
+		return nil, err
+	}
+	client := github.NewClient(&http.Client{Transport: &oauth2.Transport{
+		Source: oauth2.StaticTokenSource(&oauth2.Token{AccessToken: jwt}),
+		Base:   s.base,
+	}})
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	if s.installationID == 0 {
+		installations, _, err := client.Apps.ListInstallations(ctx, nil)
+		if err != nil {
+			return nil, fmt.Errorf("listing the installations of GitHub App %d: %w", s.appID, err)
+		}
+		if len(installations) != 1 {
+			return nil, fmt.Errorf("GitHub App %d has %d installations, select one with its installation ID", s.appID, len(installations))
+		}
+		s.installationID = installations[0].GetID()
+	}
+
+	token, _, err := client.Apps.CreateInstallationToken(ctx, s.installationID, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating a token for installation %d of GitHub App %d: %w", s.installationID, s.appID, err)
+	}
+	slog.Info("Created a GitHub App installation token", "app", s.appID, "installation", s.installationID,
+		"expires", token.GetExpiresAt().Format(time.RFC3339))
+	return &oauth2.Token{AccessToken: token.GetToken(), Expiry: token.GetExpiresAt()}, nil
+}
+
+// jwt The RS256 signed JSON web token authenticating as the app, valid for 9 minutes. It is issued a minute in
+// the past to allow for clock drift.
+func (s *appTokenSource) jwt(now time.Time) (string, error) {
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(map[string]interface{}{
+		"iat": now.Add(-time.Minute).Unix(),
+		"exp": now.Add(9 * time.Minute).Unix(),
+		"iss": strconv.FormatInt(s.appID, 10),
+	})
+	if err != nil {
+		return "", err
+	}
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	sum := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(nil, s.key, crypto.SHA256, sum[:])
+	if err != nil {
+		return "", err
+	}
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
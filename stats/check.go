@@ -0,0 +1,135 @@
+package stats
+
+import (
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"sort"
+	"strings"
+)
+
+// CheckRepos Reads a repos.yaml file like LoadRepos but reports every malformed entry and unknown group instead
+// of stopping at the first one, followed by the problems Duplicates finds. The returned Repos hold the
+// well-formed projects.
+func CheckRepos(data []byte) (Repos, []error) {
+	repos := Repos{
+		Graduated:  make(map[string]Project),
+		Incubating: make(map[string]Project),
+		Sandbox:    make(map[string]Project),
+		Custom:     make(map[string]Project),
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return repos, []error{err}
+	}
+	if len(doc.Content) == 0 {
+		return repos, nil
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return repos, []error{fmt.Errorf("line %d: expected the Graduated, Incubating, Sandbox and Custom groups", root.Line)}
+	}
+
+	var problems []error
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, value := root.Content[i], root.Content[i+1]
+		if key.Value == "weights" {
+			if err := value.Decode(&repos.Weights); err != nil {
+				problems = append(problems, fmt.Errorf("weights: %w", err))
+			}
+			continue
+		}
+		group := repos.group(key.Value)
+		switch {
+		case group == nil:
+			problems = append(problems, fmt.Errorf("line %d: unknown group %q, must be Graduated, Incubating, Sandbox or Custom", key.Line, key.Value))
+			continue
+		case value.Tag == "!!null":
+			continue
+		case value.Kind != yaml.MappingNode:
+			problems = append(problems, fmt.Errorf("line %d: %s must map project names to their URLs", value.Line, key.Value))
+			continue
+		}
+		for j := 0; j+1 < len(value.Content); j += 2 {
+			name, entry := value.Content[j], value.Content[j+1]
+			if _, ok := group[name.Value]; ok {
+				problems = append(problems, fmt.Errorf("line %d: %s is listed twice in %s", name.Line, name.Value, key.Value))
+				continue
+			}
+			var p Project
+			if err := entry.Decode(&p); err != nil {
+				problems = append(problems, fmt.Errorf("%s: %w", name.Value, err))
+				continue
+			}
+			group[name.Value] = p
+		}
+	}
+	return repos, append(problems, repos.Duplicates()...)
+}
+
+// Duplicates Reports what would be counted twice: projects listed in several groups, compared case-insensitively,
+// repo URLs listed more than once and repos already included by an org URL of another project
+func (r Repos) Duplicates() []error {
+	type listed struct {
+		group, name string
+		project     Project
+	}
+	var all []listed
+	for _, g := range r.groups() {
+		names := make([]string, 0, len(g.projects))
+		for name := range g.projects {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			all = append(all, listed{g.name, name, g.projects[name]})
+		}
+	}
+
+	// orgs The project listing every org URL, keyed by host and owner
+	orgs := make(map[string]string)
+	for _, l := range all {
+		for _, u := range l.project.URLs {
+			if ref, err := parseRepoURL(u); err == nil && ref.repo == "" {
+				orgs[strings.ToLower(ref.String())] = l.name
+			}
+		}
+	}
+
+	var problems []error
+	names := make(map[string]string)
+	urls := make(map[string]string)
+	for _, l := range all {
+		if other, ok := names[strings.ToLower(l.name)]; ok {
+			problems = append(problems, fmt.Errorf("%s is listed in %s and as %s", l.name, l.group, other))
+		} else {
+			names[strings.ToLower(l.name)] = l.group + " " + l.name
+		}
+		for _, u := range l.project.URLs {
+			ref, err := parseRepoURL(u)
+			if err != nil {
+				continue
+			}
+			key := strings.ToLower(ref.String())
+			if other, ok := urls[key]; ok {
+				if other == l.name {
+					problems = append(problems, fmt.Errorf("%s lists %s twice", l.name, u))
+				} else {
+					problems = append(problems, fmt.Errorf("%s lists %s, which %s already lists", l.name, u, other))
+				}
+				continue
+			}
+			urls[key] = l.name
+			if ref.repo == "" {
+				continue
+			}
+			org := repoURL{host: ref.host, owner: ref.owner}
+			switch other, ok := orgs[strings.ToLower(org.String())]; {
+			case ok && other == l.name:
+				problems = append(problems, fmt.Errorf("%s lists %s, which its org URL already includes", l.name, u))
+			case ok:
+				problems = append(problems, fmt.Errorf("%s lists %s, which the org URL of %s already includes", l.name, u, other))
+			}
+		}
+	}
+	return problems
+}
@@ -0,0 +1,167 @@
+package stats
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"sync"
+)
+
+// Checkpoint Records the languages of every fetched repo in a file so that an interrupted run can resume
+// without fetching them again. Each repo is appended as a JSON line when it completes.
+type Checkpoint struct {
+	mu sync.Mutex
+	// f The checkpoint file, nil for a checkpoint kept in memory
+	f     *os.File
+	repos map[string]checkpointEntry
+}
+
+// NewCheckpoint Creates a checkpoint kept in memory until it is saved to a file with Save, e.g. when a run
+// is interrupted
+func NewCheckpoint() *Checkpoint {
+	return &Checkpoint{repos: make(map[string]checkpointEntry)}
+}
+
+// checkpointEntry A line of the checkpoint file
+type checkpointEntry struct {
+	Repo      string         `json:"repo"`
+	Languages map[string]int `json:"languages"`
+	// Lines Whether the languages are code lines counted in a clone rather than bytes
+	Lines bool `json:"lines,omitempty"`
+}
+
+// OpenCheckpoint Opens the checkpoint file at path. With resume the repos recorded by a previous run are
+// loaded and kept, otherwise the file starts empty.
+func OpenCheckpoint(path string, resume bool, perm os.FileMode) (*Checkpoint, error) {
+	c := &Checkpoint{repos: make(map[string]checkpointEntry)}
+	flags := os.O_APPEND | os.O_CREATE | os.O_WRONLY
+	var torn bool
+	if resume {
+		var err error
+		if torn, err = c.load(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+		slog.Info("Resuming from the checkpoint", "repos", len(c.repos), "path", path)
+	} else {
+		flags |= os.O_TRUNC
+	}
+
+	f, err := os.OpenFile(path, flags, perm)
+	if err != nil {
+		return nil, err
+	}
+	if err := f.Chmod(perm); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if torn {
+		// Start the next line after the torn one
+		if _, err := f.Write([]byte{'\n'}); err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+	c.f = f
+	return c, nil
+}
+
+// load Reads the recorded repos and reports whether the file ends with a torn line
+func (c *Checkpoint) load(path string) (bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	for {
+		line, err := r.ReadBytes('\n')
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			var entry checkpointEntry
+			if jsonErr := json.Unmarshal(line, &entry); jsonErr != nil {
+				// A line torn by the interruption
+				slog.Warn("Skipping an unreadable checkpoint line", "path", path, "error", jsonErr)
+			} else {
+				c.repos[entry.Repo] = entry
+			}
+		}
+		if err == io.EOF {
+			return len(line) > 0, nil
+		}
+		if err != nil {
+			return false, err
+		}
+	}
+}
+
+// languages The recorded languages of the repo if they were counted the same way, lines or bytes
+func (c *Checkpoint) languages(owner, repo string, lines bool) (map[string]int, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.repos[owner+"/"+repo]
+	if !ok || entry.Lines != lines {
+		return nil, false
+	}
+	return entry.Languages, true
+}
+
+// record Records the languages of the repo and whether they are lines, appending them to the file with a
+// single write
+func (c *Checkpoint) record(owner, repo string, lines bool, languages map[string]int) error {
+	entry := checkpointEntry{Repo: owner + "/" + repo, Languages: languages, Lines: lines}
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.repos[entry.Repo] = entry
+	if c.f == nil {
+		return nil
+	}
+	_, err = c.f.Write(append(b, '\n'))
+	return err
+}
+
+// Save Writes every recorded repo to a checkpoint file at path that OpenCheckpoint can resume from and
+// returns the number of repos
+func (c *Checkpoint) Save(path string, perm os.FileMode) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var b bytes.Buffer
+	for _, entry := range c.repos {
+		line, err := json.Marshal(entry)
+		if err != nil {
+			return 0, err
+		}
+		b.Write(append(line, '\n'))
+	}
+	if err := os.WriteFile(path, b.Bytes(), perm); err != nil {
+		return 0, err
+	}
+	return len(c.repos), os.Chmod(path, perm)
+}
+
+// Reset Forgets every recorded repo, for the next run after a run completed
+func (c *Checkpoint) Reset() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.repos = make(map[string]checkpointEntry)
+	if c.f == nil {
+		return nil
+	}
+	return c.f.Truncate(0)
+}
+
+// Close Closes the checkpoint file, if there is one
+func (c *Checkpoint) Close() error {
+	if c.f == nil {
+		return nil
+	}
+	return c.f.Close()
+}
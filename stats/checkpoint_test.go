@@ -0,0 +1,46 @@
+package stats
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestCheckpointResume(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint.jsonl")
+	c, err := OpenCheckpoint(path, false, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.record("envoyproxy", "envoy", false, map[string]int{"C++": 100}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.record("gitlab.com/g", "p", true, map[string]int{"Go": 10}); err != nil {
+		t.Fatal(err)
+	}
+	c.Close()
+
+	resumed, err := OpenCheckpoint(path, true, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resumed.Close()
+	tests := []struct {
+		owner, repo string
+		lines       bool
+		want        map[string]int
+		ok          bool
+	}{
+		{"envoyproxy", "envoy", false, map[string]int{"C++": 100}, true},
+		{"envoyproxy", "envoy", true, nil, false},
+		{"gitlab.com/g", "p", true, map[string]int{"Go": 10}, true},
+		{"gitlab.com/g", "p", false, nil, false},
+		{"other", "repo", false, nil, false},
+	}
+	for _, tt := range tests {
+		got, ok := resumed.languages(tt.owner, tt.repo, tt.lines)
+		if ok != tt.ok || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s/%s lines %v: got %v %v, want %v %v", tt.owner, tt.repo, tt.lines, got, ok, tt.want, tt.ok)
+		}
+	}
+}
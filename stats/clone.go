@@ -0,0 +1,303 @@
+package stats
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// lineSyntax The comment syntax of a language, used to tell code lines from comments
+type lineSyntax struct {
+	language             string
+	lineComments         []string
+	blockStart, blockEnd string
+}
+
+var (
+	cStyle      = lineSyntax{lineComments: []string{"//"}, blockStart: "/*", blockEnd: "*/"}
+	hashStyle   = lineSyntax{lineComments: []string{"#"}}
+	markupStyle = lineSyntax{blockStart: "<!--", blockEnd: "-->"}
+)
+
+// named Returns the syntax with the language name set
+func (s lineSyntax) named(language string) lineSyntax {
+	s.language = language
+	return s
+}
+
+// languageExtensions The languages counted in clone mode by file extension, named as GitHub names them
+var languageExtensions = map[string]lineSyntax{
+	".go":    cStyle.named("Go"),
+	".rs":    cStyle.named("Rust"),
+	".c":     cStyle.named("C"),
+	".h":     cStyle.named("C"),
+	".cc":    cStyle.named("C++"),
+	".cpp":   cStyle.named("C++"),
+	".cxx":   cStyle.named("C++"),
+	".hpp":   cStyle.named("C++"),
+	".cs":    cStyle.named("C#"),
+	".java":  cStyle.named("Java"),
+	".kt":    cStyle.named("Kotlin"),
+	".scala": cStyle.named("Scala"),
+	".swift": cStyle.named("Swift"),
+	".js":    cStyle.named("JavaScript"),
+	".mjs":   cStyle.named("JavaScript"),
+	".jsx":   cStyle.named("JavaScript"),
+	".ts":    cStyle.named("TypeScript"),
+	".tsx":   cStyle.named("TypeScript"),
+	".css":   {language: "CSS", blockStart: "/*", blockEnd: "*/"},
+	".scss":  cStyle.named("SCSS"),
+	".proto": cStyle.named("Protocol Buffer"),
+	".php":   {language: "PHP", lineComments: []string{"//", "#"}, blockStart: "/*", blockEnd: "*/"},
+	".hcl":   {language: "HCL", lineComments: []string{"#", "//"}, blockStart: "/*", blockEnd: "*/"},
+	".tf":    {language: "HCL", lineComments: []string{"#", "//"}, blockStart: "/*", blockEnd: "*/"},
+	".py":    hashStyle.named("Python"),
+	".rb":    hashStyle.named("Ruby"),
+	".pl":    hashStyle.named("Perl"),
+	".sh":    hashStyle.named("Shell"),
+	".bash":  hashStyle.named("Shell"),
+	".bzl":   hashStyle.named("Starlark"),
+	".rego":  hashStyle.named("Open Policy Agent"),
+	".lua":   {language: "Lua", lineComments: []string{"--"}, blockStart: "--[[", blockEnd: "]]"},
+	".html":  markupStyle.named("HTML"),
+	".htm":   markupStyle.named("HTML"),
+	".vue":   markupStyle.named("Vue"),
+}
+
+// languageFileNames The languages counted in clone mode by file name
+var languageFileNames = map[string]lineSyntax{
+	"Makefile":    hashStyle.named("Makefile"),
+	"Dockerfile":  hashStyle.named("Dockerfile"),
+	"BUILD":       hashStyle.named("Starlark"),
+	"BUILD.bazel": hashStyle.named("Starlark"),
+}
+
+// cloneLanguages Shallow clones the repo into a temporary directory under Options.CloneDir and counts its lines,
+// counting them at Options.CloneAt when set
+func (c *Collector) cloneLanguages(ctx context.Context, name string, ref repoURL) (map[string]int, error) {
+	if !c.opts.CloneAt.IsZero() {
+		return c.cloneLanguagesAt(ctx, name, ref)
+	}
+	dir, err := os.MkdirTemp(c.opts.CloneDir, strings.ReplaceAll(ref.owner+"-"+ref.repo, "/", "-")+"-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	slog.Debug("Cloning", "repo", name)
+	url := fmt.Sprintf("https://%s/%s/%s.git", ref.host, ref.owner, ref.repo)
+	cmd := exec.CommandContext(ctx, "git", "clone", "--depth", "1", "--quiet", url, dir)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("cloning %s: %w: %s", url, err, bytes.TrimSpace(out))
+	}
+	return CountLines(dir, c.opts.CloneExcludes)
+}
+
+// cloneCacheLocks Serializes the checkouts of every Options.CloneCache clone, which the groups collected at once
+// and the Collectors of a backfill may share
+var cloneCacheLocks = keyedMutex{locks: make(map[string]*sync.Mutex)}
+
+// keyedMutex A mutex per key, created when first locked
+type keyedMutex struct {
+	mu    sync.Mutex
+	locks map[string]*sync.Mutex
+}
+
+// lock Locks the mutex of the key and returns the function unlocking it
+func (m *keyedMutex) lock(key string) func() {
+	m.mu.Lock()
+	l, ok := m.locks[key]
+	if !ok {
+		l = &sync.Mutex{}
+		m.locks[key] = l
+	}
+	m.mu.Unlock()
+	l.Lock()
+	return l.Unlock
+}
+
+// cloneLanguagesAt Checks out the last commit of the repo's default branch before Options.CloneAt in a clone
+// with its history and counts its lines. Without Options.CloneCache the clone is removed afterwards, a cached
+// clone is fetched again before its commits are looked up. Blobs are only fetched for the checked out commits.
+func (c *Collector) cloneLanguagesAt(ctx context.Context, name string, ref repoURL) (map[string]int, error) {
+	var dir string
+	if c.opts.CloneCache != "" {
+		dir = filepath.Join(c.opts.CloneCache, ref.host, ref.owner, ref.repo)
+		defer cloneCacheLocks.lock(dir)()
+	} else {
+		tmp, err := os.MkdirTemp(c.opts.CloneDir, strings.ReplaceAll(ref.owner+"-"+ref.repo, "/", "-")+"-")
+		if err != nil {
+			return nil, err
+		}
+		defer os.RemoveAll(tmp)
+		dir = tmp
+	}
+	git := func(args ...string) (string, error) {
+		out, err := exec.CommandContext(ctx, "git", append([]string{"-C", dir}, args...)...).CombinedOutput()
+		if err != nil {
+			if ctx.Err() != nil {
+				return "", ctx.Err()
+			}
+			return "", fmt.Errorf("git %s in %s: %w: %s", strings.Join(args, " "), name, err, bytes.TrimSpace(out))
+		}
+		return string(bytes.TrimSpace(out)), nil
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+		// The cached clone may predate the commits before CloneAt
+		slog.Debug("Fetching the cached clone", "repo", name)
+		if _, err := git("fetch", "--quiet", "origin"); err != nil {
+			return nil, err
+		}
+	} else {
+		slog.Debug("Cloning with history", "repo", name)
+		url := fmt.Sprintf("https://%s/%s/%s.git", ref.host, ref.owner, ref.repo)
+		mode := c.opts.CloneCacheMode
+		if mode == 0 {
+			mode = 0755
+		}
+		if err := os.MkdirAll(dir, mode); err != nil {
+			return nil, err
+		}
+		if _, err := git("clone", "--quiet", "--filter=blob:none", "--no-checkout", url, "."); err != nil {
+			return nil, err
+		}
+	}
+	commit, err := git("rev-list", "-1", "--first-parent", "--before="+c.opts.CloneAt.UTC().Format(time.RFC3339), "origin/HEAD")
+	if err != nil {
+		return nil, err
+	}
+	if commit == "" {
+		slog.Debug("Repo has no commit before the date", "repo", name, "date", c.opts.CloneAt)
+		return map[string]int{}, nil
+	}
+	slog.Debug("Checking out", "repo", name, "commit", commit)
+	if _, err := git("checkout", "--quiet", "--force", "--detach", commit); err != nil {
+		return nil, err
+	}
+	return CountLines(dir, c.opts.CloneExcludes)
+}
+
+// CountLines Counts the code lines of every language in the files under dir, leaving out blank lines,
+// comments, the files of unknown languages and those matching the exclude globs. The linguist-vendored,
+// linguist-generated, linguist-documentation and linguist-language attributes in the root .gitattributes
+// override the excludes and the detected languages.
+func CountLines(dir string, excludes []string) (map[string]int, error) {
+	filter, err := newFileFilter(dir, excludes)
+	if err != nil {
+		return nil, err
+	}
+	lines := make(map[string]int)
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == dir {
+			return nil
+		}
+		rel := relPath(dir, path)
+		if d.IsDir() {
+			if d.Name() == ".git" || filter.skipDir(rel) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		included, language := filter.file(rel)
+		if !included {
+			return nil
+		}
+		syntax, ok := languageFileNames[d.Name()]
+		if !ok {
+			syntax, ok = languageExtensions[strings.ToLower(filepath.Ext(d.Name()))]
+		}
+		if language != "" {
+			syntax, ok = syntaxOf(language), true
+		}
+		if !ok {
+			return nil
+		}
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if n := countCodeLines(b, syntax); n > 0 {
+			lines[syntax.language] += n
+		}
+		return nil
+	})
+	return lines, err
+}
+
+// countCodeLines Counts the lines of the file holding code, binary files have none
+func countCodeLines(b []byte, syntax lineSyntax) int {
+	head := b
+	if len(head) > 8000 {
+		head = head[:8000]
+	}
+	if bytes.IndexByte(head, 0) >= 0 {
+		return 0
+	}
+
+	var n int
+	var inBlock bool
+	for _, line := range strings.Split(string(b), "\n") {
+		line = strings.TrimSpace(line)
+		code := false
+		for line != "" {
+			if inBlock {
+				end := strings.Index(line, syntax.blockEnd)
+				if end < 0 {
+					break
+				}
+				inBlock = false
+				line = strings.TrimSpace(line[end+len(syntax.blockEnd):])
+				continue
+			}
+			// Block comments first, Lua's --[[ also starts with its line comment --
+			if syntax.blockStart != "" && strings.HasPrefix(line, syntax.blockStart) {
+				inBlock = true
+				line = line[len(syntax.blockStart):]
+				continue
+			}
+			if hasAnyPrefix(line, syntax.lineComments) {
+				break
+			}
+			// A line starting with code counts as code even when a comment follows,
+			// which may open a block comment continuing on the next lines
+			code = true
+			if syntax.blockStart != "" {
+				if start := strings.LastIndex(line, syntax.blockStart); start >= 0 {
+					inBlock = !strings.Contains(line[start+len(syntax.blockStart):], syntax.blockEnd)
+				}
+			}
+			break
+		}
+		if code {
+			n++
+		}
+	}
+	return n
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,95 @@
+package stats
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestCountCodeLines(t *testing.T) {
+	tests := []struct {
+		name   string
+		syntax lineSyntax
+		file   string
+		want   int
+	}{
+		{"blank lines", cStyle, "package main\n\n\t\nfunc main() {}\n", 2},
+		{"line comments", cStyle, "// Package main\npackage main\n  // indented\n", 1},
+		{"code before a line comment", cStyle, "x := 1 // one\n", 1},
+		{"block comment", cStyle, "/*\nlicense\n*/\npackage main\n", 1},
+		{"one line block comment", cStyle, "/* license */\npackage main\n", 1},
+		{"code after a block comment", cStyle, "/* a */ x := 1\n", 1},
+		{"code after a block comment ends", cStyle, "/*\na\n*/ x := 1\n", 1},
+		{"block comment opened after code", cStyle, "x := 1 /* a\nb\n*/\ny := 2\n", 2},
+		{"block comment closed on the line", cStyle, "x := 1 /* a */\ny := 2\n", 2},
+		{"several line comment prefixes", languageExtensions[".php"], "# a\n// b\n<?php\n", 1},
+		{"hash comments", hashStyle, "#!/bin/sh\n# comment\necho hi\n", 1},
+		{"markup comments", markupStyle, "<!-- a\nb -->\n<p>hi</p>\n", 1},
+		{"Lua line comment", languageExtensions[".lua"], "-- comment\nlocal x = 1\n", 1},
+		{"Lua block comment starting a line", languageExtensions[".lua"], "--[[\nlocal y = 2\n]]\nlocal x = 1\n", 1},
+		{"Lua one line block comment", languageExtensions[".lua"], "--[[ a ]] local x = 1\n", 1},
+		{"Lua block comment after code", languageExtensions[".lua"], "local x = 1 --[[ a\nb\n]]\n", 1},
+		{"binary", cStyle, "a\x00b\n", 0},
+		{"empty", cStyle, "", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := countCodeLines([]byte(tt.file), tt.syntax); got != tt.want {
+				t.Errorf("got %d code lines, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCountLines(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    map[string]string
+		excludes []string
+		want     map[string]int
+	}{
+		{
+			name:  "languages by extension and file name",
+			files: map[string]string{"main.go": "package main\n", "lib/x.lua": "local x = 1\n", "Makefile": "all:\n\tgo build\n", "README.md": "# x\n"},
+			want:  map[string]int{"Go": 1, "Lua": 1, "Makefile": 2},
+		},
+		{
+			name:     "excludes",
+			files:    map[string]string{"main.go": "package main\n", "vendor/x/x.go": "package x\n"},
+			excludes: []string{"vendor/**"},
+			want:     map[string]int{"Go": 1},
+		},
+		{
+			name:  "git directory",
+			files: map[string]string{"main.go": "package main\n", ".git/hooks/x.sh": "echo\n"},
+			want:  map[string]int{"Go": 1},
+		},
+		{
+			name:  "files without code",
+			files: map[string]string{"doc.go": "// Package x\n"},
+			want:  map[string]int{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tt.files {
+				path := filepath.Join(dir, name)
+				if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			got, err := CountLines(dir, tt.excludes)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,1255 @@
+package stats
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/go-github/v47/github"
+	"golang.org/x/oauth2"
+	"golang.org/x/time/rate"
+	"log/slog"
+	"math"
+	"net/http"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Options Configures a Collector
+type Options struct {
+	// Token GitHub token used to authenticate API requests
+	Token string
+	// GitLabToken GitLab token used to authenticate requests to gitlab.com, which are anonymous when empty
+	GitLabToken string
+	// Tokens Rotate between several tokens instead of Token when set, switching to the next token once the
+	// rate limit of the current one is exhausted. Requests only wait for a reset when every token is exhausted.
+	Tokens []string
+	// TokenSource Authenticates API requests instead of Token when set, e.g. as a GitHub App with NewAppTokenSource
+	TokenSource oauth2.TokenSource
+	// BaseTransport Sends the GitHub and GitLab API requests, http.DefaultTransport when nil.
+	// The oauth2 transport adding the token wraps it, so requests reaching it already carry the
+	// Authorization header. This is the hook for request logging, proxies and fake transports in tests.
+	BaseTransport http.RoundTripper
+	// LanguageLister Fetches the languages of GitHub repos instead of the GitHub client when set, e.g. a fake,
+	// a replay of recorded responses or another source of GitHub's language stats. GraphQL queries still fetch
+	// the languages themselves.
+	LanguageLister LanguageLister
+	// Limiter Additionally paces GitHub API requests when set and HostLimits has no entry for github.com.
+	// Without it requests are only held back once GitHub reports the rate limit as exhausted or a secondary
+	// rate limit is hit. The limiter is shared by all groups a Collector processes concurrently and may also
+	// be shared between Collectors.
+	Limiter *rate.Limiter
+	// HostLimits Pace and cap the requests to each code host by its name, e.g. gitlab.com, see Hosts. Every host
+	// has its own rate limit state, so that GitHub's exhausted quota or Limiter never holds back GitLab requests.
+	HostLimits map[string]HostLimit
+	// ReserveQuota Holds back GitHub requests until the rate limit resets once a response reports this many
+	// requests remaining or fewer, leaving them to other jobs sharing the token. 0 uses up the whole quota.
+	ReserveQuota int
+	// ComputeConcentration Adds each language's largest project and its share to the Result
+	ComputeConcentration bool
+	// Workers Number of projects fetched concurrently from a host whose requests are not capped by HostLimits or
+	// DefaultHostConcurrency, 1 when not positive. Every capped host has as many workers as requests in flight.
+	Workers int
+	// MaxLanguagesPerRepo Keeps only the largest languages of a repo, 0 keeps all
+	MaxLanguagesPerRepo int
+	// GraphQL Fetches the languages of up to 50 repos per GraphQL query before the workers start instead of
+	// a REST request per repo. Repos the queries cannot return are fetched with the REST API.
+	GraphQL bool
+	// Clone Counts the code lines of every language in shallow clones of the repos instead of fetching the
+	// bytes GitHub reports. Org listings are still fetched from the API.
+	Clone bool
+	// CloneDir Directory the repos are cloned into while counting, the system's temporary directory when empty
+	CloneDir string
+	// CloneAt Counts the lines of every repo as of the last commit of its default branch before this time instead
+	// of its latest commit when set. The repos are cloned with their history, a repo without a commit before
+	// CloneAt has no languages.
+	CloneAt time.Time
+	// CloneCache Keeps the history clones of CloneAt in this directory instead of cloning every repo for every
+	// collection, e.g. for collecting several past dates
+	CloneCache string
+	// CloneCacheMode Permissions of the directories created in CloneCache, 0755 when 0
+	CloneCacheMode os.FileMode
+	// CloneExcludes Globs of the files left out of clone mode's line counts, e.g. DefaultCloneExcludes.
+	// A glob without a slash matches base names at any depth and "dir/" every directory named dir.
+	CloneExcludes []string
+	// MinPercent, MinLines Sum up the languages of a project below this share of the project, from 0 to 100,
+	// or below this many bytes or lines as OtherLanguage. The project's top language is always kept.
+	MinPercent float64
+	MinLines   int
+	// TopN Records the top N languages of every project and scores them in Result.WeightedTopLanguage
+	// when above 1
+	TopN int
+	// Aliases Renames languages before they are aggregated, summing up the languages renamed to the same name
+	Aliases map[string]string
+	// Aggregator Scores the languages of every group in Result.Scores with another methodology than counting
+	// top languages when set, see RegisterAggregator
+	Aggregator Aggregator
+	// Retry Retries requests failing with transient errors, requests are not retried by default.
+	// Rate limited requests are always retried once the limit allows.
+	Retry Retry
+	// RequestTimeout Cancels every attempt of an API request taking longer, a timed out attempt is retried like
+	// a network error. Attempts are only bounded by the context when not positive.
+	RequestTimeout time.Duration
+	// CheckRepos Looks up every repo given by its URL before fetching its languages to detect renamed,
+	// transferred and archived repos, costing a request per repo unless GraphQL is used. They are listed in
+	// Result.Renamed and Result.Archived. Archived repos of orgs are always detected.
+	CheckRepos bool
+	// SkipForks, SkipMirrors and SkipArchived Leave the forks, mirrors or archived repos of orgs and of projects
+	// listing several repos out of the stats, so that copies do not inflate them. The repos listed by URL are
+	// looked up to tell, projects of a single repo always keep it.
+	SkipForks, SkipMirrors, SkipArchived bool
+	// MaxRepoSize Caps the bytes or lines a repo adds to the group totals after its weight, 0 for no cap.
+	// Its project still reports it in full.
+	MaxRepoSize int
+	// ExcludeRepos Leaves the repos of orgs and of projects listing several repos whose owner/repo matches any
+	// of these path.Match patterns, ignoring case, out of the stats, e.g. "*/website" or "kubernetes/kube-*"
+	ExcludeRepos []string
+	// LanguagePresence Counts the projects containing each language in Result.LanguagePresence, those with at
+	// least PresencePercent, from 0 to 100, of a project's bytes or lines. Languages are counted before
+	// MinPercent and MinLines sum them up.
+	LanguagePresence bool
+	PresencePercent  float64
+	// LanguageProjects Lists the projects of every top language in Result.ProjectsByLanguage and, with
+	// LanguagePresence, the projects containing every language in Result.ProjectsContaining
+	LanguageProjects bool
+	// Normalize Computes Result.Normalized, the share of projects containing each language and its average
+	// bytes or lines per project
+	Normalize bool
+	// Metadata Records the stars, forks, open issues, license, default branch and last push of every project
+	// in Result.Metadata. Repos given by their URL are looked up like with CheckRepos, org listings and GraphQL
+	// queries already report them.
+	Metadata bool
+	// Licenses Counts the projects of every SPDX license identifier in Result.Licenses, looking up the repos
+	// like Metadata. Projects take the license of their first repo, projects without one are counted as
+	// NoLicense.
+	Licenses bool
+	// Contributors Counts the contributors of every repo, a request per repo even in clone mode, and records
+	// their sum per project in Result.Contributors. People contributing to several repos of a project or
+	// group are counted once per repo.
+	Contributors bool
+	// CommitActivity Counts the commits of the last 52 weeks to the default branch of every repo, a request per
+	// repo even in clone mode, and records their sum per project in Result.Commits and per top language of
+	// the projects in Result.CommitsByLanguage
+	CommitActivity bool
+	// GoModules Fetches the go.mod of every repo whose top language is Go, a request per such repo even in clone
+	// mode, and records the declared Go version of every project in Result.GoVersions and the number of
+	// projects directly requiring each module in Result.GoModules
+	GoModules bool
+	// Releases Lists the releases of the last year of every repo, a request per 100 releases even in clone mode,
+	// and records how actively every project ships in Result.Releases and averaged across the group in
+	// Result.ReleaseCadence. Repos without releases, e.g. only tagging them, count as not releasing.
+	Releases bool
+	// Checkpoint Records every fetched repo when set. Repos it already holds counted the same way, as lines with
+	// Clone and bytes otherwise, are not fetched again.
+	Checkpoint *Checkpoint
+	// Incremental Reuses the languages of the repos not pushed to since they were recorded in the state when
+	// set, and records those of every repo fetched. Repos given by their URL are looked up like with CheckRepos
+	// for their last push.
+	Incremental *RepoState
+	// Force Fetches every repo with Incremental, still recording them
+	Force bool
+	// Build The build of the tool, recorded in Result.Meta
+	Build BuildInfo
+	// RepoHook Is called with the sorted languages of every processed repo when set.
+	// It may be called concurrently when several groups are collected at once.
+	RepoHook func(repoGroup, project, owner, repo string, l LanguageLinesList)
+	// Progress Is called when a group starts and after every processed project when set.
+	// It may be called concurrently when several groups are collected at once.
+	Progress func(p Progress)
+}
+
+// Progress How far Collect got with a group
+type Progress struct {
+	Group string
+	// Done, Total Projects processed so far, including failed ones, and in the group
+	Done, Total int
+	// Repos Repos fetched so far
+	Repos int
+	// Project The project processed last, empty before the first one
+	Project string
+	// RateRemaining Requests the GitHub rate limit allows until it resets, -1 before the first response
+	RateRemaining int
+	// Started When Collect started the group
+	Started time.Time
+}
+
+// ETA Estimated time until the group is done, extrapolating from the time the processed projects took.
+// It is 0 before the first project is done.
+func (p Progress) ETA() time.Duration {
+	if p.Done == 0 {
+		return 0
+	}
+	elapsed := time.Since(p.Started)
+	return elapsed * time.Duration(p.Total-p.Done) / time.Duration(p.Done)
+}
+
+// LanguageLister Lists the bytes of every language of a GitHub repo, implemented by the Repositories service of
+// a *github.Client. The response may be nil, it is only used for its rate limit and the redirect of moved repos.
+type LanguageLister interface {
+	ListLanguages(ctx context.Context, owner, repo string) (map[string]int, *github.Response, error)
+}
+
+// Collector Fetches the language stats of projects from GitHub or GitLab and aggregates them per group
+type Collector struct {
+	GitHubClient *github.Client
+	// Weights Scale the bytes or lines the lower case owner/repo adds to the group totals, see Repos.Weights.
+	// It must not change while Collect runs.
+	Weights map[string]float64
+	// Languages Fetches the languages of GitHub repos, Options.LanguageLister or the GitHub client's
+	// Repositories service
+	Languages LanguageLister
+	opts      Options
+	// hosts The rate limit state, pacing and concurrency cap of every host in providers
+	hosts map[string]*hostLimiter
+	// providers The code host of every supported URL host
+	providers map[string]provider
+}
+
+// NewCollector Creates a Collector authenticating to GitHub with opts.TokenSource or opts.Token
+func NewCollector(opts Options) *Collector {
+	if opts.BaseTransport == nil {
+		opts.BaseTransport = http.DefaultTransport
+	}
+	if opts.Workers < 1 {
+		opts.Workers = 1
+	}
+	source := opts.TokenSource
+	if source == nil {
+		source = oauth2.StaticTokenSource(&oauth2.Token{AccessToken: opts.Token})
+	}
+	var transport http.RoundTripper = &oauth2.Transport{Source: source, Base: opts.BaseTransport}
+	if len(opts.Tokens) > 0 && opts.TokenSource == nil {
+		transport = newTokenPool(opts.Tokens, opts.BaseTransport)
+	}
+	c := &Collector{
+		GitHubClient: github.NewClient(&http.Client{Transport: transport}),
+		opts:         opts,
+	}
+	c.Languages = opts.LanguageLister
+	if c.Languages == nil {
+		c.Languages = c.GitHubClient.Repositories
+	}
+	c.initProviders()
+	c.hosts = make(map[string]*hostLimiter, len(c.providers))
+	for host := range c.providers {
+		limit, ok := opts.HostLimits[host]
+		if !ok && host == gitHubHost {
+			limit.Limiter = opts.Limiter
+		}
+		var reserve int
+		if host == gitHubHost {
+			reserve = opts.ReserveQuota
+		}
+		c.hosts[host] = newHostLimiter(host, limit, reserve)
+	}
+	return c
+}
+
+// initProviders Sets up the code hosts fetching through c
+func (c *Collector) initProviders() {
+	c.providers = map[string]provider{
+		gitHubHost: gitHubProvider{c: c},
+		gitLabHost: gitLabProvider{
+			c:       c,
+			client:  &http.Client{Transport: c.opts.BaseTransport},
+			token:   c.opts.GitLabToken,
+			baseURL: gitLabAPI,
+		},
+	}
+}
+
+// Split Returns n Collectors for collecting n groups at once, each with its own budget of an n-th of the rate
+// and concurrency of every host, at least one request at a time. They share c's clients and the rate limit state
+// of every host, so all of them pause once a host's quota is exhausted, and a large group cannot starve the
+// others. Weights is copied, it must be set before.
+func (c *Collector) Split(n int) []*Collector {
+	collectors := make([]*Collector, n)
+	for i := range collectors {
+		s := *c
+		s.hosts = make(map[string]*hostLimiter, len(c.hosts))
+		for host, h := range c.hosts {
+			s.hosts[host] = h.split(n)
+		}
+		s.initProviders()
+		collectors[i] = &s
+	}
+	return collectors
+}
+
+// workers The number of workers fetching the projects of the host, as many as the host's requests in flight
+func (c *Collector) workers(host string) int {
+	if h, ok := c.hosts[host]; ok && h.slots != nil {
+		return cap(h.slots)
+	}
+	return c.opts.Workers
+}
+
+// groupResult Accumulates the stats of a single project group
+type groupResult struct {
+	Result
+	// projectLanguages Sorted language stats of every project in the group
+	projectLanguages map[string]LanguageLinesList
+}
+
+// fetchedProject The languages of every repo of a project as fetched by a worker
+type fetchedProject struct {
+	name    string
+	project Project
+	repos   []repoLanguages
+	// renamed The current URL of every renamed repo URL of the project
+	renamed map[string]string
+	// archived The names of the project's archived repos
+	archived []string
+	// estimated The names of the project's repos whose bytes are estimated
+	estimated []string
+	err       error
+}
+
+// repoLanguages The languages of a single repo
+type repoLanguages struct {
+	owner, repo string
+	languages   map[string]int
+	// metadata The repo's metadata when known
+	metadata *Metadata
+	// contributors The repo's contributors when counted, -1 when counting them failed
+	contributors int
+	// commits The repo's commits of the last year when counted, -1 when counting them failed
+	commits int
+	// goMod The repo's go.mod when fetched and the repo has one
+	goMod *goModule
+	// releases The repo's releases when listed
+	releases *repoReleases
+}
+
+// Collect Collects the language stats of every project in the group, stopping early when ctx is cancelled.
+// Every host has its own pool of workers fetching the projects of its first URL, so that a host whose rate limit
+// holds back its workers never holds back another's, and repos are aggregated as they arrive. Projects whose
+// repos fail to fetch are left out of the stats and listed in Result.Errors.
+func (c *Collector) Collect(ctx context.Context, repoGroup string, projects map[string]Project) (Result, error) {
+	g := groupResult{
+		Result: Result{
+			TopLanguage: make(map[string]int),
+			Projects:    make(map[string]ProjectResult),
+			Errors:      make(map[string]string),
+		},
+		projectLanguages: make(map[string]LanguageLinesList),
+	}
+	if c.opts.Aggregator != nil {
+		g.Aggregation = c.opts.Aggregator.Name()
+		g.Scores = make(map[string]float64)
+	}
+
+	if c.opts.Clone {
+		g.TotalLines = make(map[string]int)
+	} else {
+		g.TotalBytes = make(map[string]int)
+	}
+
+	// done The projects fetched or left out, those missing remain when the collection is interrupted
+	done := make(map[string]bool, len(projects))
+	meta := RunMeta{BuildInfo: c.opts.Build, Projects: len(projects), API: c.apiMode()}
+	requests := new(atomic.Int64)
+	ctx = context.WithValue(ctx, requestCounterKey{}, requests)
+	var pre *prefetched
+	if c.opts.GraphQL {
+		var err error
+		if pre, err = c.prefetchGraphQL(ctx, projects); err != nil {
+			if ctx.Err() != nil {
+				return Result{}, interrupted(repoGroup, projects, done, err)
+			}
+			slog.Warn("GraphQL failed, falling back to the REST API", "error", err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	byHost := make(map[string][]string)
+	for name, project := range projects {
+		host := projectHost(project)
+		byHost[host] = append(byHost[host], name)
+	}
+	fetched := make(chan fetchedProject)
+	var wg sync.WaitGroup
+	for host, names := range byHost {
+		jobs := make(chan string)
+		go func() {
+			defer close(jobs)
+			for _, name := range names {
+				select {
+				case jobs <- name:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+		for i := 0; i < c.workers(host); i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for name := range jobs {
+					fetched <- c.fetchProject(ctx, name, projects[name], pre)
+				}
+			}()
+		}
+	}
+	go func() {
+		wg.Wait()
+		close(fetched)
+	}()
+
+	progress := Progress{Group: repoGroup, Total: len(projects), RateRemaining: -1, Started: time.Now()}
+	c.reportProgress(progress)
+	var capped []string
+	var err error
+	for f := range fetched {
+		if err != nil {
+			// Drain the workers after a failure
+			continue
+		}
+		progress.Done++
+		progress.Repos += len(f.repos)
+		progress.Project = f.name
+		c.reportProgress(progress)
+		if f.err != nil {
+			if ctx.Err() != nil {
+				err = f.err
+				cancel()
+				continue
+			}
+			done[f.name] = true
+			// Keep going without the project
+			slog.Warn("Leaving out the project", "group", repoGroup, "project", f.name, "error", f.err)
+			g.Errors[f.name] = f.err.Error()
+			continue
+		}
+		done[f.name] = true
+		for old, current := range f.renamed {
+			if g.Renamed == nil {
+				g.Renamed = make(map[string]string)
+			}
+			g.Renamed[old] = current
+		}
+		g.Archived = append(g.Archived, f.archived...)
+		g.EstimatedBytes = append(g.EstimatedBytes, f.estimated...)
+		if c.wantsMetadata() {
+			var m Metadata
+			for _, r := range f.repos {
+				if r.metadata != nil {
+					m.add(*r.metadata)
+				}
+			}
+			if c.opts.Metadata {
+				if g.Metadata == nil {
+					g.Metadata = make(map[string]Metadata)
+				}
+				g.Metadata[f.name] = m
+			}
+			if c.opts.Licenses {
+				g.processLicense(m.License)
+			}
+		}
+		if c.opts.Contributors {
+			contributors := 0
+			for _, r := range f.repos {
+				if r.contributors > 0 {
+					contributors += r.contributors
+				}
+			}
+			if g.Contributors == nil {
+				g.Contributors = make(map[string]int)
+			}
+			g.Contributors[f.name] = contributors
+			g.TotalContributors += contributors
+		}
+		if c.opts.GoModules {
+			g.processGoModules(f)
+		}
+		if c.opts.Releases {
+			var releases []repoReleases
+			for _, r := range f.repos {
+				if r.releases != nil {
+					releases = append(releases, *r.releases)
+				}
+			}
+			if g.Releases == nil {
+				g.Releases = make(map[string]ProjectReleases)
+			}
+			g.Releases[f.name] = projectReleases(releases, time.Now())
+		}
+		commits := 0
+		if c.opts.CommitActivity {
+			for _, r := range f.repos {
+				if r.commits > 0 {
+					commits += r.commits
+				}
+			}
+			if g.Commits == nil {
+				g.Commits = make(map[string]int)
+			}
+			g.Commits[f.name] = commits
+		}
+
+		projectLines := make(map[string]int)
+		// weightedLines The project's languages summed with the weights of its repos, when any is weighted
+		var weightedLines map[string]int
+		var repos []string
+		for _, r := range f.repos {
+			label := f.name
+			if len(f.repos) > 1 {
+				label = fmt.Sprintf("%s (%s/%s)", f.name, r.owner, r.repo)
+			}
+			if len(r.languages) == 0 {
+				slog.Warn("Repo does not contain any language stats", "repo", label)
+				continue
+			}
+			l := SortLanguageMap(c.alias(r.languages))
+			if max := c.opts.MaxLanguagesPerRepo; max > 0 && len(l) > max {
+				slog.Info("Capping the languages of the repo", "repo", label, "languages", len(l), "kept", max)
+				l = l[:max]
+				capped = append(capped, label)
+			}
+			if c.opts.RepoHook != nil {
+				c.opts.RepoHook(repoGroup, f.name, r.owner, r.repo, l)
+			}
+			for _, language := range l {
+				projectLines[language.Language] += language.Lines
+			}
+			if w := c.repoWeight(r.owner+"/"+r.repo, l); w != 1 || weightedLines != nil {
+				if weightedLines == nil {
+					// The repos before this one count in full
+					weightedLines = make(map[string]int, len(projectLines))
+					for language, lines := range projectLines {
+						weightedLines[language] = lines
+					}
+					for _, language := range l {
+						weightedLines[language.Language] -= language.Lines
+					}
+				}
+				if w != 1 {
+					slog.Debug("Weighting the repo in the group totals", "repo", label, "weight", w)
+					if g.Weights == nil {
+						g.Weights = make(map[string]float64)
+					}
+					g.Weights[r.owner+"/"+r.repo] = w
+				}
+				for _, language := range l {
+					weightedLines[language.Language] += int(math.Round(float64(language.Lines) * w))
+				}
+			}
+			repos = append(repos, r.owner+"/"+r.repo)
+		}
+		if len(projectLines) == 0 {
+			if len(f.repos) != 1 {
+				slog.Warn("Project does not contain any language stats", "project", f.name)
+			}
+			meta.Skipped++
+			continue
+		}
+		if len(f.repos) == 1 {
+			repos = nil
+		}
+
+		l := SortLanguageMap(projectLines)
+		if c.opts.LanguagePresence {
+			g.processLanguagePresence(f.name, l, c.opts.PresencePercent, c.opts.LanguageProjects)
+		}
+		if c.opts.MinPercent > 0 || c.opts.MinLines > 0 {
+			l = c.bucketSmall(l)
+			projectLines = make(map[string]int, len(l))
+			for _, language := range l {
+				projectLines[language.Language] = language.Lines
+			}
+		}
+		// totals The project's languages added to the group totals
+		totals := l
+		if weightedLines != nil {
+			totals = weighLanguages(l, weightedLines)
+		}
+		g.projectLanguages[f.name] = totals
+		g.Projects[f.name] = ProjectResult{
+			URL:          f.project.URLs[0],
+			Repos:        repos,
+			TopLanguage:  l[0].Language,
+			MaturityDate: f.project.MaturityDate,
+			Languages:    projectLines,
+			Percentages:  LanguagePercentages(l),
+		}
+		if c.opts.TopN > 1 {
+			g.processWeightedTopLanguages(f.name, l, c.opts.TopN)
+		}
+		if c.opts.Aggregator != nil {
+			for language, score := range c.opts.Aggregator.Score(l) {
+				g.Scores[language] += score
+			}
+		}
+		if c.opts.CommitActivity {
+			if g.CommitsByLanguage == nil {
+				g.CommitsByLanguage = make(map[string]int)
+			}
+			g.CommitsByLanguage[l[0].Language] += commits
+		}
+		if c.opts.Normalize {
+			g.processPresence(l)
+		}
+		if c.opts.LanguageProjects {
+			if g.ProjectsByLanguage == nil {
+				g.ProjectsByLanguage = make(map[string][]string)
+			}
+			g.ProjectsByLanguage[l[0].Language] = append(g.ProjectsByLanguage[l[0].Language], f.name)
+		}
+
+		// Process repo language statistics
+		g.processTopLanguageStats(l)
+		g.processTotalLinesStats(totals)
+	}
+	if err == nil && ctx.Err() != nil {
+		// Cancelled between projects, the workers stopped without a failure
+		err = ctx.Err()
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			return Result{}, interrupted(repoGroup, projects, done, err)
+		}
+		return Result{}, err
+	}
+
+	if len(g.Errors) == 0 {
+		g.Errors = nil
+	}
+	sort.Strings(g.Archived)
+	sort.Strings(g.EstimatedBytes)
+	sortProjectLists(g.ProjectsByLanguage)
+	sortProjectLists(g.ProjectsContaining)
+	if len(capped) > 0 {
+		sort.Strings(capped)
+		slog.Info("Projects exceeded the languages per repo limit", "count", len(capped), "projects", strings.Join(capped, ", "))
+	}
+	meta.MaxLanguagesPerRepo, meta.CappedRepos = c.opts.MaxLanguagesPerRepo, len(capped)
+	if c.opts.ComputeConcentration {
+		g.processConcentrationStats()
+	}
+	g.SchemaVersion = SchemaVersion
+	meta.Failed = len(g.Errors)
+	meta.Requests = int(requests.Load())
+	meta.CollectedAt = time.Now().UTC().Format(time.RFC3339)
+	g.Meta = &meta
+	if c.opts.Normalize {
+		g.Normalized = normalize(g.Normalized, g.TopLanguage, g.Totals())
+	}
+	g.Gini = Gini(g.Totals())
+	g.ReleaseCadence = releaseCadence(g.Releases)
+	g.Percentages = LanguagePercentages(SortLanguageMap(g.Totals()))
+	return g.Result, nil
+}
+
+// reportProgress Passes the progress with the current rate limit to Options.Progress, if set
+func (c *Collector) reportProgress(p Progress) {
+	if c.opts.Progress == nil {
+		return
+	}
+	p.RateRemaining = c.hosts[gitHubHost].gate.remaining()
+	c.opts.Progress(p)
+}
+
+// alias Renames the languages by Options.Aliases
+func (c *Collector) alias(languages map[string]int) map[string]int {
+	if len(c.opts.Aliases) == 0 {
+		return languages
+	}
+	aliased := make(map[string]int, len(languages))
+	for lang, v := range languages {
+		if to, ok := c.opts.Aliases[lang]; ok {
+			lang = to
+		}
+		aliased[lang] += v
+	}
+	return aliased
+}
+
+// bucketSmall Sums up the languages below Options.MinPercent or Options.MinLines of the sorted list as
+// OtherLanguage, keeping the top language
+func (c *Collector) bucketSmall(l LanguageLinesList) LanguageLinesList {
+	var total int
+	for _, language := range l {
+		total += language.Lines
+	}
+	kept := l[:1:1]
+	var other int
+	for _, language := range l[1:] {
+		small := language.Lines < c.opts.MinLines ||
+			total > 0 && float64(language.Lines)*100/float64(total) < c.opts.MinPercent
+		if small || language.Language == OtherLanguage {
+			other += language.Lines
+		} else {
+			kept = append(kept, language)
+		}
+	}
+	if other > 0 {
+		kept = append(kept, LanguageLines{Language: OtherLanguage, Lines: other})
+		// Other may outgrow the languages kept, but never replaces the top language
+		sortLanguages(kept[1:])
+	}
+	return kept
+}
+
+// fetchProject Fetches the languages of every repo of the project, expanding org URLs into the org's repos.
+// Org listings and languages in pre are used instead of fetching them.
+func (c *Collector) fetchProject(ctx context.Context, name string, project Project, pre *prefetched) fetchedProject {
+	f := fetchedProject{name: name, project: project}
+	for _, u := range project.URLs {
+		ref, err := parseRepoURL(u)
+		if err != nil {
+			f.err = fmt.Errorf("%s: %w", name, err)
+			return f
+		}
+		p := c.providers[ref.host]
+		repos := []repoInfo{{owner: ref.owner, name: ref.repo}}
+		// filtered Whether forks, mirrors and archived repos are left out of the project, which needs the lookup
+		filtered := len(project.URLs) > 1 && (c.opts.SkipForks || c.opts.SkipMirrors || c.opts.SkipArchived)
+		if ref.repo != "" && (c.opts.CheckRepos || c.wantsMetadata() || c.opts.Incremental != nil || filtered) {
+			var info repoInfo
+			if info, f.err = c.lookUpRepo(ctx, name, p, ref, pre); f.err != nil {
+				return f
+			}
+			if c.opts.CheckRepos && !strings.EqualFold(info.owner+"/"+info.name, ref.owner+"/"+ref.repo) {
+				current := repoURL{host: ref.host, owner: info.owner, repo: info.name}.String()
+				slog.Warn("Repo moved", "project", name, "url", u, "current", current)
+				if f.renamed == nil {
+					f.renamed = make(map[string]string)
+				}
+				f.renamed[u] = current
+			}
+			repos[0] = info
+		}
+		if ref.repo == "" {
+			var ok bool
+			if pre != nil && ref.host == gitHubHost {
+				repos, ok = pre.orgs[ref.owner]
+			}
+			if !ok {
+				if repos, f.err = p.listRepos(ctx, name, ref.owner); f.err != nil {
+					return f
+				}
+			}
+		}
+		// expanded Whether the project has several repos, the filters leave single repos alone
+		expanded := ref.repo == "" || len(project.URLs) > 1
+		for _, info := range repos {
+			ref := repoURL{host: ref.host, owner: info.owner, repo: info.name}
+			label := name
+			if len(project.URLs) > 1 || len(repos) > 1 {
+				label = fmt.Sprintf("%s (%s/%s)", name, ref.namespace(), ref.repo)
+			}
+			if expanded {
+				if reason := c.excluded(info, ref); reason != "" {
+					slog.Info("Leaving out the repo", "repo", label, "reason", reason)
+					continue
+				}
+			}
+			if info.archived {
+				slog.Warn("Repo is archived", "repo", label)
+				f.archived = append(f.archived, ref.namespace()+"/"+ref.repo)
+			}
+			r := repoLanguages{owner: ref.namespace(), repo: ref.repo, metadata: info.metadata}
+			start := time.Now()
+			if r.languages, f.err = c.fetchLanguages(ctx, label, p, ref, info.metadata, pre); f.err != nil {
+				return f
+			}
+			if ref.host == gitLabHost && !c.opts.Clone && len(r.languages) > 0 {
+				f.estimated = append(f.estimated, ref.namespace()+"/"+ref.repo)
+			}
+			if c.opts.GoModules && len(r.languages) > 0 && SortLanguageMap(c.alias(r.languages))[0].Language == "Go" {
+				if r.goMod, f.err = c.fetchGoMod(ctx, label, p, ref); f.err != nil {
+					return f
+				}
+			}
+			if c.opts.Releases {
+				if r.releases, f.err = c.fetchReleases(ctx, label, p, ref); f.err != nil {
+					return f
+				}
+			}
+			if c.opts.Contributors {
+				if r.contributors, f.err = countRepo(ctx, "contributors", label, ref, p.contributors); f.err != nil {
+					return f
+				}
+			}
+			if c.opts.CommitActivity {
+				if r.commits, f.err = countRepo(ctx, "commits", label, ref, p.commits); f.err != nil {
+					return f
+				}
+			}
+			slog.Debug("Fetched repo", "project", name, "repo", r.owner+"/"+r.repo, "languages", len(r.languages),
+				"duration", time.Since(start))
+			f.repos = append(f.repos, r)
+		}
+	}
+	return f
+}
+
+// InterruptedError Reports a collection stopped by the cancellation of its context and the projects it did
+// not get to
+type InterruptedError struct {
+	Group string
+	// Remaining The sorted names of the projects that were not fetched
+	Remaining []string
+	Err       error
+}
+
+func (e *InterruptedError) Error() string {
+	return fmt.Sprintf("collecting %s interrupted with %d projects remaining: %v", e.Group, len(e.Remaining), e.Err)
+}
+
+func (e *InterruptedError) Unwrap() error {
+	return e.Err
+}
+
+// interrupted An InterruptedError listing the projects not done
+func interrupted(repoGroup string, projects map[string]Project, done map[string]bool, err error) error {
+	var remaining []string
+	for name := range projects {
+		if !done[name] {
+			remaining = append(remaining, name)
+		}
+	}
+	sort.Strings(remaining)
+	return &InterruptedError{Group: repoGroup, Remaining: remaining, Err: err}
+}
+
+// wantsMetadata Reports whether repo metadata is needed, for Options.Metadata or Options.Licenses
+func (c *Collector) wantsMetadata() bool {
+	return c.opts.Metadata || c.opts.Licenses
+}
+
+// countRepo Counts the contributors or commits of a repo with count, -1 when counting fails. The repo's languages
+// are kept, a failed count only leaves the repo out of the counted sums. It fails when ctx is done.
+func countRepo(ctx context.Context, what, name string, ref repoURL,
+	count func(ctx context.Context, name, owner, repo string) (int, error)) (int, error) {
+	n, err := count(ctx, name, ref.owner, ref.repo)
+	if err != nil {
+		if ctx.Err() != nil {
+			return 0, err
+		}
+		slog.Warn("Counting "+what+" failed", "repo", name, "error", err)
+		return -1, nil
+	}
+	return n, nil
+}
+
+// fetchGoMod Fetches and parses the go.mod of the repo's root directory, nil when it has none. Failures leave
+// the repo out of the Go module stats with a warning, it fails only when ctx is done.
+func (c *Collector) fetchGoMod(ctx context.Context, name string, p provider, ref repoURL) (*goModule, error) {
+	data, err := p.file(ctx, name, ref.owner, ref.repo, "go.mod")
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		slog.Warn("Getting go.mod failed", "repo", name, "error", err)
+		return nil, nil
+	}
+	if data == nil {
+		slog.Debug("Repo has no go.mod", "repo", name)
+		return nil, nil
+	}
+	m, err := parseGoMod(data)
+	if err != nil {
+		slog.Warn("Parsing go.mod failed", "repo", name, "error", err)
+		return nil, nil
+	}
+	return m, nil
+}
+
+// lookUpRepo Looks up the current name of a repo unless pre holds it
+func (c *Collector) lookUpRepo(ctx context.Context, name string, p provider, ref repoURL, pre *prefetched) (repoInfo, error) {
+	if pre != nil {
+		if info, ok := pre.info[ref.namespace()+"/"+ref.repo]; ok {
+			return info, nil
+		}
+	}
+	return p.repo(ctx, name, ref.owner, ref.repo)
+}
+
+// fetchLanguages Fetches the languages of a repo from its provider unless pre or the incremental state hold them,
+// name identifies the repo in logs and errors. The metadata of the repo, if known, holds its last push.
+func (c *Collector) fetchLanguages(ctx context.Context, name string, p provider, ref repoURL, metadata *Metadata, pre *prefetched) (map[string]int, error) {
+	owner, repo := ref.namespace(), ref.repo
+	if c.opts.Checkpoint != nil {
+		if languages, ok := c.opts.Checkpoint.languages(owner, repo, c.opts.Clone); ok {
+			slog.Debug("Using checkpointed language stats", "repo", name)
+			return languages, nil
+		}
+	}
+	var pushedAt string
+	if metadata != nil && c.opts.Incremental != nil {
+		pushedAt = metadata.PushedAt
+	}
+	if pushedAt != "" && !c.opts.Force {
+		if languages, ok := c.opts.Incremental.languages(owner+"/"+repo, pushedAt, c.opts.Clone); ok {
+			slog.Debug("Repo unchanged since its last collection, reusing its language stats", "repo", name, "pushedAt", pushedAt)
+			return languages, nil
+		}
+	}
+
+	var languages map[string]int
+	var ok bool
+	if pre != nil {
+		languages, ok = pre.repos[owner+"/"+repo]
+	}
+	var err error
+	if c.opts.Clone {
+		languages, err = c.cloneLanguages(ctx, name, ref)
+	} else if !ok {
+		languages, err = p.languages(ctx, name, ref.owner, ref.repo)
+	}
+	if err != nil {
+		if ctx.Err() == nil {
+			err = fmt.Errorf("getting language stats for %s: %w", name, err)
+		}
+		return nil, err
+	}
+	if pushedAt != "" {
+		c.opts.Incremental.record(owner+"/"+repo, pushedAt, c.opts.Clone, languages)
+	}
+	if c.opts.Checkpoint != nil {
+		if err := c.opts.Checkpoint.record(owner, repo, c.opts.Clone, languages); err != nil {
+			slog.Warn("Checkpointing failed", "repo", name, "error", err)
+		}
+	}
+	return languages, nil
+}
+
+// OrgProjects Lists every repo of the GitHub org as a project of its own named after the repo, so that Collect
+// aggregates the whole org as a group. The repos Options.SkipForks and the other filters exclude are left out.
+func (c *Collector) OrgProjects(ctx context.Context, org string) (map[string]Project, error) {
+	repos, err := c.listOrgRepos(ctx, org, org)
+	if err != nil {
+		return nil, err
+	}
+	projects := make(map[string]Project, len(repos))
+	for _, r := range repos {
+		ref := repoURL{host: gitHubHost, owner: org, repo: r.name}
+		if reason := c.excluded(r, ref); reason != "" {
+			slog.Info("Leaving out the repo", "repo", org+"/"+r.name, "reason", reason)
+			continue
+		}
+		projects[r.name] = Project{URLs: []string{ref.String()}}
+	}
+	return projects, nil
+}
+
+// repoWeight The factor the languages l of the owner/repo are scaled by in the group totals, its weight capped
+// by Options.MaxRepoSize
+func (c *Collector) repoWeight(repo string, l LanguageLinesList) float64 {
+	w := 1.0
+	if weight, ok := c.Weights[strings.ToLower(repo)]; ok {
+		w = weight
+	}
+	if max := c.opts.MaxRepoSize; max > 0 {
+		var size int
+		for _, language := range l {
+			size += language.Lines
+		}
+		if float64(size)*w > float64(max) {
+			w = float64(max) / float64(size)
+		}
+	}
+	return w
+}
+
+// weighLanguages The project's languages l, summed up by Options.MinPercent and MinLines, with the values of the
+// weighted languages. The weighted values of the languages summed up go to Other.
+func weighLanguages(l LanguageLinesList, weighted map[string]int) LanguageLinesList {
+	var rest int
+	for _, lines := range weighted {
+		rest += lines
+	}
+	totals := make(LanguageLinesList, len(l))
+	other := -1
+	for i, language := range l {
+		if language.Language == OtherLanguage {
+			other = i
+			continue
+		}
+		totals[i] = LanguageLines{Language: language.Language, Lines: weighted[language.Language]}
+		rest -= totals[i].Lines
+	}
+	if other >= 0 {
+		totals[other] = LanguageLines{Language: OtherLanguage, Lines: rest}
+	}
+	sortLanguages(totals)
+	return totals
+}
+
+// excluded Why the repo is left out of the stats by the filters of the options, empty when it is not
+func (c *Collector) excluded(info repoInfo, ref repoURL) string {
+	switch {
+	case c.opts.SkipForks && info.fork:
+		return "fork"
+	case c.opts.SkipMirrors && info.mirror:
+		return "mirror"
+	case c.opts.SkipArchived && info.archived:
+		return "archived"
+	}
+	name := strings.ToLower(ref.namespace() + "/" + ref.repo)
+	for _, pattern := range c.opts.ExcludeRepos {
+		if ok, _ := path.Match(strings.ToLower(pattern), name); ok {
+			return "matches " + pattern
+		}
+	}
+	return ""
+}
+
+// listOrgRepos Lists all repos of a GitHub org
+func (c *Collector) listOrgRepos(ctx context.Context, name, org string) ([]repoInfo, error) {
+	var repos []repoInfo
+	opt := &github.RepositoryListByOrgOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		var page []*github.Repository
+		err := c.call(ctx, gitHubHost, func(ctx context.Context) (*github.Response, error) {
+			slog.Debug("Listing repos of org", "org", org, "project", name)
+			var resp *github.Response
+			var err error
+			page, resp, err = c.GitHubClient.Repositories.ListByOrg(ctx, org, opt)
+			if resp != nil {
+				opt.Page = resp.NextPage
+			}
+			return resp, err
+		})
+		if err != nil {
+			if ctx.Err() == nil {
+				err = fmt.Errorf("listing repos of org %s for %s: %w", org, name, err)
+			}
+			return nil, err
+		}
+		for _, r := range page {
+			info := gitHubRepoInfo(r)
+			info.owner = org
+			repos = append(repos, info)
+		}
+		if opt.Page == 0 {
+			sortRepos(repos)
+			return repos, nil
+		}
+	}
+}
+
+// requestCounterKey The context key of the *atomic.Int64 counting the API requests of a Collect call
+type requestCounterKey struct{}
+
+// apiMode How the collector fetches languages, recorded in RunMeta.API
+func (c *Collector) apiMode() string {
+	switch {
+	case c.opts.Clone:
+		return "clone"
+	case c.opts.GraphQL:
+		return "graphql"
+	}
+	return "rest"
+}
+
+// call Sends an API request to the host once its rate limits and concurrency cap allow it, retrying it after
+// rate limit and transient errors. Every attempt gets a context ending after the request timeout. The error is
+// ctx's error when ctx is cancelled.
+func (c *Collector) call(ctx context.Context, host string, request func(ctx context.Context) (*github.Response, error)) error {
+	h := c.hosts[host]
+	var limited, failed int
+	for {
+		if err := h.gate.wait(ctx); err != nil {
+			return err
+		}
+		if err := h.limiter.Wait(ctx); err != nil {
+			return err
+		}
+		if err := h.acquire(ctx); err != nil {
+			return err
+		}
+
+		if requests, ok := ctx.Value(requestCounterKey{}).(*atomic.Int64); ok {
+			requests.Add(1)
+		}
+		start := time.Now()
+		resp, err := c.attempt(ctx, request)
+		h.release()
+		logRequest(time.Since(start), resp, err)
+		h.gate.observe(resp)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err == nil {
+			return nil
+		}
+		if h.gate.backoff(err, limited) {
+			limited++
+			continue
+		}
+
+		failed++
+		if failed >= c.opts.Retry.MaxAttempts || !isTransient(err) {
+			return err
+		}
+		d := c.opts.Retry.delay(failed)
+		slog.Warn("Retrying a failed request", "attempt", failed, "in", d.Round(time.Millisecond), "error", err)
+		if err := sleep(ctx, d); err != nil {
+			return err
+		}
+	}
+}
+
+// attempt Sends a request once, cancelling it after the request timeout
+func (c *Collector) attempt(ctx context.Context, request func(ctx context.Context) (*github.Response, error)) (*github.Response, error) {
+	if c.opts.RequestTimeout <= 0 {
+		return request(ctx)
+	}
+	attemptCtx, cancel := context.WithTimeout(ctx, c.opts.RequestTimeout)
+	defer cancel()
+	resp, err := request(attemptCtx)
+	if err != nil && ctx.Err() == nil && attemptCtx.Err() != nil {
+		err = fmt.Errorf("request timed out after %s: %w", c.opts.RequestTimeout, err)
+	}
+	return resp, err
+}
+
+// logRequest Logs the latency, status and rate limit state of an API request at debug level
+func logRequest(latency time.Duration, resp *github.Response, err error) {
+	if !slog.Default().Enabled(context.Background(), slog.LevelDebug) {
+		return
+	}
+	attrs := []any{"latency", latency}
+	if resp != nil && resp.Response != nil {
+		if resp.Request != nil {
+			attrs = append(attrs, "method", resp.Request.Method, "url", resp.Request.URL.String())
+		}
+		attrs = append(attrs, "status", resp.StatusCode)
+	}
+	if resp != nil && resp.Rate.Limit > 0 {
+		attrs = append(attrs, "rateRemaining", resp.Rate.Remaining, "rateLimit", resp.Rate.Limit,
+			"rateReset", resp.Rate.Reset.Format(time.RFC3339))
+	}
+	if err != nil {
+		attrs = append(attrs, "error", err)
+	}
+	slog.Debug("API request", attrs...)
+}
+
+func (g *groupResult) processTopLanguageStats(l LanguageLinesList) {
+	g.TopLanguage[l[0].Language]++
+}
+
+// processWeightedTopLanguages Records the project's top n languages and adds 1/rank to their scores
+func (g *groupResult) processWeightedTopLanguages(project string, l LanguageLinesList, n int) {
+	if g.WeightedTopLanguage == nil {
+		g.WeightedTopLanguage = make(map[string]float64)
+	}
+	if len(l) < n {
+		n = len(l)
+	}
+	p := g.Projects[project]
+	for i, language := range l[:n] {
+		p.TopLanguages = append(p.TopLanguages, language.Language)
+		g.WeightedTopLanguage[language.Language] += 1 / float64(i+1)
+	}
+	g.Projects[project] = p
+}
+
+// processLicense Counts a project of the SPDX license, NoLicense when empty
+func (g *groupResult) processLicense(license string) {
+	if license == "" {
+		license = NoLicense
+	}
+	if g.Licenses == nil {
+		g.Licenses = make(map[string]int)
+	}
+	g.Licenses[license]++
+}
+
+// processGoModules Records the Go version of the project's first go.mod declaring one and counts the modules
+// any of its go.mod files requires, once per project
+func (g *groupResult) processGoModules(f fetchedProject) {
+	required := make(map[string]bool)
+	for _, r := range f.repos {
+		if r.goMod == nil {
+			continue
+		}
+		if _, ok := g.GoVersions[f.name]; !ok && r.goMod.goVersion != "" {
+			if g.GoVersions == nil {
+				g.GoVersions = make(map[string]string)
+			}
+			g.GoVersions[f.name] = r.goMod.goVersion
+		}
+		for _, path := range r.goMod.requires {
+			required[path] = true
+		}
+	}
+	for path := range required {
+		if g.GoModules == nil {
+			g.GoModules = make(map[string]int)
+		}
+		g.GoModules[path]++
+	}
+}
+
+// processLanguagePresence Counts the project in LanguagePresence for every language with at least percent of its
+// total, listing it in ProjectsContaining when list is set
+func (g *groupResult) processLanguagePresence(project string, l LanguageLinesList, percent float64, list bool) {
+	if g.LanguagePresence == nil {
+		g.LanguagePresence = make(map[string]int)
+	}
+	if list && g.ProjectsContaining == nil {
+		g.ProjectsContaining = make(map[string][]string)
+	}
+	var total int
+	for _, language := range l {
+		total += language.Lines
+	}
+	for _, language := range l {
+		if language.Lines > 0 && float64(language.Lines)*100 >= percent*float64(total) {
+			g.LanguagePresence[language.Language]++
+			if list {
+				g.ProjectsContaining[language.Language] = append(g.ProjectsContaining[language.Language], project)
+			}
+		}
+	}
+}
+
+// processPresence Counts the project in Normalized for every language it contains
+func (g *groupResult) processPresence(l LanguageLinesList) {
+	if g.Normalized == nil {
+		g.Normalized = make(map[string]NormalizedLanguage)
+	}
+	for _, language := range l {
+		n := g.Normalized[language.Language]
+		n.Projects++
+		g.Normalized[language.Language] = n
+	}
+}
+
+func (g *groupResult) processTotalLinesStats(l LanguageLinesList) {
+	for _, language := range l {
+		g.Totals()[language.Language] += language.Lines
+	}
+}
+
+// processConcentrationStats For every language finds the project contributing the most bytes, the first by name
+// of the projects contributing as many.
+// The share is that project's bytes divided by the language's total bytes across the group,
+// so 1 means the language is used by a single project.
+func (g *groupResult) processConcentrationStats() {
+	g.Concentration = make(map[string]Concentration)
+	for project, l := range g.projectLanguages {
+		for _, language := range l {
+			// Ties go to the project first by name, which does not depend on the map's order
+			c, ok := g.Concentration[language.Language]
+			if language.Lines > c.lines || ok && language.Lines == c.lines && project < c.Project {
+				g.Concentration[language.Language] = Concentration{Project: project, lines: language.Lines}
+			}
+		}
+	}
+	for lang, c := range g.Concentration {
+		if total := g.Totals()[lang]; total > 0 {
+			c.Share = float64(c.lines) / float64(total)
+		}
+		g.Concentration[lang] = c
+	}
+}
@@ -0,0 +1,235 @@
+package stats
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// RankChange A language's rank in the baseline and current results. A rank of 0 means the language is absent.
+type RankChange struct {
+	Language string
+	Baseline int
+	Current  int
+}
+
+// Delta Number of places the language climbed, negative when it fell
+func (c RankChange) Delta() int {
+	return c.Baseline - c.Current
+}
+
+func (c RankChange) String() string {
+	switch {
+	case c.Baseline == 0:
+		return fmt.Sprintf("%s: new at #%d", c.Language, c.Current)
+	case c.Current == 0:
+		return fmt.Sprintf("%s: dropped, was #%d", c.Language, c.Baseline)
+	default:
+		return fmt.Sprintf("%s: #%d → #%d, %+d", c.Language, c.Baseline, c.Current, c.Delta())
+	}
+}
+
+// CompareRanks Joins the language ranks of both results, leaving out languages whose rank did not change.
+// Languages that moved come first sorted by absolute rank change, followed by new and then dropped languages.
+func CompareRanks(baseline, current Result, rankBy string) []RankChange {
+	baselineValues, currentValues := baseline.TopLanguage, current.TopLanguage
+	if rankBy == "bytes" {
+		baselineValues, currentValues = baseline.Totals(), current.Totals()
+	}
+	baselineRanks := RankLanguages(baselineValues)
+	currentRanks := RankLanguages(currentValues)
+
+	var changes []RankChange
+	for lang, rank := range currentRanks {
+		if baselineRanks[lang] != rank {
+			changes = append(changes, RankChange{Language: lang, Baseline: baselineRanks[lang], Current: rank})
+		}
+	}
+	for lang, rank := range baselineRanks {
+		if _, ok := currentRanks[lang]; !ok {
+			changes = append(changes, RankChange{Language: lang, Baseline: rank})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		a, b := changes[i], changes[j]
+		if ka, kb := a.kind(), b.kind(); ka != kb {
+			return ka < kb
+		}
+		if da, db := abs(a.Delta()), abs(b.Delta()); a.kind() == 0 && da != db {
+			return da > db
+		}
+		if a.Current != b.Current {
+			return a.Current < b.Current
+		}
+		if a.Baseline != b.Baseline {
+			return a.Baseline < b.Baseline
+		}
+		return a.Language < b.Language
+	})
+	return changes
+}
+
+// kind Orders moved languages before new ones, and new ones before dropped ones
+func (c RankChange) kind() int {
+	switch {
+	case c.Baseline == 0:
+		return 1
+	case c.Current == 0:
+		return 2
+	default:
+		return 0
+	}
+}
+
+// RankLanguages Ranks languages by descending value, languages with equal values share the same rank
+func RankLanguages(values map[string]int) map[string]int {
+	l := SortLanguageMap(values)
+	ranks := make(map[string]int, len(l))
+	for i, language := range l {
+		if i > 0 && language.Lines == l[i-1].Lines {
+			ranks[language.Language] = ranks[l[i-1].Language]
+			continue
+		}
+		ranks[language.Language] = i + 1
+	}
+	return ranks
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// LeaderChange The top languages of a group in the baseline and current results, ranked by project count or bytes
+type LeaderChange struct {
+	By       string
+	Baseline []string
+	Current  []string
+}
+
+// Changed Whether the top languages differ, languages tied for the top are compared as a set
+func (c LeaderChange) Changed() bool {
+	return strings.Join(c.Baseline, ",") != strings.Join(c.Current, ",")
+}
+
+func (c LeaderChange) String() string {
+	if !c.Changed() {
+		return fmt.Sprintf("by %s: %s, unchanged", c.By, leaderNames(c.Current))
+	}
+	return fmt.Sprintf("by %s: %s → %s", c.By, leaderNames(c.Baseline), leaderNames(c.Current))
+}
+
+// CompareLeaders Compares the top languages by project count and by bytes
+func CompareLeaders(baseline, current Result) []LeaderChange {
+	return []LeaderChange{
+		{By: "count", Baseline: Leaders(baseline.TopLanguage), Current: Leaders(current.TopLanguage)},
+		{By: "bytes", Baseline: Leaders(baseline.Totals()), Current: Leaders(current.Totals())},
+	}
+}
+
+// Leaders The sorted names of the languages with the highest value
+func Leaders(values map[string]int) []string {
+	var top []string
+	var max int
+	for lang, v := range values {
+		switch {
+		case v > max:
+			top, max = []string{lang}, v
+		case v == max && v > 0:
+			top = append(top, lang)
+		}
+	}
+	sort.Strings(top)
+	return top
+}
+
+func leaderNames(languages []string) string {
+	if len(languages) == 0 {
+		return "none"
+	}
+	return strings.Join(languages, ", ")
+}
+
+// SizeChange A language's total bytes or lines in the baseline and current results
+type SizeChange struct {
+	Language string
+	Baseline int
+	Current  int
+}
+
+// Delta The bytes or lines the language gained, negative when it lost some
+func (c SizeChange) Delta() int {
+	return c.Current - c.Baseline
+}
+
+func (c SizeChange) String() string {
+	if c.Baseline == 0 {
+		return fmt.Sprintf("%s: %+d, new", c.Language, c.Delta())
+	}
+	return fmt.Sprintf("%s: %+d (%+.1f%%)", c.Language, c.Delta(), float64(c.Delta())*100/float64(c.Baseline))
+}
+
+// CompareSizes Joins the Totals of both results, leaving out unchanged languages. Languages are sorted by the
+// absolute change, largest first.
+func CompareSizes(baseline, current Result) []SizeChange {
+	var changes []SizeChange
+	for lang, v := range current.Totals() {
+		if b := baseline.Totals()[lang]; b != v {
+			changes = append(changes, SizeChange{Language: lang, Baseline: b, Current: v})
+		}
+	}
+	for lang, b := range baseline.Totals() {
+		if _, ok := current.Totals()[lang]; !ok && b != 0 {
+			changes = append(changes, SizeChange{Language: lang, Baseline: b})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		if da, db := abs(changes[i].Delta()), abs(changes[j].Delta()); da != db {
+			return da > db
+		}
+		return changes[i].Language < changes[j].Language
+	})
+	return changes
+}
+
+// TopLanguageChange A project's top language in the baseline and current results, empty when the project is
+// missing from one of them
+type TopLanguageChange struct {
+	Project  string
+	Baseline string
+	Current  string
+}
+
+func (c TopLanguageChange) String() string {
+	switch {
+	case c.Baseline == "":
+		return fmt.Sprintf("%s: new, %s", c.Project, c.Current)
+	case c.Current == "":
+		return fmt.Sprintf("%s: removed, was %s", c.Project, c.Baseline)
+	default:
+		return fmt.Sprintf("%s: %s → %s", c.Project, c.Baseline, c.Current)
+	}
+}
+
+// CompareTopLanguages Lists the projects whose top language differs between the results sorted by name.
+// Only results with project details list projects.
+func CompareTopLanguages(baseline, current Result) []TopLanguageChange {
+	var changes []TopLanguageChange
+	for name, p := range current.Projects {
+		if b := baseline.Projects[name].TopLanguage; b != p.TopLanguage {
+			changes = append(changes, TopLanguageChange{Project: name, Baseline: b, Current: p.TopLanguage})
+		}
+	}
+	for name, p := range baseline.Projects {
+		if _, ok := current.Projects[name]; !ok {
+			changes = append(changes, TopLanguageChange{Project: name, Baseline: p.TopLanguage})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Project < changes[j].Project
+	})
+	return changes
+}
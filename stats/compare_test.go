@@ -0,0 +1,88 @@
+package stats
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRankLanguages(t *testing.T) {
+	tests := []struct {
+		name   string
+		values map[string]int
+		want   map[string]int
+	}{
+		{"empty", map[string]int{}, map[string]int{}},
+		{"distinct", map[string]int{"Go": 3, "C": 1, "Rust": 2}, map[string]int{"Go": 1, "Rust": 2, "C": 3}},
+		{"ties share a rank", map[string]int{"Go": 3, "C": 2, "Rust": 2, "Zig": 1}, map[string]int{"Go": 1, "C": 2, "Rust": 2, "Zig": 4}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RankLanguages(tt.values); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompareRanks(t *testing.T) {
+	tests := []struct {
+		name     string
+		baseline Result
+		current  Result
+		rankBy   string
+		want     []RankChange
+	}{
+		{
+			name:     "unchanged",
+			baseline: Result{TopLanguage: map[string]int{"Go": 2, "C": 1}},
+			current:  Result{TopLanguage: map[string]int{"Go": 5, "C": 3}},
+			want:     nil,
+		},
+		{
+			name:     "moved by size of the change, then new, then dropped",
+			baseline: Result{TopLanguage: map[string]int{"Go": 9, "C": 8, "Rust": 7, "Java": 6, "Perl": 5}},
+			current:  Result{TopLanguage: map[string]int{"Java": 9, "Go": 8, "Rust": 7, "C": 6, "Zig": 1}},
+			want: []RankChange{
+				{Language: "Java", Baseline: 4, Current: 1},
+				{Language: "C", Baseline: 2, Current: 4},
+				{Language: "Go", Baseline: 1, Current: 2},
+				{Language: "Zig", Baseline: 0, Current: 5},
+				{Language: "Perl", Baseline: 5, Current: 0},
+			},
+		},
+		{
+			name:     "by bytes",
+			baseline: Result{TopLanguage: map[string]int{"Go": 1}, TotalBytes: map[string]int{"Go": 10, "C": 20}},
+			current:  Result{TopLanguage: map[string]int{"Go": 1}, TotalBytes: map[string]int{"Go": 30, "C": 20}},
+			rankBy:   "bytes",
+			want: []RankChange{
+				{Language: "Go", Baseline: 2, Current: 1},
+				{Language: "C", Baseline: 1, Current: 2},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CompareRanks(tt.baseline, tt.current, tt.rankBy); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRankChangeString(t *testing.T) {
+	tests := []struct {
+		change RankChange
+		want   string
+	}{
+		{RankChange{Language: "Go", Baseline: 3, Current: 1}, "Go: #3 → #1, +2"},
+		{RankChange{Language: "Go", Baseline: 1, Current: 3}, "Go: #1 → #3, -2"},
+		{RankChange{Language: "Zig", Current: 5}, "Zig: new at #5"},
+		{RankChange{Language: "Perl", Baseline: 5}, "Perl: dropped, was #5"},
+	}
+	for _, tt := range tests {
+		if got := tt.change.String(); got != tt.want {
+			t.Errorf("got %q, want %q", got, tt.want)
+		}
+	}
+}
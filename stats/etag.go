@@ -0,0 +1,142 @@
+package stats
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// ETagTransport Caches the bodies and ETags of GET responses in Dir and sends If-None-Match on later requests
+// of the same URL. A 304 Not Modified response, which does not count against GitHub's rate limit, is answered
+// with the cached body as a 200 response, so the GitHub client never sees it.
+type ETagTransport struct {
+	// Dir Directory holding a file per cached URL, created when missing
+	Dir string
+	// Perm Permissions of the cache files
+	Perm os.FileMode
+	// Base Transport sending the requests, http.DefaultTransport when nil
+	Base http.RoundTripper
+}
+
+// etagEntry A cached response
+type etagEntry struct {
+	URL         string `json:"url"`
+	ETag        string `json:"etag"`
+	ContentType string `json:"contentType"`
+	Body        []byte `json:"body"`
+}
+
+func (t ETagTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if req.Method != http.MethodGet {
+		return base.RoundTrip(req)
+	}
+
+	path := filepath.Join(t.Dir, cacheKey(req.URL.String())+".json")
+	cached, err := readETagEntry(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		slog.Warn("Ignoring the cached response", "url", req.URL.String(), "error", err)
+	}
+	if cached != nil && cached.URL == req.URL.String() {
+		req = req.Clone(req.Context())
+		req.Header.Set("If-None-Match", cached.ETag)
+	} else {
+		cached = nil
+	}
+
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotModified && cached != nil:
+		resp.Body.Close()
+		resp.StatusCode, resp.Status = http.StatusOK, "200 OK"
+		resp.Header = resp.Header.Clone()
+		resp.Header.Set("Content-Type", cached.ContentType)
+		resp.Header.Set("Content-Length", strconv.Itoa(len(cached.Body)))
+		resp.ContentLength = int64(len(cached.Body))
+		resp.Body = io.NopCloser(bytes.NewReader(cached.Body))
+	case resp.StatusCode == http.StatusOK && resp.Header.Get("ETag") != "":
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+		entry := etagEntry{URL: req.URL.String(), ETag: resp.Header.Get("ETag"), ContentType: resp.Header.Get("Content-Type"), Body: body}
+		if err := t.write(path, entry); err != nil {
+			slog.Warn("Caching the response failed", "url", req.URL.String(), "error", err)
+		}
+	}
+	return resp, nil
+}
+
+// write Replaces the cache file at path with the entry
+func (t ETagTransport) write(path string, entry etagEntry) error {
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	return writeAtomic(t.Dir, path, b, t.Perm)
+}
+
+// DirMode The permissions of the directories holding files of the permissions perm, which can be searched by who
+// can read the files, e.g. 0755 for 0644 and 0700 for 0600
+func DirMode(perm os.FileMode) os.FileMode {
+	return perm | perm&0444>>2
+}
+
+// writeAtomic Replaces the file at path in dir, created when missing, with b. The file is renamed into place so
+// that concurrent requests never read a partial file.
+func writeAtomic(dir, path string, b []byte, perm os.FileMode) error {
+	if err := os.MkdirAll(dir, DirMode(perm)); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	_, err = f.Write(b)
+	if err == nil {
+		err = f.Chmod(perm)
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+func readETagEntry(path string) (*etagEntry, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var entry etagEntry
+	if err := json.Unmarshal(b, &entry); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// cacheKey The name of the cache file of a URL
+func cacheKey(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return hex.EncodeToString(sum[:])
+}
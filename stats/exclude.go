@@ -0,0 +1,213 @@
+package stats
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// DefaultCloneExcludes Vendored dependencies and generated code left out of line counts by default
+var DefaultCloneExcludes = []string{"vendor/", "third_party/", "node_modules/", "*.pb.go", "*.min.js", "zz_generated.*"}
+
+// pathPattern A compiled exclude glob or .gitattributes pattern matched against slash separated paths
+// relative to the repo root
+type pathPattern struct {
+	re *regexp.Regexp
+	// dir Matches the directory itself too, so that its files need not be walked
+	dir bool
+}
+
+// compilePattern Compiles a glob. A glob without a slash matches the base name at any depth, "dir/" matches
+// every directory named dir and its contents, a leading slash anchors the glob at the root and ** matches
+// any number of directories.
+func compilePattern(glob string) (pathPattern, error) {
+	var p pathPattern
+	if strings.HasSuffix(glob, "/") {
+		p.dir = true
+		glob = strings.TrimSuffix(glob, "/")
+	}
+	anchored := strings.Contains(glob, "/")
+	glob = strings.TrimPrefix(glob, "/")
+
+	var b strings.Builder
+	if !anchored {
+		b.WriteString("(.*/)?")
+	}
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; {
+		case strings.HasPrefix(glob[i:], "**/"):
+			b.WriteString("(.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	if p.dir {
+		b.WriteString("(/.*)?")
+	}
+	re, err := regexp.Compile("^" + b.String() + "$")
+	if err != nil {
+		return pathPattern{}, err
+	}
+	p.re = re
+	return p, nil
+}
+
+func (p pathPattern) match(rel string) bool {
+	return p.re.MatchString(rel)
+}
+
+// attributeRule A line of .gitattributes setting linguist attributes
+type attributeRule struct {
+	pattern pathPattern
+	// exclude Excluded by linguist-vendored, linguist-generated or linguist-documentation when set
+	exclude *bool
+	// language Overrides the language of the matching files when not empty
+	language string
+}
+
+// readAttributes Reads the linguist attributes of the .gitattributes file in the repo root, which override
+// the exclude globs and language detection the way they override GitHub's language stats
+func readAttributes(dir string) ([]attributeRule, error) {
+	b, err := os.ReadFile(filepath.Join(dir, ".gitattributes"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var rules []attributeRule
+	s := bufio.NewScanner(bytes.NewReader(b))
+	for s.Scan() {
+		fields := strings.Fields(s.Text())
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		var rule attributeRule
+		for _, attr := range fields[1:] {
+			name, value, hasValue := strings.Cut(attr, "=")
+			set := !strings.HasPrefix(name, "-") && !strings.HasPrefix(name, "!") && (!hasValue || value == "true")
+			switch strings.TrimLeft(name, "-!") {
+			case "linguist-vendored", "linguist-generated", "linguist-documentation":
+				if rule.exclude == nil || set {
+					rule.exclude = &set
+				}
+			case "linguist-language":
+				if hasValue {
+					rule.language = strings.ReplaceAll(value, "-", " ")
+				}
+			}
+		}
+		if rule.exclude == nil && rule.language == "" {
+			continue
+		}
+		if rule.pattern, err = compilePattern(fields[0]); err != nil {
+			continue
+		}
+		rules = append(rules, rule)
+	}
+	return rules, s.Err()
+}
+
+// fileFilter Decides which files of a repo are counted and as what language
+type fileFilter struct {
+	excludes []pathPattern
+	rules    []attributeRule
+	// overridable The attributes may include files the excludes leave out
+	overridable bool
+}
+
+func newFileFilter(dir string, excludes []string) (fileFilter, error) {
+	var f fileFilter
+	for _, glob := range excludes {
+		p, err := compilePattern(glob)
+		if err != nil {
+			return fileFilter{}, err
+		}
+		f.excludes = append(f.excludes, p)
+	}
+	var err error
+	if f.rules, err = readAttributes(dir); err != nil {
+		return fileFilter{}, err
+	}
+	for _, r := range f.rules {
+		f.overridable = f.overridable || r.exclude != nil && !*r.exclude
+	}
+	return f, nil
+}
+
+// skipDir Reports whether no file in the directory can be counted
+func (f fileFilter) skipDir(rel string) bool {
+	if f.overridable {
+		return false
+	}
+	for _, p := range f.excludes {
+		if p.dir && p.match(rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// file Reports whether the file is counted and the language set by the attributes, if any.
+// The last matching attribute wins as in git.
+func (f fileFilter) file(rel string) (bool, string) {
+	included := true
+	for _, p := range f.excludes {
+		if p.match(rel) {
+			included = false
+			break
+		}
+	}
+	var language string
+	for _, r := range f.rules {
+		if !r.pattern.match(rel) {
+			continue
+		}
+		if r.exclude != nil {
+			included = !*r.exclude
+		}
+		if r.language != "" {
+			language = r.language
+		}
+	}
+	return included, language
+}
+
+// syntaxOf The comment syntax of the language, none for languages clone mode does not know
+func syntaxOf(language string) lineSyntax {
+	for _, s := range languageExtensions {
+		if strings.EqualFold(s.language, language) {
+			return s
+		}
+	}
+	for _, s := range languageFileNames {
+		if strings.EqualFold(s.language, language) {
+			return s
+		}
+	}
+	return lineSyntax{language: language}
+}
+
+// relPath The slash separated path of path relative to dir
+func relPath(dir, p string) string {
+	rel, err := filepath.Rel(dir, p)
+	if err != nil {
+		return p
+	}
+	return path.Clean(filepath.ToSlash(rel))
+}
@@ -0,0 +1,98 @@
+package stats
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCompilePattern(t *testing.T) {
+	tests := []struct {
+		glob  string
+		path  string
+		match bool
+	}{
+		{"*.pb.go", "api.pb.go", true},
+		{"*.pb.go", "api/v1/api.pb.go", true},
+		{"*.pb.go", "api.go", false},
+		{"zz_generated.*", "pkg/zz_generated.deepcopy.go", true},
+		{"vendor/", "vendor", true},
+		{"vendor/", "vendor/github.com/x/x.go", true},
+		{"vendor/", "pkg/vendor/x.go", true},
+		{"vendor/", "vendors/x.go", false},
+		{"/docs/*.go", "docs/x.go", true},
+		{"/docs/*.go", "pkg/docs/x.go", false},
+		{"docs/*.go", "docs/sub/x.go", false},
+		{"docs/**/*.go", "docs/x.go", true},
+		{"docs/**/*.go", "docs/a/b/x.go", true},
+		{"docs/**", "docs/a/b/x.go", true},
+		{"?.go", "a.go", true},
+		{"?.go", "ab.go", false},
+		{"a+b.go", "a+b.go", true},
+		{"a+b.go", "aab.go", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.glob+" "+tt.path, func(t *testing.T) {
+			p, err := compilePattern(tt.glob)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := p.match(tt.path); got != tt.match {
+				t.Errorf("got match %v, want %v", got, tt.match)
+			}
+		})
+	}
+}
+
+func TestReadAttributes(t *testing.T) {
+	dir := t.TempDir()
+	attributes := `# comment
+*.gen.go linguist-generated
+docs/** linguist-documentation=true
+third_party/kept/** -linguist-vendored
+*.tpl linguist-language=Go-Template
+*.txt text
+`
+	if err := os.WriteFile(filepath.Join(dir, ".gitattributes"), []byte(attributes), 0644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := newFileFilter(dir, []string{"third_party/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(f.rules) != 4 {
+		t.Fatalf("got %d rules, want 4", len(f.rules))
+	}
+
+	tests := []struct {
+		path     string
+		included bool
+		language string
+	}{
+		{"main.go", true, ""},
+		{"api/x.gen.go", false, ""},
+		{"docs/a/x.go", false, ""},
+		{"third_party/x/x.go", false, ""},
+		{"third_party/kept/x.go", true, ""},
+		{"charts/x.tpl", true, "Go Template"},
+		{"notes.txt", true, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			included, language := f.file(tt.path)
+			if included != tt.included || language != tt.language {
+				t.Errorf("got %v %q, want %v %q", included, language, tt.included, tt.language)
+			}
+		})
+	}
+	if f.skipDir("third_party") {
+		t.Error("skipping third_party, which the attributes include files of")
+	}
+}
+
+func TestReadAttributesMissing(t *testing.T) {
+	rules, err := readAttributes(t.TempDir())
+	if err != nil || rules != nil {
+		t.Errorf("got %v, %v, want no rules", rules, err)
+	}
+}
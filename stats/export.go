@@ -0,0 +1,66 @@
+package stats
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Exporter Receives the result of every collected group, e.g. to send it to a sink like Kafka or BigQuery.
+// Export is called once per group and run, with "all" for the combined result.
+type Exporter interface {
+	Export(ctx context.Context, group string, result Result) error
+}
+
+// ExporterFunc Adapts a function to an Exporter
+type ExporterFunc func(ctx context.Context, group string, result Result) error
+
+func (f ExporterFunc) Export(ctx context.Context, group string, result Result) error {
+	return f(ctx, group, result)
+}
+
+// OpenExporterFunc Creates the exporter of a destination, e.g. s3://bucket/prefix
+type OpenExporterFunc func(dest string) (Exporter, error)
+
+var (
+	exportersMu sync.Mutex
+	exporters   = make(map[string]OpenExporterFunc)
+)
+
+// RegisterExporter Makes the exporter opened by open available for destinations with the scheme, the part
+// before the first colon or the whole destination without one. Like database/sql drivers, exporters register
+// themselves in an init function. It panics when the scheme is already registered.
+func RegisterExporter(scheme string, open OpenExporterFunc) {
+	exportersMu.Lock()
+	defer exportersMu.Unlock()
+	if _, ok := exporters[scheme]; ok {
+		panic("stats: RegisterExporter called twice for " + scheme)
+	}
+	exporters[scheme] = open
+}
+
+// OpenExporter Opens the exporter registered for the scheme of dest
+func OpenExporter(dest string) (Exporter, error) {
+	scheme, _, _ := strings.Cut(dest, ":")
+	exportersMu.Lock()
+	open, ok := exporters[scheme]
+	exportersMu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown export destination %q, the scheme must be one of %s", dest, strings.Join(ExporterSchemes(), ", "))
+	}
+	return open(dest)
+}
+
+// ExporterSchemes The sorted schemes of the registered exporters
+func ExporterSchemes() []string {
+	exportersMu.Lock()
+	defer exportersMu.Unlock()
+	schemes := make([]string, 0, len(exporters))
+	for scheme := range exporters {
+		schemes = append(schemes, scheme)
+	}
+	sort.Strings(schemes)
+	return schemes
+}
@@ -0,0 +1,140 @@
+package stats
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ErrNoFixture Reported by ReplayTransport for a request that was not recorded, it is not retried
+var ErrNoFixture = errors.New("no fixture recorded")
+
+// RecordTransport Saves every response sent through Base to a fixture file in Dir, which ReplayTransport serves
+// later without network access. Existing fixtures of the same requests are replaced.
+type RecordTransport struct {
+	// Dir Directory holding a fixture file per request, created when missing
+	Dir string
+	// Perm Permissions of the fixture files
+	Perm os.FileMode
+	// Base Transport sending the requests, http.DefaultTransport when nil
+	Base http.RoundTripper
+}
+
+// ReplayTransport Answers requests with the responses RecordTransport saved in Dir, without sending them. A
+// request is matched by its method, URL and body, an unrecorded request fails with ErrNoFixture.
+type ReplayTransport struct {
+	// Dir Directory holding the fixture files
+	Dir string
+}
+
+// fixture A recorded response
+type fixture struct {
+	Method     string      `json:"method"`
+	URL        string      `json:"url"`
+	StatusCode int         `json:"statusCode"`
+	Header     http.Header `json:"header"`
+	Body       []byte      `json:"body"`
+}
+
+func (t RecordTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	key, req, err := fixtureKey(req)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	b, err := json.Marshal(fixture{
+		Method:     req.Method,
+		URL:        req.URL.String(),
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Body:       body,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := writeAtomic(t.Dir, filepath.Join(t.Dir, key+".json"), b, t.Perm); err != nil {
+		return nil, fmt.Errorf("recording %s %s: %w", req.Method, req.URL, err)
+	}
+	return resp, nil
+}
+
+func (t ReplayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	key, req, err := fixtureKey(req)
+	if err != nil {
+		return nil, err
+	}
+	b, err := os.ReadFile(filepath.Join(t.Dir, key+".json"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w for %s %s in %s", ErrNoFixture, req.Method, req.URL, t.Dir)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var f fixture
+	if err := json.Unmarshal(b, &f); err != nil {
+		return nil, fmt.Errorf("fixture of %s %s: %w", req.Method, req.URL, err)
+	}
+	header := f.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	// The recorded rate limit would hold back replayed requests until its long past reset
+	for name := range header {
+		if strings.HasPrefix(name, "X-Ratelimit-") {
+			header.Del(name)
+		}
+	}
+	header.Set("Content-Length", strconv.Itoa(len(f.Body)))
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", f.StatusCode, http.StatusText(f.StatusCode)),
+		StatusCode:    f.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(f.Body)),
+		ContentLength: int64(len(f.Body)),
+		Request:       req,
+	}, nil
+}
+
+// fixtureKey The name of the fixture file of a request, from its method, URL and body. The body is read and
+// replaced in the returned copy of the request.
+func fixtureKey(req *http.Request) (string, *http.Request, error) {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s %s\n", req.Method, req.URL.String())
+	if req.Body != nil && req.Body != http.NoBody {
+		body, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return "", nil, err
+		}
+		h.Write(body)
+		req = req.Clone(req.Context())
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	return hex.EncodeToString(h.Sum(nil)), req, nil
+}
@@ -0,0 +1,351 @@
+package stats
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/google/go-github/v47/github"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// gitLabAPI Base URL of the gitlab.com REST API
+const gitLabAPI = "https://gitlab.com/api/v4"
+
+// gitLabProvider Fetches from the GitLab REST API. GitLab only reports the share of every language, which is
+// turned into bytes with the repository size of the project statistics. The statistics require at least
+// Reporter access to the project, without it the project fails with an error suggesting clone mode.
+type gitLabProvider struct {
+	c       *Collector
+	client  *http.Client
+	token   string
+	baseURL string
+}
+
+// httpError An unsuccessful response of an API other than GitHub's
+type httpError struct {
+	URL        string
+	StatusCode int
+	Message    string
+	// RetryAt When a 429 Too Many Requests response allows requests again, zero when it does not tell
+	RetryAt time.Time
+}
+
+func (e *httpError) Error() string {
+	return fmt.Sprintf("GET %s: %d %s", e.URL, e.StatusCode, e.Message)
+}
+
+// gitLabProject The fields of a GitLab project the collector uses
+type gitLabProject struct {
+	PathWithNamespace string    `json:"path_with_namespace"`
+	Archived          bool      `json:"archived"`
+	Mirror            bool      `json:"mirror"`
+	ForkedFromProject *struct{} `json:"forked_from_project"`
+	StarCount         int       `json:"star_count"`
+	ForksCount        int       `json:"forks_count"`
+	OpenIssuesCount   int       `json:"open_issues_count"`
+	DefaultBranch     string    `json:"default_branch"`
+	LastActivityAt    time.Time `json:"last_activity_at"`
+	License           *struct {
+		Key string `json:"key"`
+	} `json:"license"`
+}
+
+// gitLabLicenses The SPDX identifiers of the lower case license keys GitLab reports, e.g. apache-2.0. Other keys
+// are kept as they are.
+var gitLabLicenses = map[string]string{
+	"agpl-3.0":     "AGPL-3.0",
+	"apache-2.0":   "Apache-2.0",
+	"bsd-2-clause": "BSD-2-Clause",
+	"bsd-3-clause": "BSD-3-Clause",
+	"epl-2.0":      "EPL-2.0",
+	"gpl-2.0":      "GPL-2.0",
+	"gpl-3.0":      "GPL-3.0",
+	"isc":          "ISC",
+	"lgpl-2.1":     "LGPL-2.1",
+	"lgpl-3.0":     "LGPL-3.0",
+	"mit":          "MIT",
+	"mpl-2.0":      "MPL-2.0",
+	"unlicense":    "Unlicense",
+}
+
+// info The project's name relative to the group, archived state and metadata. GitLab only reports the license
+// of single projects requested with license=true.
+func (p gitLabProject) info(group string) repoInfo {
+	info := repoInfo{
+		owner:    group,
+		name:     strings.TrimPrefix(p.PathWithNamespace, group+"/"),
+		archived: p.Archived,
+		fork:     p.ForkedFromProject != nil,
+		mirror:   p.Mirror,
+	}
+	info.metadata = &Metadata{
+		Stars:         p.StarCount,
+		Forks:         p.ForksCount,
+		OpenIssues:    p.OpenIssuesCount,
+		DefaultBranch: p.DefaultBranch,
+	}
+	if p.License != nil {
+		info.metadata.License = p.License.Key
+		if spdx, ok := gitLabLicenses[p.License.Key]; ok {
+			info.metadata.License = spdx
+		}
+	}
+	if !p.LastActivityAt.IsZero() {
+		info.metadata.PushedAt = p.LastActivityAt.UTC().Format(time.RFC3339)
+	}
+	return info
+}
+
+func (p gitLabProvider) listRepos(ctx context.Context, name, group string) ([]repoInfo, error) {
+	var repos []repoInfo
+	query := url.Values{"include_subgroups": {"true"}, "per_page": {"100"}, "page": {"1"}}
+	for {
+		var page []gitLabProject
+		slog.Debug("Listing projects of group", "group", group, "project", name)
+		header, err := p.get(ctx, "/groups/"+url.PathEscape(group)+"/projects", query, &page)
+		if err != nil {
+			if ctx.Err() == nil {
+				err = fmt.Errorf("listing projects of group %s for %s: %w", group, name, err)
+			}
+			return nil, err
+		}
+		for _, project := range page {
+			repos = append(repos, project.info(group))
+		}
+		next := header.Get("X-Next-Page")
+		if next == "" {
+			sortRepos(repos)
+			return repos, nil
+		}
+		query.Set("page", next)
+	}
+}
+
+func (p gitLabProvider) repo(ctx context.Context, name, owner, repo string) (repoInfo, error) {
+	slog.Debug("Looking up", "repo", name)
+	var project gitLabProject
+	query := url.Values{"license": {"true"}}
+	if _, err := p.get(ctx, "/projects/"+url.PathEscape(owner+"/"+repo), query, &project); err != nil {
+		if ctx.Err() == nil {
+			err = fmt.Errorf("looking up %s: %w", name, err)
+		}
+		return repoInfo{}, err
+	}
+	if project.PathWithNamespace == "" {
+		project.PathWithNamespace = owner + "/" + repo
+	}
+	namespace := owner
+	if i := strings.LastIndex(project.PathWithNamespace, "/"); i > 0 {
+		namespace = project.PathWithNamespace[:i]
+	}
+	return project.info(namespace), nil
+}
+
+func (p gitLabProvider) languages(ctx context.Context, name, owner, repo string) (map[string]int, error) {
+	slog.Debug("Getting language stats", "repo", name)
+	project := "/projects/" + url.PathEscape(owner+"/"+repo)
+	var shares map[string]float64
+	if _, err := p.get(ctx, project+"/languages", nil, &shares); err != nil {
+		return nil, err
+	}
+	if len(shares) == 0 {
+		return map[string]int{}, nil
+	}
+
+	var details struct {
+		Statistics *struct {
+			RepositorySize int64 `json:"repository_size"`
+		} `json:"statistics"`
+	}
+	if _, err := p.get(ctx, project, url.Values{"statistics": {"true"}}, &details); err != nil {
+		return nil, err
+	}
+	if details.Statistics == nil {
+		return nil, fmt.Errorf("GitLab only reports the size of %s/%s to members with at least Reporter access, "+
+			"set GITLAB_TOKEN to such a member's token or count lines with clone mode", owner, repo)
+	}
+	languages := make(map[string]int, len(shares))
+	for language, share := range shares {
+		languages[language] = int(math.Round(share * float64(details.Statistics.RepositorySize) / 100))
+	}
+	return languages, nil
+}
+
+// contributors Counts the contributors, which GitLab tells apart by email
+func (p gitLabProvider) contributors(ctx context.Context, name, owner, repo string) (int, error) {
+	slog.Debug("Counting contributors", "repo", name)
+	return p.count(ctx, "/projects/"+url.PathEscape(owner+"/"+repo)+"/repository/contributors", url.Values{})
+}
+
+// commits Counts the commits to the default branch in the last 52 weeks
+func (p gitLabProvider) commits(ctx context.Context, name, owner, repo string) (int, error) {
+	slog.Debug("Counting commits", "repo", name)
+	since := time.Now().UTC().AddDate(0, 0, -7*commitWeeks).Format(time.RFC3339)
+	return p.count(ctx, "/projects/"+url.PathEscape(owner+"/"+repo)+"/repository/commits", url.Values{"since": {since}})
+}
+
+func (p gitLabProvider) file(ctx context.Context, name, owner, repo, path string) ([]byte, error) {
+	slog.Debug("Getting file", "repo", name, "path", path)
+	var file struct {
+		Content string `json:"content"`
+	}
+	_, err := p.get(ctx, "/projects/"+url.PathEscape(owner+"/"+repo)+"/repository/files/"+url.PathEscape(path),
+		url.Values{"ref": {"HEAD"}}, &file)
+	var httpErr *httpError
+	if errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.DecodeString(file.Content)
+}
+
+// releases Pages through the releases, newest first, until they are older than since. GitLab lists
+// upcoming releases with a future release date, they are not counted.
+func (p gitLabProvider) releases(ctx context.Context, name, owner, repo string, since time.Time) (repoReleases, error) {
+	var r repoReleases
+	now := time.Now()
+	query := url.Values{"order_by": {"released_at"}, "sort": {"desc"}, "per_page": {"100"}, "page": {"1"}}
+	for {
+		var page []struct {
+			ReleasedAt time.Time `json:"released_at"`
+		}
+		slog.Debug("Listing releases", "repo", name, "page", query.Get("page"))
+		header, err := p.get(ctx, "/projects/"+url.PathEscape(owner+"/"+repo)+"/releases", query, &page)
+		if err != nil {
+			return repoReleases{}, err
+		}
+		older := false
+		for _, release := range page {
+			if release.ReleasedAt.After(now) {
+				continue
+			}
+			if r.latest.IsZero() || release.ReleasedAt.After(r.latest) {
+				r.latest = release.ReleasedAt
+			}
+			if release.ReleasedAt.Before(since) {
+				older = true
+				continue
+			}
+			r.lastYear++
+		}
+		next := header.Get("X-Next-Page")
+		if next == "" || older {
+			return r, nil
+		}
+		query.Set("page", next)
+	}
+}
+
+// count Counts the results of a GET request from the X-Total header of the first page, which GitLab leaves
+// out above 10,000 results. Their pages are then counted instead.
+func (p gitLabProvider) count(ctx context.Context, path string, query url.Values) (int, error) {
+	query.Set("per_page", "1")
+	var first []json.RawMessage
+	header, err := p.get(ctx, path, query, &first)
+	if err != nil {
+		return 0, err
+	}
+	if total, err := strconv.Atoi(header.Get("X-Total")); err == nil {
+		return total, nil
+	}
+	count := 0
+	query.Set("per_page", "100")
+	for page := "1"; page != ""; page = header.Get("X-Next-Page") {
+		var results []json.RawMessage
+		query.Set("page", page)
+		if header, err = p.get(ctx, path, query, &results); err != nil {
+			return 0, err
+		}
+		count += len(results)
+	}
+	return count, nil
+}
+
+// get Decodes the JSON response of a GET request to the API path into v and returns the response headers
+func (p gitLabProvider) get(ctx context.Context, path string, query url.Values, v interface{}) (http.Header, error) {
+	u := p.baseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	var header http.Header
+	err := p.c.call(ctx, gitLabHost, func(ctx context.Context) (*github.Response, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+		if err != nil {
+			return nil, err
+		}
+		if p.token != "" {
+			req.Header.Set("PRIVATE-TOKEN", p.token)
+		}
+		resp, err := p.client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		// The rate limit state of gitlab.com's gate, which pauses the GitLab requests once it is used up
+		rated := &github.Response{Response: resp, Rate: gitLabRate(resp.Header)}
+		if resp.StatusCode != http.StatusOK {
+			var body struct {
+				Message interface{} `json:"message"`
+			}
+			b, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+			message := strings.TrimSpace(string(b))
+			if json.Unmarshal(b, &body) == nil && body.Message != nil {
+				message = fmt.Sprint(body.Message)
+			}
+			httpErr := &httpError{URL: u, StatusCode: resp.StatusCode, Message: message}
+			if resp.StatusCode == http.StatusTooManyRequests {
+				httpErr.RetryAt = retryAt(resp.Header, time.Now())
+			}
+			return rated, httpErr
+		}
+		header = resp.Header
+		return rated, json.NewDecoder(resp.Body).Decode(v)
+	})
+	return header, err
+}
+
+// gitLabRate The rate limit GitLab reports in the RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset headers,
+// Limit is 0 without them
+func gitLabRate(h http.Header) github.Rate {
+	limit, err := strconv.Atoi(h.Get("RateLimit-Limit"))
+	if err != nil {
+		return github.Rate{}
+	}
+	remaining, err := strconv.Atoi(h.Get("RateLimit-Remaining"))
+	if err != nil {
+		return github.Rate{}
+	}
+	reset, err := strconv.ParseInt(h.Get("RateLimit-Reset"), 10, 64)
+	if err != nil {
+		return github.Rate{}
+	}
+	return github.Rate{Limit: limit, Remaining: remaining, Reset: github.Timestamp{Time: time.Unix(reset, 0)}}
+}
+
+// retryAt When a rate limited response allows requests again by its Retry-After header, in seconds or as a date,
+// or else its RateLimit-Reset header, zero when it has neither
+func retryAt(h http.Header, now time.Time) time.Time {
+	if after := h.Get("Retry-After"); after != "" {
+		if seconds, err := strconv.Atoi(after); err == nil {
+			return now.Add(time.Duration(seconds) * time.Second)
+		}
+		if t, err := http.ParseTime(after); err == nil {
+			return t
+		}
+	}
+	if reset, err := strconv.ParseInt(h.Get("RateLimit-Reset"), 10, 64); err == nil {
+		return time.Unix(reset, 0)
+	}
+	return time.Time{}
+}
@@ -0,0 +1,56 @@
+package stats
+
+import (
+	"golang.org/x/mod/modfile"
+	"sort"
+)
+
+// goModule The Go version and direct dependencies a go.mod declares
+type goModule struct {
+	goVersion string
+	requires  []string
+}
+
+// parseGoMod Reads the go directive and the modules required without an // indirect comment, ignoring
+// directives it does not know like newer Go releases do
+func parseGoMod(data []byte) (*goModule, error) {
+	f, err := modfile.ParseLax("go.mod", data, nil)
+	if err != nil {
+		return nil, err
+	}
+	m := &goModule{}
+	if f.Go != nil {
+		m.goVersion = f.Go.Version
+	}
+	for _, r := range f.Require {
+		if !r.Indirect {
+			m.requires = append(m.requires, r.Mod.Path)
+		}
+	}
+	return m, nil
+}
+
+// ModuleCount A Go module and the number of projects requiring it
+type ModuleCount struct {
+	Path     string
+	Projects int
+}
+
+// TopGoModules The modules required by the most projects, most used first and then by path, at most n of them
+// unless n is not positive
+func TopGoModules(result Result, n int) []ModuleCount {
+	modules := make([]ModuleCount, 0, len(result.GoModules))
+	for path, projects := range result.GoModules {
+		modules = append(modules, ModuleCount{Path: path, Projects: projects})
+	}
+	sort.Slice(modules, func(i, j int) bool {
+		if modules[i].Projects != modules[j].Projects {
+			return modules[i].Projects > modules[j].Projects
+		}
+		return modules[i].Path < modules[j].Path
+	})
+	if n > 0 && len(modules) > n {
+		modules = modules[:n]
+	}
+	return modules
+}
@@ -0,0 +1,198 @@
+package stats
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/go-github/v47/github"
+	"log/slog"
+	"strconv"
+	"strings"
+)
+
+// graphQLBatch Number of repos queried by a single GraphQL query
+const graphQLBatch = 50
+
+// prefetched Org listings, repo languages and repo names fetched ahead of the workers with batched GraphQL
+// queries. It is filled before the workers start and only read afterwards.
+type prefetched struct {
+	orgs  map[string][]repoInfo
+	repos map[string]map[string]int
+	// info The current name of every queried repo keyed by the owner/repo it was queried as
+	info map[string]repoInfo
+}
+
+// graphQLResponse The response to a query of repository aliases r0, r1, ...
+type graphQLResponse struct {
+	Data map[string]*struct {
+		NameWithOwner  string `json:"nameWithOwner"`
+		IsArchived     bool   `json:"isArchived"`
+		IsFork         bool   `json:"isFork"`
+		IsMirror       bool   `json:"isMirror"`
+		StargazerCount int    `json:"stargazerCount"`
+		ForkCount      int    `json:"forkCount"`
+		Issues         struct {
+			TotalCount int `json:"totalCount"`
+		} `json:"issues"`
+		PullRequests struct {
+			TotalCount int `json:"totalCount"`
+		} `json:"pullRequests"`
+		LicenseInfo *struct {
+			SPDXID string `json:"spdxId"`
+		} `json:"licenseInfo"`
+		DefaultBranchRef *struct {
+			Name string `json:"name"`
+		} `json:"defaultBranchRef"`
+		PushedAt  string `json:"pushedAt"`
+		Languages struct {
+			TotalCount int `json:"totalCount"`
+			Edges      []struct {
+				Size int `json:"size"`
+				Node struct {
+					Name string `json:"name"`
+				} `json:"node"`
+			} `json:"edges"`
+		} `json:"languages"`
+	} `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// prefetchGraphQL Lists the repos of every org and fetches the languages of all repos not in the checkpoint
+// with one GraphQL query per graphQLBatch repos. Repos GraphQL cannot return are left for the REST API.
+func (c *Collector) prefetchGraphQL(ctx context.Context, projects map[string]Project) (*prefetched, error) {
+	pre := &prefetched{
+		orgs:  make(map[string][]repoInfo),
+		repos: make(map[string]map[string]int),
+		info:  make(map[string]repoInfo),
+	}
+	var pending [][2]string
+	seen := make(map[string]bool)
+	for name, project := range projects {
+		for _, u := range project.URLs {
+			owner, repo, err := parseGitHubURL(u)
+			if err != nil {
+				continue
+			}
+			repos := []repoInfo{{owner: owner, name: repo}}
+			if repo == "" {
+				if _, ok := pre.orgs[owner]; !ok {
+					if pre.orgs[owner], err = c.listOrgRepos(ctx, name, owner); err != nil {
+						return nil, err
+					}
+				}
+				repos = pre.orgs[owner]
+			}
+			for _, r := range repos {
+				repo := r.name
+				key := owner + "/" + repo
+				if seen[key] {
+					continue
+				}
+				seen[key] = true
+				if c.opts.Checkpoint != nil {
+					if _, ok := c.opts.Checkpoint.languages(owner, repo, c.opts.Clone); ok {
+						continue
+					}
+				}
+				pending = append(pending, [2]string{owner, repo})
+			}
+		}
+	}
+
+	var queries, fetched int
+	for start := 0; start < len(pending); start += graphQLBatch {
+		end := start + graphQLBatch
+		if end > len(pending) {
+			end = len(pending)
+		}
+		n, err := c.queryLanguages(ctx, pending[start:end], pre)
+		if err != nil {
+			return nil, err
+		}
+		queries++
+		fetched += n
+	}
+	slog.Info("Fetched languages with GraphQL", "fetched", fetched, "repos", len(pending), "queries", queries)
+	return pre, nil
+}
+
+// queryLanguages Fetches the languages of the repos with a single GraphQL query into pre and returns the
+// number of repos whose languages it holds
+func (c *Collector) queryLanguages(ctx context.Context, repos [][2]string, pre *prefetched) (int, error) {
+	fields := "nameWithOwner isArchived isFork isMirror"
+	if c.wantsMetadata() {
+		fields += " stargazerCount forkCount issues(states: OPEN) { totalCount } pullRequests(states: OPEN) { totalCount }" +
+			" licenseInfo { spdxId } defaultBranchRef { name } pushedAt"
+	}
+	var query strings.Builder
+	query.WriteString("query {")
+	for i, r := range repos {
+		fmt.Fprintf(&query, " r%d: repository(owner: %s, name: %s) { %s"+
+			" languages(first: 100, orderBy: {field: SIZE, direction: DESC}) { totalCount edges { size node { name } } } }",
+			i, strconv.Quote(r[0]), strconv.Quote(r[1]), fields)
+	}
+	query.WriteString(" }")
+
+	var resp graphQLResponse
+	err := c.call(ctx, gitHubHost, func(ctx context.Context) (*github.Response, error) {
+		slog.Debug("Querying languages with GraphQL", "repos", len(repos))
+		req, err := c.GitHubClient.NewRequest("POST", "graphql", map[string]string{"query": query.String()})
+		if err != nil {
+			return nil, err
+		}
+		return c.GitHubClient.Do(ctx, req, &resp)
+	})
+	if err != nil {
+		if ctx.Err() == nil {
+			err = fmt.Errorf("querying languages with GraphQL: %w", err)
+		}
+		return 0, err
+	}
+	for _, e := range resp.Errors {
+		// Missing repos are reported per alias, the REST API reports them again with the project
+		slog.Warn("GraphQL error", "message", e.Message)
+	}
+
+	var n int
+	for i, r := range repos {
+		repo := resp.Data["r"+strconv.Itoa(i)]
+		if repo == nil {
+			continue
+		}
+		if owner, name, ok := strings.Cut(repo.NameWithOwner, "/"); ok {
+			info := repoInfo{owner: owner, name: name, archived: repo.IsArchived, fork: repo.IsFork, mirror: repo.IsMirror}
+			if c.wantsMetadata() {
+				// Like the REST API count the open pull requests as issues
+				info.metadata = &Metadata{
+					Stars:      repo.StargazerCount,
+					Forks:      repo.ForkCount,
+					OpenIssues: repo.Issues.TotalCount + repo.PullRequests.TotalCount,
+					PushedAt:   repo.PushedAt,
+				}
+				if repo.LicenseInfo != nil {
+					info.metadata.License = repo.LicenseInfo.SPDXID
+				}
+				if repo.DefaultBranchRef != nil {
+					info.metadata.DefaultBranch = repo.DefaultBranchRef.Name
+				}
+			}
+			pre.info[r[0]+"/"+r[1]] = info
+		}
+		if repo.Languages.TotalCount > len(repo.Languages.Edges) {
+			// Leave the rare repos with more languages than a page to the REST API
+			continue
+		}
+		languages := make(map[string]int, len(repo.Languages.Edges))
+		for _, edge := range repo.Languages.Edges {
+			languages[edge.Node.Name] = edge.Size
+		}
+		pre.repos[r[0]+"/"+r[1]] = languages
+		if repo.NameWithOwner != "" {
+			// Renamed repos are fetched by their current name with Options.CheckRepos
+			pre.repos[repo.NameWithOwner] = languages
+		}
+		n++
+	}
+	return n, nil
+}
@@ -0,0 +1,86 @@
+package stats
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// RepoState The last push and languages of every repo collected earlier, with which Options.Incremental skips
+// the repos nobody pushed to since. Entries are only recorded for repos whose last push is known: those listed
+// with their org, looked up for Options.CheckRepos, Options.Metadata or Options.Licenses, and every repo in clone
+// mode, which looks them up as cloning costs far more than the lookup.
+type RepoState struct {
+	mu    sync.Mutex
+	path  string
+	perm  os.FileMode
+	repos map[string]repoState
+	// skipped The repos whose languages were reused since the state was opened or saved
+	skipped int
+}
+
+// repoState A repo's last push and its languages collected after it
+type repoState struct {
+	PushedAt  string         `json:"pushedAt"`
+	Languages map[string]int `json:"languages"`
+	// Lines Whether the languages are code lines counted in a clone rather than bytes
+	Lines bool `json:"lines,omitempty"`
+}
+
+// OpenRepoState Reads the repo state saved at path, empty when the file does not exist yet
+func OpenRepoState(path string, perm os.FileMode) (*RepoState, error) {
+	s := &RepoState{path: path, perm: perm, repos: make(map[string]repoState)}
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &s.repos); err != nil {
+		return nil, fmt.Errorf("parsing repo state %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// languages The languages of the owner/repo counted the same way if it was not pushed to since
+func (s *RepoState) languages(repo, pushedAt string, lines bool) (map[string]int, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r, ok := s.repos[repo]
+	if !ok || r.PushedAt != pushedAt || r.Lines != lines {
+		return nil, false
+	}
+	s.skipped++
+	return r.Languages, true
+}
+
+// record Records the languages of the owner/repo collected after its last push
+func (s *RepoState) record(repo, pushedAt string, lines bool, languages map[string]int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.repos[repo] = repoState{PushedAt: pushedAt, Languages: languages, Lines: lines}
+}
+
+// Skipped The number of repos whose languages were reused since the state was opened or last saved
+func (s *RepoState) Skipped() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.skipped
+}
+
+// Save Writes the state of every recorded repo to the file it was opened from
+func (s *RepoState) Save() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, err := json.Marshal(s.repos)
+	if err != nil {
+		return err
+	}
+	s.skipped = 0
+	return writeAtomic(filepath.Dir(s.path), s.path, b, s.perm)
+}
@@ -0,0 +1,97 @@
+package stats
+
+import (
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"log/slog"
+	"time"
+)
+
+// LandscapeURL Location of the official CNCF landscape.yml
+const LandscapeURL = "https://raw.githubusercontent.com/cncf/landscape/master/landscape.yml"
+
+// landscape The parts of the CNCF landscape.yml describing CNCF projects
+type landscape struct {
+	Landscape []struct {
+		Subcategories []struct {
+			Items []landscapeItem `yaml:"items"`
+		} `yaml:"subcategories"`
+	} `yaml:"landscape"`
+}
+
+type landscapeItem struct {
+	Name            string `yaml:"name"`
+	Project         string `yaml:"project"`
+	RepoURL         string `yaml:"repo_url"`
+	AdditionalRepos []struct {
+		RepoURL string `yaml:"repo_url"`
+	} `yaml:"additional_repos"`
+	// Extra Holds the dates a project was accepted and moved to incubating and graduated
+	Extra struct {
+		Accepted   string `yaml:"accepted"`
+		Incubating string `yaml:"incubating"`
+		Graduated  string `yaml:"graduated"`
+	} `yaml:"extra"`
+}
+
+// ParseLandscape Extracts the graduated, incubating and sandbox projects and their repos from a CNCF landscape.yml.
+// Projects without a repo URL are skipped. The maturity date is the date the project reached its current maturity level.
+func ParseLandscape(data []byte) (Repos, error) {
+	var l landscape
+	if err := yaml.Unmarshal(data, &l); err != nil {
+		return Repos{}, fmt.Errorf("parsing landscape: %w", err)
+	}
+
+	repos := Repos{
+		Graduated:  make(map[string]Project),
+		Incubating: make(map[string]Project),
+		Sandbox:    make(map[string]Project),
+	}
+	for _, category := range l.Landscape {
+		for _, subcategory := range category.Subcategories {
+			for _, item := range subcategory.Items {
+				var group map[string]Project
+				var date string
+				switch item.Project {
+				case "graduated":
+					group, date = repos.Graduated, item.Extra.Graduated
+				case "incubating":
+					group, date = repos.Incubating, item.Extra.Incubating
+				case "sandbox":
+					group, date = repos.Sandbox, item.Extra.Accepted
+				default:
+					continue
+				}
+				if item.RepoURL == "" {
+					slog.Warn("Project has no repo_url in the landscape, skipping", "project", item.Name)
+					continue
+				}
+				if _, ok := group[item.Name]; ok {
+					// Projects appearing in several categories
+					continue
+				}
+
+				var p Project
+				urls := []string{item.RepoURL}
+				for _, r := range item.AdditionalRepos {
+					urls = append(urls, r.RepoURL)
+				}
+				for _, u := range urls {
+					if _, err := parseRepoURL(u); err != nil {
+						slog.Warn("Skipping the repo", "project", item.Name, "error", err)
+						continue
+					}
+					p.URLs = append(p.URLs, u)
+				}
+				if len(p.URLs) == 0 {
+					continue
+				}
+				if _, err := time.Parse("2006-01-02", date); err == nil {
+					p.MaturityDate = date
+				}
+				group[item.Name] = p
+			}
+		}
+	}
+	return repos, nil
+}
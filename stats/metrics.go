@@ -0,0 +1,333 @@
+package stats
+
+import (
+	"math"
+	"sort"
+)
+
+// Gini Computes the Gini coefficient of the values. With the n values sorted ascending as x_1..x_n it is
+//
+//	G = 2 * Σ(i * x_i) / (n * Σx_i) - (n + 1) / n
+//
+// which is 0 when all values are equal and (n - 1) / n when a single value holds everything.
+func Gini(values map[string]int) float64 {
+	x := make([]int, 0, len(values))
+	var sum int
+	for _, v := range values {
+		x = append(x, v)
+		sum += v
+	}
+	if len(x) == 0 || sum == 0 {
+		return 0
+	}
+	sort.Ints(x)
+
+	var weighted float64
+	for i, v := range x {
+		weighted += float64(i+1) * float64(v)
+	}
+	n := float64(len(x))
+	return 2*weighted/(n*float64(sum)) - (n+1)/n
+}
+
+// sortProjectLists Sorts the project names of every language
+func sortProjectLists(lists map[string][]string) {
+	for _, names := range lists {
+		sort.Strings(names)
+	}
+}
+
+// normalize Completes the counted projects containing every language with the shares and averages relative
+// to the group's projects, the sum of the top language counts
+func normalize(counted map[string]NormalizedLanguage, topLanguage, totals map[string]int) map[string]NormalizedLanguage {
+	var projects int
+	for _, count := range topLanguage {
+		projects += count
+	}
+	normalized := make(map[string]NormalizedLanguage, len(counted))
+	if projects == 0 {
+		return normalized
+	}
+	share := func(n int) float64 {
+		return math.Round(float64(n)*10000/float64(projects)) / 100
+	}
+	for lang, n := range counted {
+		normalized[lang] = NormalizedLanguage{
+			Projects:         n.Projects,
+			ProjectShare:     share(n.Projects),
+			TopLanguageShare: share(topLanguage[lang]),
+			PerProject:       int(math.Round(float64(totals[lang]) / float64(projects))),
+		}
+	}
+	return normalized
+}
+
+// UniqueLanguage A language found in exactly one group
+type UniqueLanguage struct {
+	Language string `json:"language"`
+	Group    string `json:"group"`
+	Bytes    int    `json:"bytes"`
+}
+
+// UniqueLanguages Finds the languages whose bytes come from a single group, sorted descending by bytes
+func UniqueLanguages(groupResults map[string]Result) []UniqueLanguage {
+	groups := make(map[string][]string)
+	for group, result := range groupResults {
+		for lang := range result.Totals() {
+			groups[lang] = append(groups[lang], group)
+		}
+	}
+
+	unique := []UniqueLanguage{}
+	for lang, in := range groups {
+		if len(in) == 1 {
+			unique = append(unique, UniqueLanguage{Language: lang, Group: in[0], Bytes: groupResults[in[0]].Totals()[lang]})
+		}
+	}
+	sort.Slice(unique, func(i, j int) bool {
+		if unique[i].Bytes != unique[j].Bytes {
+			return unique[i].Bytes > unique[j].Bytes
+		}
+		return unique[i].Language < unique[j].Language
+	})
+	return unique
+}
+
+// Average Averages the results. Every language's value is the arithmetic mean over all results,
+// counting results the language is missing from as 0, rounded to the nearest integer.
+func Average(results []Result) Result {
+	topLanguage := make(map[string]int)
+	totals := make(map[string]int)
+	var lines bool
+	for _, r := range results {
+		lines = lines || r.TotalLines != nil
+		for lang, count := range r.TopLanguage {
+			topLanguage[lang] += count
+		}
+		for lang, v := range r.Totals() {
+			totals[lang] += v
+		}
+	}
+
+	n := float64(len(results))
+	for lang, count := range topLanguage {
+		topLanguage[lang] = int(math.Round(float64(count) / n))
+	}
+	for lang, v := range totals {
+		totals[lang] = int(math.Round(float64(v) / n))
+	}
+	average := Result{SchemaVersion: SchemaVersion, TopLanguage: topLanguage, Percentages: LanguagePercentages(SortLanguageMap(totals)), Gini: Gini(totals)}
+	if lines {
+		average.TotalLines = totals
+	} else {
+		average.TotalBytes = totals
+	}
+	return average
+}
+
+// Combine Sums the results of several groups into a single result, e.g. of every CNCF project. Projects,
+// errors, renamed, archived and estimated repos are merged and each language's concentration is the largest of the groups' relative to the new total.
+func Combine(results []Result) Result {
+	combined := Result{TopLanguage: make(map[string]int)}
+	totals := make(map[string]int)
+	var lines bool
+	// meta Whether every result has its Meta, the combined counts would be incomplete otherwise
+	meta := true
+	for _, r := range results {
+		lines = lines || r.TotalLines != nil
+		for lang, count := range r.TopLanguage {
+			combined.TopLanguage[lang] += count
+		}
+		for lang, v := range r.Totals() {
+			totals[lang] += v
+		}
+		for lang, score := range r.WeightedTopLanguage {
+			if combined.WeightedTopLanguage == nil {
+				combined.WeightedTopLanguage = make(map[string]float64)
+			}
+			combined.WeightedTopLanguage[lang] += score
+		}
+		for name, p := range r.Projects {
+			if combined.Projects == nil {
+				combined.Projects = make(map[string]ProjectResult)
+			}
+			combined.Projects[name] = p
+		}
+		for name, e := range r.Errors {
+			if combined.Errors == nil {
+				combined.Errors = make(map[string]string)
+			}
+			combined.Errors[name] = e
+		}
+		for old, current := range r.Renamed {
+			if combined.Renamed == nil {
+				combined.Renamed = make(map[string]string)
+			}
+			combined.Renamed[old] = current
+		}
+		combined.Archived = append(combined.Archived, r.Archived...)
+		combined.EstimatedBytes = append(combined.EstimatedBytes, r.EstimatedBytes...)
+		for repo, w := range r.Weights {
+			if combined.Weights == nil {
+				combined.Weights = make(map[string]float64)
+			}
+			combined.Weights[repo] = w
+		}
+		if r.Aggregation != "" && (combined.Aggregation == "" || combined.Aggregation == r.Aggregation) {
+			combined.Aggregation = r.Aggregation
+			if combined.Scores == nil {
+				combined.Scores = make(map[string]float64)
+			}
+			for lang, score := range r.Scores {
+				combined.Scores[lang] += score
+			}
+		}
+		for name, n := range r.Contributors {
+			if combined.Contributors == nil {
+				combined.Contributors = make(map[string]int)
+			}
+			combined.Contributors[name] = n
+		}
+		combined.TotalContributors += r.TotalContributors
+		for name, n := range r.Commits {
+			if combined.Commits == nil {
+				combined.Commits = make(map[string]int)
+			}
+			combined.Commits[name] = n
+		}
+		for lang, n := range r.CommitsByLanguage {
+			if combined.CommitsByLanguage == nil {
+				combined.CommitsByLanguage = make(map[string]int)
+			}
+			combined.CommitsByLanguage[lang] += n
+		}
+		for name, version := range r.GoVersions {
+			if combined.GoVersions == nil {
+				combined.GoVersions = make(map[string]string)
+			}
+			combined.GoVersions[name] = version
+		}
+		for path, n := range r.GoModules {
+			if combined.GoModules == nil {
+				combined.GoModules = make(map[string]int)
+			}
+			combined.GoModules[path] += n
+		}
+		for name, p := range r.Releases {
+			if combined.Releases == nil {
+				combined.Releases = make(map[string]ProjectReleases)
+			}
+			combined.Releases[name] = p
+		}
+		for license, n := range r.Licenses {
+			if combined.Licenses == nil {
+				combined.Licenses = make(map[string]int)
+			}
+			combined.Licenses[license] += n
+		}
+		for lang, n := range r.LanguagePresence {
+			if combined.LanguagePresence == nil {
+				combined.LanguagePresence = make(map[string]int)
+			}
+			combined.LanguagePresence[lang] += n
+		}
+		for lang, names := range r.ProjectsByLanguage {
+			if combined.ProjectsByLanguage == nil {
+				combined.ProjectsByLanguage = make(map[string][]string)
+			}
+			combined.ProjectsByLanguage[lang] = append(combined.ProjectsByLanguage[lang], names...)
+		}
+		for lang, names := range r.ProjectsContaining {
+			if combined.ProjectsContaining == nil {
+				combined.ProjectsContaining = make(map[string][]string)
+			}
+			combined.ProjectsContaining[lang] = append(combined.ProjectsContaining[lang], names...)
+		}
+		for lang, n := range r.Normalized {
+			if combined.Normalized == nil {
+				combined.Normalized = make(map[string]NormalizedLanguage)
+			}
+			c := combined.Normalized[lang]
+			c.Projects += n.Projects
+			combined.Normalized[lang] = c
+		}
+		if r.Meta != nil {
+			combined.Meta = combineMeta(combined.Meta, *r.Meta)
+		} else {
+			meta = false
+		}
+		for name, m := range r.Metadata {
+			if combined.Metadata == nil {
+				combined.Metadata = make(map[string]Metadata)
+			}
+			combined.Metadata[name] = m
+		}
+		for lang, c := range r.Concentration {
+			if combined.Concentration == nil {
+				combined.Concentration = make(map[string]Concentration)
+			}
+			if c.lines == 0 {
+				// Loaded results only keep the share
+				c.lines = int(math.Round(c.Share * float64(r.Totals()[lang])))
+			}
+			if prev, ok := combined.Concentration[lang]; c.lines > prev.lines || ok && c.lines == prev.lines && c.Project < prev.Project {
+				combined.Concentration[lang] = c
+			}
+		}
+	}
+
+	for lang, c := range combined.Concentration {
+		if total := totals[lang]; total > 0 {
+			c.Share = float64(c.lines) / float64(total)
+		}
+		combined.Concentration[lang] = c
+	}
+	if lines {
+		combined.TotalLines = totals
+	} else {
+		combined.TotalBytes = totals
+	}
+	sort.Strings(combined.Archived)
+	sort.Strings(combined.EstimatedBytes)
+	sortProjectLists(combined.ProjectsByLanguage)
+	sortProjectLists(combined.ProjectsContaining)
+	combined.Percentages = LanguagePercentages(SortLanguageMap(totals))
+	combined.SchemaVersion = SchemaVersion
+	if !meta {
+		combined.Meta = nil
+	}
+	if combined.Normalized != nil {
+		combined.Normalized = normalize(combined.Normalized, combined.TopLanguage, totals)
+	}
+	combined.Gini = Gini(totals)
+	combined.ReleaseCadence = releaseCadence(combined.Releases)
+	return combined
+}
+
+// combineMeta Adds the counts of m to those of combined, nil before the first group. The build and API are kept
+// when all groups agree and cleared otherwise, the languages per repo cap is the largest of the groups'.
+func combineMeta(combined *RunMeta, m RunMeta) *RunMeta {
+	if combined == nil {
+		return &m
+	}
+	c := *combined
+	c.Projects += m.Projects
+	c.Skipped += m.Skipped
+	c.Failed += m.Failed
+	c.Requests += m.Requests
+	c.CappedRepos += m.CappedRepos
+	if m.CollectedAt > c.CollectedAt {
+		c.CollectedAt = m.CollectedAt
+	}
+	if m.BuildInfo != c.BuildInfo {
+		c.BuildInfo = BuildInfo{}
+	}
+	if m.API != c.API {
+		c.API = ""
+	}
+	if m.MaxLanguagesPerRepo != c.MaxLanguagesPerRepo {
+		// The larger cap, no group kept more languages per repo than it
+		c.MaxLanguagesPerRepo = max(c.MaxLanguagesPerRepo, m.MaxLanguagesPerRepo)
+	}
+	return &c
+}
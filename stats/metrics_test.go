@@ -0,0 +1,176 @@
+package stats
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestGini(t *testing.T) {
+	tests := []struct {
+		name   string
+		values map[string]int
+		want   float64
+	}{
+		{"empty", map[string]int{}, 0},
+		{"all zero", map[string]int{"Go": 0, "C": 0}, 0},
+		{"one language", map[string]int{"Go": 100}, 0},
+		{"even", map[string]int{"Go": 100, "C": 100, "Rust": 100}, 0},
+		{"one holds everything", map[string]int{"Go": 100, "C": 0, "Rust": 0, "Shell": 0}, 0.75},
+		{"uneven", map[string]int{"Go": 300, "Shell": 100}, 0.25},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Gini(tt.values); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProcessConcentrationStats(t *testing.T) {
+	tests := []struct {
+		name     string
+		projects map[string]LanguageLinesList
+		want     map[string]Concentration
+	}{
+		{
+			name: "largest project",
+			projects: map[string]LanguageLinesList{
+				"a": {{Language: "Go", Lines: 300}, {Language: "Shell", Lines: 10}},
+				"b": {{Language: "Go", Lines: 100}, {Language: "Shell", Lines: 30}},
+			},
+			want: map[string]Concentration{
+				"Go":    {Project: "a", Share: 0.75, lines: 300},
+				"Shell": {Project: "b", Share: 0.75, lines: 30},
+			},
+		},
+		{
+			name: "ties by project name",
+			projects: map[string]LanguageLinesList{
+				"c": {{Language: "Go", Lines: 100}},
+				"a": {{Language: "Go", Lines: 100}},
+				"b": {{Language: "Go", Lines: 100}},
+			},
+			want: map[string]Concentration{"Go": {Project: "a", Share: 1.0 / 3, lines: 100}},
+		},
+		{
+			name:     "single project",
+			projects: map[string]LanguageLinesList{"a": {{Language: "Go", Lines: 5}}},
+			want:     map[string]Concentration{"Go": {Project: "a", Share: 1, lines: 5}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := groupResult{Result: Result{TotalBytes: make(map[string]int)}, projectLanguages: tt.projects}
+			for _, l := range tt.projects {
+				for _, language := range l {
+					g.TotalBytes[language.Language] += language.Lines
+				}
+			}
+			g.processConcentrationStats()
+			if !reflect.DeepEqual(g.Concentration, tt.want) {
+				t.Errorf("got %+v, want %+v", g.Concentration, tt.want)
+			}
+		})
+	}
+}
+
+func TestCombine(t *testing.T) {
+	graduated := Result{
+		TopLanguage:   map[string]int{"Go": 2},
+		TotalBytes:    map[string]int{"Go": 300, "Shell": 100},
+		Concentration: map[string]Concentration{"Go": {Project: "b", Share: 0.5}, "Shell": {Project: "a", Share: 1}},
+		Archived:      []string{"x/z"},
+		Errors:        map[string]string{"e": "failed"},
+	}
+	incubating := Result{
+		TopLanguage:    map[string]int{"Go": 1, "Rust": 1},
+		TotalBytes:     map[string]int{"Go": 150, "Rust": 50},
+		Concentration:  map[string]Concentration{"Go": {Project: "a", Share: 1}, "Rust": {Project: "r", Share: 1}},
+		Archived:       []string{"x/a"},
+		EstimatedBytes: []string{"gitlab.com/g/p"},
+	}
+	got := Combine([]Result{graduated, incubating})
+
+	if want := map[string]int{"Go": 3, "Rust": 1}; !reflect.DeepEqual(got.TopLanguage, want) {
+		t.Errorf("got top languages %v, want %v", got.TopLanguage, want)
+	}
+	if want := map[string]int{"Go": 450, "Shell": 100, "Rust": 50}; !reflect.DeepEqual(got.TotalBytes, want) {
+		t.Errorf("got totals %v, want %v", got.TotalBytes, want)
+	}
+	if got.TotalLines != nil {
+		t.Errorf("got lines %v of results in bytes", got.TotalLines)
+	}
+	if want := map[string]float64{"Go": 75, "Shell": 16.67, "Rust": 8.33}; !reflect.DeepEqual(got.Percentages, want) {
+		t.Errorf("got percentages %v, want %v", got.Percentages, want)
+	}
+	// Both of Go's largest projects have 150 bytes, the tie goes to a
+	wantConcentration := map[string]Concentration{
+		"Go":    {Project: "a", Share: 1.0 / 3},
+		"Shell": {Project: "a", Share: 1},
+		"Rust":  {Project: "r", Share: 1},
+	}
+	for lang, want := range wantConcentration {
+		c := got.Concentration[lang]
+		if c.Project != want.Project || math.Abs(c.Share-want.Share) > 1e-9 {
+			t.Errorf("got concentration %+v of %s, want %+v", c, lang, want)
+		}
+	}
+	if want := []string{"x/a", "x/z"}; !reflect.DeepEqual(got.Archived, want) {
+		t.Errorf("got archived %v, want %v", got.Archived, want)
+	}
+	if want := []string{"gitlab.com/g/p"}; !reflect.DeepEqual(got.EstimatedBytes, want) {
+		t.Errorf("got estimated %v, want %v", got.EstimatedBytes, want)
+	}
+	if got.Errors["e"] != "failed" {
+		t.Errorf("got errors %v", got.Errors)
+	}
+	if got.SchemaVersion != SchemaVersion {
+		t.Errorf("got schema version %d, want %d", got.SchemaVersion, SchemaVersion)
+	}
+	if want := Gini(got.TotalBytes); got.Gini != want {
+		t.Errorf("got gini %v, want %v", got.Gini, want)
+	}
+}
+
+func TestCombineMeta(t *testing.T) {
+	a := Result{TopLanguage: map[string]int{}, Meta: &RunMeta{Projects: 2, Failed: 1, API: "rest", CollectedAt: "2024-01-01T00:00:00Z", MaxLanguagesPerRepo: 3, CappedRepos: 2}}
+	b := Result{TopLanguage: map[string]int{}, Meta: &RunMeta{Projects: 3, Skipped: 1, API: "graphql", CollectedAt: "2024-01-02T00:00:00Z", MaxLanguagesPerRepo: 5, CappedRepos: 1}}
+	got := Combine([]Result{a, b}).Meta
+	want := &RunMeta{Projects: 5, Skipped: 1, Failed: 1, CollectedAt: "2024-01-02T00:00:00Z", MaxLanguagesPerRepo: 5, CappedRepos: 3}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+	if m := Combine([]Result{a, {TopLanguage: map[string]int{}}}).Meta; m != nil {
+		t.Errorf("got meta %+v combining a result without it", m)
+	}
+}
+
+func TestAverage(t *testing.T) {
+	got := Average([]Result{
+		{TopLanguage: map[string]int{"Go": 3}, TotalBytes: map[string]int{"Go": 100, "C": 10}},
+		{TopLanguage: map[string]int{"Go": 2}, TotalBytes: map[string]int{"Go": 201}},
+	})
+	if want := map[string]int{"Go": 3}; !reflect.DeepEqual(got.TopLanguage, want) {
+		t.Errorf("got top languages %v, want %v", got.TopLanguage, want)
+	}
+	if want := map[string]int{"Go": 151, "C": 5}; !reflect.DeepEqual(got.TotalBytes, want) {
+		t.Errorf("got totals %v, want %v", got.TotalBytes, want)
+	}
+}
+
+func TestUniqueLanguages(t *testing.T) {
+	got := UniqueLanguages(map[string]Result{
+		"graduated":  {TotalBytes: map[string]int{"Go": 100, "Rust": 5}},
+		"incubating": {TotalBytes: map[string]int{"Go": 50, "Zig": 5, "C": 9}},
+	})
+	want := []UniqueLanguage{
+		{Language: "C", Group: "incubating", Bytes: 9},
+		{Language: "Rust", Group: "graduated", Bytes: 5},
+		{Language: "Zig", Group: "incubating", Bytes: 5},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
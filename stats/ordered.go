@@ -0,0 +1,174 @@
+package stats
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MarshalJSON Encodes the result with the entries of every map of numbers, e.g. the bytes or projects of each
+// language, ordered by value, largest first, and ties by key. The other maps keep encoding/json's order by key,
+// so that the result files of consecutive runs only differ where the stats changed.
+func (r Result) MarshalJSON() ([]byte, error) {
+	// plain Has no MarshalJSON method, which would recurse
+	type plain Result
+	b, err := json.Marshal(plain(r))
+	if err != nil {
+		return nil, err
+	}
+	return orderJSON(b, reflect.TypeOf(r))
+}
+
+// jsonMember A key and its encoded value in a JSON object
+type jsonMember struct {
+	key   string
+	value json.RawMessage
+}
+
+// orderJSON Orders the maps of numbers in the JSON encoding of a value of type t by value, recursing into
+// structs, maps and slices
+func orderJSON(b []byte, t reflect.Type) ([]byte, error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if bytes.Equal(b, []byte("null")) {
+		return b, nil
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		members, err := decodeObject(b)
+		if err != nil {
+			return nil, err
+		}
+		fields := jsonFields(t)
+		for i, m := range members {
+			if ft, ok := fields[m.key]; ok {
+				if members[i].value, err = orderJSON(m.value, ft); err != nil {
+					return nil, err
+				}
+			}
+		}
+		return encodeObject(members)
+	case reflect.Map:
+		members, err := decodeObject(b)
+		if err != nil {
+			return nil, err
+		}
+		if isNumber(t.Elem()) {
+			values := make(map[string]float64, len(members))
+			for _, m := range members {
+				if values[m.key], err = strconv.ParseFloat(string(m.value), 64); err != nil {
+					return nil, err
+				}
+			}
+			sort.SliceStable(members, func(i, j int) bool {
+				vi, vj := values[members[i].key], values[members[j].key]
+				if vi != vj {
+					return vi > vj
+				}
+				return members[i].key < members[j].key
+			})
+		} else {
+			for i, m := range members {
+				if members[i].value, err = orderJSON(m.value, t.Elem()); err != nil {
+					return nil, err
+				}
+			}
+		}
+		return encodeObject(members)
+	case reflect.Slice, reflect.Array:
+		if isNumber(t.Elem()) || t.Elem().Kind() == reflect.String {
+			return b, nil
+		}
+		var elems []json.RawMessage
+		if err := json.Unmarshal(b, &elems); err != nil {
+			return nil, err
+		}
+		for i, e := range elems {
+			var err error
+			if elems[i], err = orderJSON(e, t.Elem()); err != nil {
+				return nil, err
+			}
+		}
+		return json.Marshal(elems)
+	}
+	return b, nil
+}
+
+// jsonFields The types of a struct's fields by their JSON key, including those of embedded structs
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if !f.IsExported() || tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			for key, ft := range jsonFields(f.Type) {
+				fields[key] = ft
+			}
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[name] = f.Type
+	}
+	return fields
+}
+
+func isNumber(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// decodeObject The members of a JSON object in their order
+func decodeObject(b []byte) ([]jsonMember, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+	var members []jsonMember
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil, err
+		}
+		members = append(members, jsonMember{key: key.(string), value: value})
+	}
+	return members, nil
+}
+
+// encodeObject Encodes the members as a JSON object in their order
+func encodeObject(members []jsonMember) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, m := range members {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(m.key)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(m.value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,58 @@
+package stats
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestResultMarshalJSON(t *testing.T) {
+	tests := []struct {
+		name   string
+		result Result
+		want   string
+	}{
+		{
+			name:   "maps of numbers by value",
+			result: Result{TopLanguage: map[string]int{"C": 1, "Go": 5, "Rust": 2}},
+			want:   `{"topLanguage":{"Go":5,"Rust":2,"C":1},"gini":0}`,
+		},
+		{
+			name:   "ties by key",
+			result: Result{TopLanguage: map[string]int{"Rust": 2, "C": 2, "Go": 2}},
+			want:   `{"topLanguage":{"C":2,"Go":2,"Rust":2},"gini":0}`,
+		},
+		{
+			name:   "floats",
+			result: Result{TopLanguage: map[string]int{}, Percentages: map[string]float64{"C": 10.5, "Go": 89.5}},
+			want:   `{"topLanguage":{},"percentages":{"Go":89.5,"C":10.5},"gini":0}`,
+		},
+		{
+			name:   "other maps by key",
+			result: Result{TopLanguage: map[string]int{}, Errors: map[string]string{"b": "x", "a": "y"}},
+			want:   `{"topLanguage":{},"errors":{"a":"y","b":"x"},"gini":0}`,
+		},
+		{
+			name: "nested maps",
+			result: Result{TopLanguage: map[string]int{}, Projects: map[string]ProjectResult{
+				"p": {URL: "u", TopLanguage: "Go", Languages: map[string]int{"Shell": 1, "Go": 9}},
+			}},
+			want: `{"topLanguage":{},"projects":{"p":{"url":"u","topLanguage":"Go","languages":{"Go":9,"Shell":1}}},"gini":0}`,
+		},
+		{
+			name:   "nil maps",
+			result: Result{},
+			want:   `{"topLanguage":null,"gini":0}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := json.Marshal(tt.result)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != tt.want {
+				t.Errorf("got %s, want %s", b, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,319 @@
+package stats
+
+import (
+	"bytes"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"math"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Repos The projects of every CNCF maturity group, keyed by project name
+type Repos struct {
+	Graduated  map[string]Project `yaml:"Graduated"`
+	Incubating map[string]Project `yaml:"Incubating"`
+	Sandbox    map[string]Project `yaml:"Sandbox"`
+	// Custom Projects outside the CNCF, e.g. popular open source projects, that the CNCF groups are compared against
+	Custom map[string]Project `yaml:"Custom"`
+	// Weights Scale the bytes or lines the lower case owner/repo adds to the group totals, e.g. 0.25 to count a
+	// quarter of a monorepo or 0 to leave it out, while its project still reports it in full
+	Weights map[string]float64 `yaml:"weights"`
+}
+
+// repoGroup A maturity group of Repos with its name in repos.yaml
+type repoGroup struct {
+	name     string
+	projects map[string]Project
+}
+
+// groups The maturity groups in repos.yaml order, followed by Custom
+func (r Repos) groups() []repoGroup {
+	return []repoGroup{
+		{"Graduated", r.Graduated},
+		{"Incubating", r.Incubating},
+		{"Sandbox", r.Sandbox},
+		{"Custom", r.Custom},
+	}
+}
+
+// Projects The projects of every group including Custom, keyed by project name. A name listed in several groups
+// keeps the project of the last.
+func (r Repos) Projects() map[string]Project {
+	projects := make(map[string]Project)
+	for _, g := range r.groups() {
+		for name, p := range g.projects {
+			projects[name] = p
+		}
+	}
+	return projects
+}
+
+// group The projects of the maturity group named as in repos.yaml, nil for an unknown name
+func (r Repos) group(name string) map[string]Project {
+	for _, g := range r.groups() {
+		if g.name == name {
+			return g.projects
+		}
+	}
+	return nil
+}
+
+// LoadRepos Reads the projects of every maturity group from a repos.yaml file
+func LoadRepos(path string) (Repos, error) {
+	var repos Repos
+	f, err := os.ReadFile(path)
+	if err != nil {
+		return repos, err
+	}
+	if err := yaml.Unmarshal(f, &repos); err != nil {
+		return repos, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	if len(repos.Weights) > 0 {
+		weights := make(map[string]float64, len(repos.Weights))
+		for repo, w := range repos.Weights {
+			if owner, name, ok := strings.Cut(repo, "/"); !ok || owner == "" || name == "" {
+				return repos, fmt.Errorf("parsing %s: weight of %q, repos are weighted by owner/repo", path, repo)
+			}
+			if w < 0 || math.IsNaN(w) {
+				return repos, fmt.Errorf("parsing %s: weight %v of %s must not be negative", path, w, repo)
+			}
+			weights[strings.ToLower(repo)] = w
+		}
+		repos.Weights = weights
+	}
+	return repos, nil
+}
+
+// ReplaceURLs Replaces the project URLs that are keys of urls with their values and returns the number of
+// replaced URLs
+func (r Repos) ReplaceURLs(urls map[string]string) int {
+	var n int
+	for _, g := range r.groups() {
+		for _, p := range g.projects {
+			for i, u := range p.URLs {
+				if replacement, ok := urls[u]; ok {
+					p.URLs[i] = replacement
+					n++
+				}
+			}
+		}
+	}
+	return n
+}
+
+// MarshalRepos Encodes the projects in the repos.yaml format with the projects of every group sorted by name
+func MarshalRepos(repos Repos, generated time.Time) ([]byte, error) {
+	doc := &yaml.Node{Kind: yaml.MappingNode}
+	for _, group := range repos.groups() {
+		if group.name == "Custom" && len(group.projects) == 0 {
+			continue
+		}
+		names := make([]string, 0, len(group.projects))
+		for name := range group.projects {
+			names = append(names, name)
+		}
+		sort.Slice(names, func(i, j int) bool {
+			return strings.ToLower(names[i]) < strings.ToLower(names[j])
+		})
+
+		projects := &yaml.Node{Kind: yaml.MappingNode}
+		for _, name := range names {
+			var value yaml.Node
+			if err := value.Encode(group.projects[name]); err != nil {
+				return nil, err
+			}
+			projects.Content = append(projects.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: name}, &value)
+		}
+		doc.Content = append(doc.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: group.name}, projects)
+	}
+	if len(repos.Weights) > 0 {
+		var weights yaml.Node
+		if err := weights.Encode(repos.Weights); err != nil {
+			return nil, err
+		}
+		doc.Content = append(doc.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: "weights"}, &weights)
+	}
+	doc.HeadComment = "Last generated: " + generated.Format("2006-01-02")
+
+	var b bytes.Buffer
+	enc := yaml.NewEncoder(&b)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// Project A CNCF project entry in repos.yaml. An entry is either a URL, a list of URLs or a mapping with the url
+// (again a single URL or a list) and an optional maturityDate, the YYYY-MM-DD date the project reached its current
+// maturity level. A URL is either a repository or a GitHub org, which includes all of the org's repositories.
+type Project struct {
+	URLs         []string
+	MaturityDate string
+}
+
+func (p *Project) UnmarshalYAML(value *yaml.Node) error {
+	switch value.Kind {
+	case yaml.ScalarNode, yaml.SequenceNode:
+		if err := decodeURLs(value, &p.URLs); err != nil {
+			return err
+		}
+	default:
+		var entry struct {
+			URL          yaml.Node `yaml:"url"`
+			MaturityDate string    `yaml:"maturityDate"`
+		}
+		if err := value.Decode(&entry); err != nil {
+			return err
+		}
+		if entry.URL.Kind == 0 {
+			return fmt.Errorf("line %d: project is missing a url", value.Line)
+		}
+		if err := decodeURLs(&entry.URL, &p.URLs); err != nil {
+			return err
+		}
+		p.MaturityDate = entry.MaturityDate
+	}
+
+	if len(p.URLs) == 0 {
+		return fmt.Errorf("line %d: project is missing a url", value.Line)
+	}
+	for _, u := range p.URLs {
+		if _, err := parseRepoURL(u); err != nil {
+			return fmt.Errorf("line %d: %w", value.Line, err)
+		}
+	}
+	if p.MaturityDate != "" {
+		if _, err := time.Parse("2006-01-02", p.MaturityDate); err != nil {
+			return fmt.Errorf("line %d: maturityDate %q is not formatted as YYYY-MM-DD", value.Line, p.MaturityDate)
+		}
+	}
+	return nil
+}
+
+// MarshalYAML Encodes the project in the shortest repos.yaml form
+func (p Project) MarshalYAML() (interface{}, error) {
+	var url interface{} = p.URLs
+	if len(p.URLs) == 1 {
+		url = p.URLs[0]
+	}
+	if p.MaturityDate == "" {
+		return url, nil
+	}
+	return struct {
+		URL          interface{} `yaml:"url"`
+		MaturityDate string      `yaml:"maturityDate"`
+	}{url, p.MaturityDate}, nil
+}
+
+// decodeURLs Decodes a single URL or a list of URLs
+func decodeURLs(value *yaml.Node, urls *[]string) error {
+	if value.Kind == yaml.ScalarNode {
+		var u string
+		if err := value.Decode(&u); err != nil {
+			return err
+		}
+		*urls = []string{u}
+		return nil
+	}
+	return value.Decode(urls)
+}
+
+const (
+	gitHubHost = "github.com"
+	gitLabHost = "gitlab.com"
+)
+
+// Hosts The code hosts project URLs may point to
+var Hosts = []string{gitHubHost, gitLabHost}
+
+// repoURL A repository or org URL split into its parts, repo is empty for an org or group URL
+type repoURL struct {
+	host, owner, repo string
+}
+
+// namespace The owner, prefixed with the host for repos not on GitHub so that their names cannot clash
+// with GitHub's in checkpoints and results
+func (u repoURL) namespace() string {
+	if u.host == gitHubHost {
+		return u.owner
+	}
+	return u.host + "/" + u.owner
+}
+
+func (u repoURL) String() string {
+	if u.repo == "" {
+		return fmt.Sprintf("https://%s/%s", u.host, u.owner)
+	}
+	return fmt.Sprintf("https://%s/%s/%s", u.host, u.owner, u.repo)
+}
+
+// projectHost The host of the project's first URL whose workers fetch it, github.com when it has none or it does
+// not parse, which fetching the project then reports
+func projectHost(project Project) string {
+	if len(project.URLs) > 0 {
+		if ref, err := parseRepoURL(project.URLs[0]); err == nil {
+			return ref.host
+		}
+	}
+	return gitHubHost
+}
+
+// parseRepoURL Splits a GitHub or GitLab URL into its host, owner and repo. On GitLab the owner is the full
+// namespace of a project, so https://gitlab.com/group/subgroup/project has the owner group/subgroup, and a
+// URL with a single path segment is a group.
+func parseRepoURL(rawURL string) (repoURL, error) {
+	// https://github.com/containerd/containerd, https://github.com/argoproj or https://gitlab.com/gitlab-org/gitlab
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return repoURL{}, err
+	}
+	path := strings.Split(strings.Trim(u.Path, "/"), "/")
+	switch u.Host {
+	case gitHubHost:
+		switch {
+		case len(path) == 1 && path[0] != "":
+			return repoURL{host: u.Host, owner: path[0]}, nil
+		case len(path) == 2:
+			return repoURL{host: u.Host, owner: path[0], repo: path[1]}, nil
+		}
+		return repoURL{}, fmt.Errorf("%q is neither a GitHub repository nor an org URL", rawURL)
+	case gitLabHost:
+		for _, segment := range path {
+			if segment == "" || segment == "-" {
+				return repoURL{}, fmt.Errorf("%q is neither a GitLab project nor a group URL", rawURL)
+			}
+		}
+		if len(path) == 1 {
+			return repoURL{host: u.Host, owner: path[0]}, nil
+		}
+		last := len(path) - 1
+		return repoURL{host: u.Host, owner: strings.Join(path[:last], "/"), repo: path[last]}, nil
+	}
+	return repoURL{}, fmt.Errorf("%q is neither a GitHub nor a GitLab URL", rawURL)
+}
+
+// IsOrgURL Reports whether the URL is a GitHub org or GitLab group URL, which includes all of its repos
+func IsOrgURL(rawURL string) bool {
+	u, err := parseRepoURL(rawURL)
+	return err == nil && u.repo == ""
+}
+
+// parseGitHubURL Splits a GitHub URL into its owner and repo, repo is empty for an org URL
+func parseGitHubURL(rawURL string) (string, string, error) {
+	u, err := parseRepoURL(rawURL)
+	if err != nil {
+		return "", "", err
+	}
+	if u.host != gitHubHost {
+		return "", "", fmt.Errorf("%q is not a GitHub URL", rawURL)
+	}
+	return u.owner, u.repo, nil
+}
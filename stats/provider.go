@@ -0,0 +1,233 @@
+package stats
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/go-github/v47/github"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// provider A code host org listings and language stats are fetched from
+type provider interface {
+	// listRepos Lists all repos of an org, named relative to it
+	listRepos(ctx context.Context, name, org string) ([]repoInfo, error)
+	// repo Looks up the current name of a repo, following renames and transfers
+	repo(ctx context.Context, name, owner, repo string) (repoInfo, error)
+	// languages Fetches the bytes of every language of a repo
+	languages(ctx context.Context, name, owner, repo string) (map[string]int, error)
+	// contributors Counts the contributors of a repo
+	contributors(ctx context.Context, name, owner, repo string) (int, error)
+	// commits Counts the commits to a repo's default branch in the last commitWeeks weeks
+	commits(ctx context.Context, name, owner, repo string) (int, error)
+	// file Fetches a file of a repo's default branch, nil when the repo has no such file
+	file(ctx context.Context, name, owner, repo, path string) ([]byte, error)
+	// releases Counts the releases of a repo published since and finds its latest release
+	releases(ctx context.Context, name, owner, repo string, since time.Time) (repoReleases, error)
+}
+
+// commitWeeks Weeks of commit activity counted, the year GitHub's statistics cover
+const commitWeeks = 52
+
+// computingRetry Retries GitHub statistics requests answered with 202 Accepted while GitHub computes them
+var computingRetry = Retry{MaxAttempts: 6, Backoff: 2 * time.Second, Jitter: 0.2}
+
+// repoInfo A repo's current owner and name, whether it is archived and its metadata if reported
+type repoInfo struct {
+	owner, name string
+	archived    bool
+	// fork and mirror Whether the repo is a fork or a mirror of another repo
+	fork, mirror bool
+	metadata     *Metadata
+}
+
+// sortRepos Sorts listed repos by name
+func sortRepos(repos []repoInfo) {
+	sort.Slice(repos, func(i, j int) bool {
+		return repos[i].name < repos[j].name
+	})
+}
+
+// gitHubProvider Fetches from the GitHub REST API
+type gitHubProvider struct {
+	c *Collector
+}
+
+func (p gitHubProvider) listRepos(ctx context.Context, name, org string) ([]repoInfo, error) {
+	return p.c.listOrgRepos(ctx, name, org)
+}
+
+func (p gitHubProvider) repo(ctx context.Context, name, owner, repo string) (repoInfo, error) {
+	var r *github.Repository
+	err := p.c.call(ctx, gitHubHost, func(ctx context.Context) (*github.Response, error) {
+		slog.Debug("Looking up", "repo", name)
+		var resp *github.Response
+		var err error
+		r, resp, err = p.c.GitHubClient.Repositories.Get(ctx, owner, repo)
+		return resp, err
+	})
+	if err != nil {
+		if ctx.Err() == nil {
+			err = fmt.Errorf("looking up %s: %w", name, err)
+		}
+		return repoInfo{}, err
+	}
+	return gitHubRepoInfo(r), nil
+}
+
+// gitHubRepoInfo The name, archived state and metadata of a repo returned by the REST API
+func gitHubRepoInfo(r *github.Repository) repoInfo {
+	m := &Metadata{
+		Stars:         r.GetStargazersCount(),
+		Forks:         r.GetForksCount(),
+		OpenIssues:    r.GetOpenIssuesCount(),
+		License:       r.GetLicense().GetSPDXID(),
+		DefaultBranch: r.GetDefaultBranch(),
+	}
+	if pushed := r.GetPushedAt(); !pushed.IsZero() {
+		m.PushedAt = pushed.UTC().Format(time.RFC3339)
+	}
+	return repoInfo{
+		owner:    r.GetOwner().GetLogin(),
+		name:     r.GetName(),
+		archived: r.GetArchived(),
+		fork:     r.GetFork(),
+		mirror:   r.GetMirrorURL() != "",
+		metadata: m,
+	}
+}
+
+func (p gitHubProvider) languages(ctx context.Context, name, owner, repo string) (map[string]int, error) {
+	var languages map[string]int
+	err := p.c.call(ctx, gitHubHost, func(ctx context.Context) (*github.Response, error) {
+		slog.Debug("Getting language stats", "repo", name)
+		var resp *github.Response
+		var err error
+		languages, resp, err = p.c.Languages.ListLanguages(ctx, owner, repo)
+		if err == nil && resp != nil && resp.Request != nil && !strings.EqualFold(resp.Request.URL.Path, "/repos/"+owner+"/"+repo+"/languages") {
+			// The HTTP client followed GitHub's redirect to the repo's new name
+			slog.Warn("Repo moved, GitHub redirected its language stats", "repo", name, "path", resp.Request.URL.Path)
+		}
+		return resp, err
+	})
+	return languages, err
+}
+
+// contributors Counts the contributors with a GitHub account from the last page of a single contributor per page
+func (p gitHubProvider) contributors(ctx context.Context, name, owner, repo string) (int, error) {
+	var count int
+	err := p.c.call(ctx, gitHubHost, func(ctx context.Context) (*github.Response, error) {
+		slog.Debug("Counting contributors", "repo", name)
+		opts := &github.ListContributorsOptions{ListOptions: github.ListOptions{PerPage: 1}}
+		contributors, resp, err := p.c.GitHubClient.Repositories.ListContributors(ctx, owner, repo, opts)
+		count = len(contributors)
+		if err == nil && resp.LastPage > 0 {
+			count = resp.LastPage
+		}
+		return resp, err
+	})
+	return count, err
+}
+
+// commits Sums up the weekly commit activity. GitHub answers with 202 Accepted while it computes the statistics
+// of a repo not requested lately, the request is then retried with computingRetry.
+func (p gitHubProvider) commits(ctx context.Context, name, owner, repo string) (int, error) {
+	for attempt := 1; ; attempt++ {
+		var weeks []*github.WeeklyCommitActivity
+		err := p.c.call(ctx, gitHubHost, func(ctx context.Context) (*github.Response, error) {
+			slog.Debug("Getting commit activity", "repo", name)
+			var resp *github.Response
+			var err error
+			weeks, resp, err = p.c.GitHubClient.Repositories.ListCommitActivity(ctx, owner, repo)
+			return resp, err
+		})
+		var accepted *github.AcceptedError
+		if errors.As(err, &accepted) && attempt < computingRetry.MaxAttempts {
+			delay := computingRetry.delay(attempt)
+			slog.Debug("GitHub is computing the commit activity, retrying", "repo", name, "attempt", attempt, "delay", delay)
+			if err := sleep(ctx, delay); err != nil {
+				return 0, err
+			}
+			continue
+		}
+		if err != nil {
+			if accepted != nil {
+				err = fmt.Errorf("GitHub was still computing the commit activity after %d attempts", attempt)
+			}
+			return 0, err
+		}
+		commits := 0
+		for _, week := range weeks {
+			commits += week.GetTotal()
+		}
+		return commits, nil
+	}
+}
+
+func (p gitHubProvider) file(ctx context.Context, name, owner, repo, path string) ([]byte, error) {
+	var content *github.RepositoryContent
+	err := p.c.call(ctx, gitHubHost, func(ctx context.Context) (*github.Response, error) {
+		slog.Debug("Getting file", "repo", name, "path", path)
+		var resp *github.Response
+		var err error
+		content, _, resp, err = p.c.GitHubClient.Repositories.GetContents(ctx, owner, repo, path, nil)
+		return resp, err
+	})
+	var errResp *github.ErrorResponse
+	if errors.As(err, &errResp) && errResp.Response != nil && errResp.Response.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if content == nil {
+		// path is a directory
+		return nil, nil
+	}
+	text, err := content.GetContent()
+	return []byte(text), err
+}
+
+// releases Pages through the releases, newest first, until they are older than since. Drafts are skipped.
+func (p gitHubProvider) releases(ctx context.Context, name, owner, repo string, since time.Time) (repoReleases, error) {
+	var r repoReleases
+	opt := &github.ListOptions{PerPage: 100}
+	for {
+		var page []*github.RepositoryRelease
+		err := p.c.call(ctx, gitHubHost, func(ctx context.Context) (*github.Response, error) {
+			slog.Debug("Listing releases", "repo", name, "page", opt.Page)
+			var resp *github.Response
+			var err error
+			page, resp, err = p.c.GitHubClient.Repositories.ListReleases(ctx, owner, repo, opt)
+			if resp != nil {
+				opt.Page = resp.NextPage
+			}
+			return resp, err
+		})
+		if err != nil {
+			return repoReleases{}, err
+		}
+		older := false
+		for _, release := range page {
+			if release.GetDraft() {
+				continue
+			}
+			published := release.GetPublishedAt().Time
+			if r.latest.IsZero() || published.After(r.latest) {
+				r.latest = published
+			}
+			if published.Before(since) {
+				older = true
+				continue
+			}
+			r.lastYear++
+		}
+		if opt.Page == 0 || older {
+			return r, nil
+		}
+	}
+}
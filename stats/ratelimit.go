@@ -0,0 +1,186 @@
+package stats
+
+import (
+	"context"
+	"errors"
+	"github.com/google/go-github/v47/github"
+	"golang.org/x/time/rate"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// secondaryRateLimitBackoff Initial wait after a secondary rate limit without a Retry-After header,
+	// doubled on every consecutive hit of the same request
+	secondaryRateLimitBackoff = time.Minute
+	// maxSecondaryRateLimitBackoff Longest wait after a secondary rate limit
+	maxSecondaryRateLimitBackoff = 15 * time.Minute
+)
+
+// DefaultHostConcurrency Requests in flight at once to each host without a HostLimit capping them, few enough
+// that GitHub does not answer a burst of them with a secondary rate limit
+var DefaultHostConcurrency = map[string]int{gitHubHost: 10, gitLabHost: 5}
+
+// HostLimit Paces and caps the API requests to one code host independently of the other hosts
+type HostLimit struct {
+	// Limiter Paces the requests to the host when set. It is shared by all groups a Collector processes
+	// concurrently and may also be shared between Collectors.
+	Limiter *rate.Limiter
+	// Concurrency Caps the requests to the host in flight at once across all workers and groups,
+	// DefaultHostConcurrency of the host when not positive
+	Concurrency int
+}
+
+// hostLimiter The rate limit state, pacing and concurrency cap of the requests to one host, so that an exhausted
+// or throttled host never holds back the requests to another
+type hostLimiter struct {
+	gate    *rateLimitGate
+	limiter *rate.Limiter
+	// slots Holds a value for every request in flight, nil when they are not capped
+	slots chan struct{}
+}
+
+func newHostLimiter(host string, limit HostLimit, reserve int) *hostLimiter {
+	h := &hostLimiter{gate: &rateLimitGate{reserve: reserve}, limiter: limit.Limiter}
+	if h.limiter == nil {
+		h.limiter = rate.NewLimiter(rate.Inf, 1)
+	}
+	n := limit.Concurrency
+	if n <= 0 {
+		n = DefaultHostConcurrency[host]
+	}
+	if n > 0 {
+		h.slots = make(chan struct{}, n)
+	}
+	return h
+}
+
+// split A hostLimiter sharing h's rate limit state, pacing and capping the requests at an n-th of h's rate and
+// concurrency, at least one request at a time
+func (h *hostLimiter) split(n int) *hostLimiter {
+	s := &hostLimiter{gate: h.gate, limiter: h.limiter}
+	if limit := h.limiter.Limit(); limit != rate.Inf {
+		s.limiter = rate.NewLimiter(limit/rate.Limit(n), 1)
+	}
+	if h.slots != nil {
+		s.slots = make(chan struct{}, (cap(h.slots)+n-1)/n)
+	}
+	return s
+}
+
+// acquire Blocks until a request may be sent to the host, which release must then be called for
+func (h *hostLimiter) acquire(ctx context.Context) error {
+	if h.slots == nil {
+		return nil
+	}
+	select {
+	case h.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (h *hostLimiter) release() {
+	if h.slots != nil {
+		<-h.slots
+	}
+}
+
+// rateLimitGate Holds back API requests while a host's rate limit is exhausted. It is shared by all
+// workers of a Collector so that a limit seen by one worker pauses the others too.
+type rateLimitGate struct {
+	mu       sync.Mutex
+	resumeAt time.Time
+	// rate The rate limit reported by the latest response, Limit is 0 before the first one
+	rate github.Rate
+	// reserve The remaining requests left to other users of the token
+	reserve int
+}
+
+// wait Blocks until requests may be sent again or ctx is cancelled
+func (g *rateLimitGate) wait(ctx context.Context) error {
+	g.mu.Lock()
+	d := time.Until(g.resumeAt)
+	g.mu.Unlock()
+	if d <= 0 {
+		return nil
+	}
+	slog.Warn("Rate limited", "resumingIn", d.Round(time.Second))
+	return sleep(ctx, d)
+}
+
+// pauseUntil Holds back requests until t unless they are already held back for longer
+func (g *rateLimitGate) pauseUntil(t time.Time) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if t.After(g.resumeAt) {
+		g.resumeAt = t
+	}
+}
+
+// observe Pauses requests until the reset time once a response reports no remaining requests beyond the reserve
+func (g *rateLimitGate) observe(resp *github.Response) {
+	if resp == nil || resp.Rate.Limit == 0 {
+		return
+	}
+	g.mu.Lock()
+	g.rate = resp.Rate
+	g.mu.Unlock()
+	if resp.Rate.Remaining <= g.reserve {
+		if g.reserve > 0 {
+			slog.Debug("Rate limit down to the reserved quota", "remaining", resp.Rate.Remaining, "reset", resp.Rate.Reset.Format(time.RFC3339))
+		}
+		g.pauseUntil(resp.Rate.Reset.Time)
+	}
+}
+
+// remaining The requests remaining according to the latest response, -1 before the first one
+func (g *rateLimitGate) remaining() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.rate.Limit == 0 {
+		return -1
+	}
+	return g.rate.Remaining
+}
+
+// backoff Pauses requests when err is a primary or secondary rate limit error of GitHub or a 429 Too Many
+// Requests response of another host and reports whether the request should be retried. attempt counts the
+// previous secondary rate limit hits of the request.
+func (g *rateLimitGate) backoff(err error, attempt int) bool {
+	var rateErr *github.RateLimitError
+	var abuseErr *github.AbuseRateLimitError
+	var httpErr *httpError
+	switch {
+	case errors.As(err, &rateErr):
+		slog.Warn("Rate limit exhausted", "reset", rateErr.Rate.Reset.Format(time.RFC3339))
+		g.pauseUntil(rateErr.Rate.Reset.Time)
+		return true
+	case errors.As(err, &abuseErr):
+		d := secondaryRateLimitBackoff << attempt
+		if d <= 0 || d > maxSecondaryRateLimitBackoff {
+			d = maxSecondaryRateLimitBackoff
+		}
+		if abuseErr.RetryAfter != nil {
+			d = *abuseErr.RetryAfter
+		}
+		slog.Warn("Secondary rate limit hit", "backoff", d)
+		g.pauseUntil(time.Now().Add(d))
+		return true
+	case errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusTooManyRequests:
+		if httpErr.RetryAt.IsZero() {
+			d := secondaryRateLimitBackoff << attempt
+			if d <= 0 || d > maxSecondaryRateLimitBackoff {
+				d = maxSecondaryRateLimitBackoff
+			}
+			httpErr.RetryAt = time.Now().Add(d)
+		}
+		slog.Warn("Rate limited", "url", httpErr.URL, "resumingAt", httpErr.RetryAt.Format(time.RFC3339))
+		g.pauseUntil(httpErr.RetryAt)
+		return true
+	}
+	return false
+}
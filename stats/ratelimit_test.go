@@ -0,0 +1,161 @@
+package stats
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/go-github/v47/github"
+	"golang.org/x/time/rate"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRateLimitGateObserve(t *testing.T) {
+	reset := time.Now().Add(time.Hour).Truncate(time.Second)
+	tests := []struct {
+		name      string
+		reserve   int
+		rate      github.Rate
+		paused    bool
+		remaining int
+	}{
+		{"requests left", 0, github.Rate{Limit: 5000, Remaining: 10, Reset: github.Timestamp{Time: reset}}, false, 10},
+		{"used up", 0, github.Rate{Limit: 5000, Remaining: 0, Reset: github.Timestamp{Time: reset}}, true, 0},
+		{"down to the reserve", 1000, github.Rate{Limit: 5000, Remaining: 1000, Reset: github.Timestamp{Time: reset}}, true, 1000},
+		{"above the reserve", 1000, github.Rate{Limit: 5000, Remaining: 1001, Reset: github.Timestamp{Time: reset}}, false, 1001},
+		{"no rate limit reported", 0, github.Rate{}, false, -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := &rateLimitGate{reserve: tt.reserve}
+			g.observe(&github.Response{Rate: tt.rate})
+			if paused := g.resumeAt.Equal(reset); paused != tt.paused {
+				t.Errorf("got paused %v until %v, want %v", paused, g.resumeAt, tt.paused)
+			}
+			if got := g.remaining(); got != tt.remaining {
+				t.Errorf("got %d remaining, want %d", got, tt.remaining)
+			}
+		})
+	}
+}
+
+func TestRateLimitGateBackoff(t *testing.T) {
+	reset := time.Now().Add(time.Hour).Truncate(time.Second)
+	retryAfter := 30 * time.Second
+	tests := []struct {
+		name    string
+		err     error
+		attempt int
+		retry   bool
+		// pause How long requests are held back at least, and at most a second longer
+		pause time.Duration
+		// until When requests are held back until instead of pause when set
+		until time.Time
+	}{
+		{"primary rate limit", &github.RateLimitError{Rate: github.Rate{Reset: github.Timestamp{Time: reset}}}, 0, true, 0, reset},
+		{"wrapped primary rate limit", fmt.Errorf("listing: %w", &github.RateLimitError{Rate: github.Rate{Reset: github.Timestamp{Time: reset}}}), 0, true, 0, reset},
+		{"secondary rate limit", &github.AbuseRateLimitError{}, 0, true, secondaryRateLimitBackoff, time.Time{}},
+		{"repeated secondary rate limit", &github.AbuseRateLimitError{}, 2, true, 4 * secondaryRateLimitBackoff, time.Time{}},
+		{"secondary rate limit at the longest backoff", &github.AbuseRateLimitError{}, 10, true, maxSecondaryRateLimitBackoff, time.Time{}},
+		{"secondary rate limit with Retry-After", &github.AbuseRateLimitError{RetryAfter: &retryAfter}, 3, true, retryAfter, time.Time{}},
+		{"429 with Retry-After", &httpError{StatusCode: http.StatusTooManyRequests, RetryAt: reset}, 0, true, 0, reset},
+		{"429 without Retry-After", &httpError{StatusCode: http.StatusTooManyRequests}, 1, true, 2 * secondaryRateLimitBackoff, time.Time{}},
+		{"other status", &httpError{StatusCode: http.StatusInternalServerError}, 0, false, 0, time.Time{}},
+		{"other error", errors.New("connection reset"), 0, false, 0, time.Time{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := &rateLimitGate{}
+			start := time.Now()
+			if got := g.backoff(tt.err, tt.attempt); got != tt.retry {
+				t.Fatalf("got retry %v, want %v", got, tt.retry)
+			}
+			switch {
+			case !tt.until.IsZero():
+				if !g.resumeAt.Equal(tt.until) {
+					t.Errorf("paused until %v, want %v", g.resumeAt, tt.until)
+				}
+			case tt.pause > 0:
+				if d := g.resumeAt.Sub(start); d < tt.pause || d > tt.pause+time.Second {
+					t.Errorf("paused for %v, want %v", d, tt.pause)
+				}
+			default:
+				if !g.resumeAt.IsZero() {
+					t.Errorf("paused until %v, want no pause", g.resumeAt)
+				}
+			}
+		})
+	}
+}
+
+func TestRateLimitGatePauseUntil(t *testing.T) {
+	g := &rateLimitGate{}
+	later := time.Now().Add(time.Hour)
+	g.pauseUntil(later)
+	g.pauseUntil(later.Add(-time.Minute))
+	if !g.resumeAt.Equal(later) {
+		t.Errorf("paused until %v, want the later %v", g.resumeAt, later)
+	}
+	g = &rateLimitGate{}
+	g.pauseUntil(time.Now().Add(-time.Minute))
+	if err := g.wait(context.Background()); err != nil {
+		t.Errorf("waiting for a past pause: %v", err)
+	}
+}
+
+func TestHostLimiterSplit(t *testing.T) {
+	tests := []struct {
+		name        string
+		limit       HostLimit
+		n           int
+		concurrency int
+		rate        rate.Limit
+	}{
+		{"concurrency divided", HostLimit{Concurrency: 10}, 3, 4, rate.Inf},
+		{"at least one request", HostLimit{Concurrency: 2}, 4, 1, rate.Inf},
+		{"rate divided", HostLimit{Concurrency: 10, Limiter: rate.NewLimiter(10, 1)}, 4, 3, 2.5},
+		{"default concurrency", HostLimit{}, 2, DefaultHostConcurrency[gitHubHost] / 2, rate.Inf},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newHostLimiter(gitHubHost, tt.limit, 0)
+			s := h.split(tt.n)
+			if s.gate != h.gate {
+				t.Error("the split limiter has its own rate limit state")
+			}
+			if got := cap(s.slots); got != tt.concurrency {
+				t.Errorf("got concurrency %d, want %d", got, tt.concurrency)
+			}
+			if got := s.limiter.Limit(); got != tt.rate {
+				t.Errorf("got rate %v, want %v", got, tt.rate)
+			}
+		})
+	}
+}
+
+func TestRetryAt(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		name   string
+		header map[string]string
+		want   time.Time
+	}{
+		{"seconds", map[string]string{"Retry-After": "30"}, now.Add(30 * time.Second)},
+		{"date", map[string]string{"Retry-After": "Tue, 02 Jan 2024 03:10:00 GMT"}, time.Date(2024, 1, 2, 3, 10, 0, 0, time.UTC)},
+		{"RateLimit-Reset", map[string]string{"RateLimit-Reset": "1704164700"}, time.Unix(1704164700, 0)},
+		{"Retry-After first", map[string]string{"Retry-After": "30", "RateLimit-Reset": "1704164700"}, now.Add(30 * time.Second)},
+		{"neither", map[string]string{}, time.Time{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := http.Header{}
+			for key, value := range tt.header {
+				h.Set(key, value)
+			}
+			if got := retryAt(h, now); !got.Equal(tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package stats
+
+import (
+	"math"
+	"sort"
+)
+
+// LanguageShare A language's share of a result compared with its share of a reference result, e.g. of the CNCF
+// projects against the popular open source projects listed under Custom in repos.yaml. Shares are in percent.
+type LanguageShare struct {
+	Language string `json:"language"`
+	// Percent, ReferencePercent The language's share of the Totals of each result
+	Percent          float64 `json:"percent"`
+	ReferencePercent float64 `json:"referencePercent"`
+	// Difference Percent minus ReferencePercent in percentage points
+	Difference float64 `json:"difference"`
+	// TopLanguage, ReferenceTopLanguage The share of the projects of each result the language is the top language of
+	TopLanguage          float64 `json:"topLanguage"`
+	ReferenceTopLanguage float64 `json:"referenceTopLanguage"`
+}
+
+// CompareShares Joins the shares of every language of either result. Languages are sorted descending by the larger
+// of their two shares of the totals, then by name.
+func CompareShares(result, reference Result) []LanguageShare {
+	percent := LanguagePercentages(SortLanguageMap(result.Totals()))
+	referencePercent := LanguagePercentages(SortLanguageMap(reference.Totals()))
+	top := LanguagePercentages(SortLanguageMap(result.TopLanguage))
+	referenceTop := LanguagePercentages(SortLanguageMap(reference.TopLanguage))
+
+	languages := make(map[string]bool)
+	for _, m := range []map[string]float64{percent, referencePercent, top, referenceTop} {
+		for lang := range m {
+			languages[lang] = true
+		}
+	}
+	shares := make([]LanguageShare, 0, len(languages))
+	for lang := range languages {
+		shares = append(shares, LanguageShare{
+			Language:             lang,
+			Percent:              percent[lang],
+			ReferencePercent:     referencePercent[lang],
+			Difference:           math.Round((percent[lang]-referencePercent[lang])*100) / 100,
+			TopLanguage:          top[lang],
+			ReferenceTopLanguage: referenceTop[lang],
+		})
+	}
+	sort.Slice(shares, func(i, j int) bool {
+		a := math.Max(shares[i].Percent, shares[i].ReferencePercent)
+		b := math.Max(shares[j].Percent, shares[j].ReferencePercent)
+		if a != b {
+			return a > b
+		}
+		return shares[i].Language < shares[j].Language
+	})
+	return shares
+}
+
+// ShareDistance The total variation distance between the distributions of the shares, the percentage of the totals
+// that would have to move to another language for both to match: 0 when they are the same and 100 when no language
+// is in both
+func ShareDistance(shares []LanguageShare) float64 {
+	var sum float64
+	for _, s := range shares {
+		sum += math.Abs(s.Difference)
+	}
+	return math.Round(sum*50) / 100
+}
@@ -0,0 +1,95 @@
+package stats
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"time"
+)
+
+// releaseWindow The period releases are counted in for the releases per year
+const releaseWindow = 365 * 24 * time.Hour
+
+// repoReleases The releases of a repo in the last year and its latest release
+type repoReleases struct {
+	lastYear int
+	latest   time.Time
+}
+
+// ProjectReleases How actively a project ships, summed across its repos
+type ProjectReleases struct {
+	// PerYear Releases published in the year before the collection
+	PerYear int `json:"perYear"`
+	// LastRelease RFC 3339 time of the latest release of any of the project's repos, empty without releases
+	LastRelease string `json:"lastRelease,omitempty"`
+	// DaysSinceLastRelease Full days from the latest release to the collection, -1 without releases
+	DaysSinceLastRelease int `json:"daysSinceLastRelease"`
+}
+
+// ReleaseCadence How actively the projects of a group ship
+type ReleaseCadence struct {
+	// Projects Projects with at least one release
+	Projects int `json:"projects"`
+	// MeanReleasesPerYear Releases per year averaged across the projects with a release
+	MeanReleasesPerYear float64 `json:"meanReleasesPerYear"`
+	// MedianDaysSinceLastRelease Median of the days since the latest release of the projects with a release
+	MedianDaysSinceLastRelease float64 `json:"medianDaysSinceLastRelease"`
+}
+
+// projectReleases Sums up the releases of a project's repos as of now
+func projectReleases(repos []repoReleases, now time.Time) ProjectReleases {
+	var p ProjectReleases
+	var latest time.Time
+	for _, r := range repos {
+		p.PerYear += r.lastYear
+		if r.latest.After(latest) {
+			latest = r.latest
+		}
+	}
+	p.DaysSinceLastRelease = -1
+	if !latest.IsZero() {
+		p.LastRelease = latest.UTC().Format(time.RFC3339)
+		p.DaysSinceLastRelease = int(now.Sub(latest) / (24 * time.Hour))
+	}
+	return p
+}
+
+// releaseCadence Averages the release stats of the projects that have released, nil when none has
+func releaseCadence(releases map[string]ProjectReleases) *ReleaseCadence {
+	var perYear int
+	var days []int
+	for _, p := range releases {
+		if p.DaysSinceLastRelease < 0 {
+			continue
+		}
+		perYear += p.PerYear
+		days = append(days, p.DaysSinceLastRelease)
+	}
+	if len(days) == 0 {
+		return nil
+	}
+	sort.Ints(days)
+	median := float64(days[len(days)/2])
+	if len(days)%2 == 0 {
+		median = float64(days[len(days)/2-1]+days[len(days)/2]) / 2
+	}
+	return &ReleaseCadence{
+		Projects:                   len(days),
+		MeanReleasesPerYear:        float64(perYear) / float64(len(days)),
+		MedianDaysSinceLastRelease: median,
+	}
+}
+
+// fetchReleases Lists the releases of the last year of the repo. Failures leave the repo out of the release
+// stats with a warning, nil is returned, it fails only when ctx is done.
+func (c *Collector) fetchReleases(ctx context.Context, name string, p provider, ref repoURL) (*repoReleases, error) {
+	r, err := p.releases(ctx, name, ref.owner, ref.repo, time.Now().Add(-releaseWindow))
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		slog.Warn("Listing releases failed", "repo", name, "error", err)
+		return nil, nil
+	}
+	return &r, nil
+}
@@ -0,0 +1,266 @@
+// Package stats Collects the programming languages of CNCF projects from GitHub and aggregates them per maturity group.
+//
+// A Collector fetches the language stats of every project in a group and returns a Result:
+//
+//	collector := stats.NewCollector(stats.Options{Token: os.Getenv("GITHUB_TOKEN")})
+//	repos, err := stats.LoadRepos("repos.yaml")
+//	...
+//	result, err := collector.Collect(ctx, "graduated", repos.Graduated)
+package stats
+
+import (
+	"math"
+	"sort"
+)
+
+// OtherLanguage The language the languages below Options.MinPercent or Options.MinLines are summed up as
+const OtherLanguage = "Other"
+
+// Result The aggregated language stats of a project group
+type Result struct {
+	// SchemaVersion The SchemaVersion the result was written with, older results are upgraded by MigrateResult
+	SchemaVersion int `json:"schemaVersion,omitempty"`
+	// Meta How and when the result was collected, missing in results collected before it was added
+	Meta        *RunMeta       `json:"meta,omitempty"`
+	TopLanguage map[string]int `json:"topLanguage"`
+	// TotalBytes Bytes of each language as reported by GitHub summed across the group's projects,
+	// unless the languages were counted in clones
+	TotalBytes map[string]int `json:"totalBytes,omitempty"`
+	// TotalLines Code lines of each language summed across the group's projects when counted in clones.
+	// Results saved before totalBytes was introduced hold the bytes here until migrated with MigrateResult.
+	TotalLines map[string]int `json:"totalLines,omitempty"`
+	// Percentages Share of each language in the group's Totals, from 0 to 100 rounded to two decimals
+	Percentages   map[string]float64       `json:"percentages,omitempty"`
+	Concentration map[string]Concentration `json:"concentration,omitempty"`
+	Projects      map[string]ProjectResult `json:"projects,omitempty"`
+	// Errors Why projects are missing from the stats, keyed by project name
+	Errors map[string]string `json:"errors,omitempty"`
+	// WeightedTopLanguage Scores of the languages ranked among the top Options.TopN of the projects, the
+	// language ranked n-th in a project scoring 1/n. Only set when TopN is above 1.
+	WeightedTopLanguage map[string]float64 `json:"weightedTopLanguage,omitempty"`
+	// Aggregation The name of the Options.Aggregator that scored the languages in Scores
+	Aggregation string `json:"aggregation,omitempty"`
+	// Scores The scores of the languages summed across the projects, only set with Options.Aggregator
+	Scores map[string]float64 `json:"scores,omitempty"`
+	// LanguagePresence The number of projects containing each language at all, with at least
+	// Options.PresencePercent of their bytes or lines, when counted with Options.LanguagePresence
+	LanguagePresence map[string]int `json:"languagePresence,omitempty"`
+	// ProjectsByLanguage The sorted names of the projects of every top language when listed with
+	// Options.LanguageProjects
+	ProjectsByLanguage map[string][]string `json:"projectsByLanguage,omitempty"`
+	// ProjectsContaining The sorted names of the projects counted in LanguagePresence for every language
+	ProjectsContaining map[string][]string `json:"projectsContaining,omitempty"`
+	// Normalized Every language's stats relative to the number of projects, which compare across groups of
+	// different sizes, when computed with Options.Normalize
+	Normalized map[string]NormalizedLanguage `json:"normalized,omitempty"`
+	// Metadata The activity and license of every project when collected with Options.Metadata
+	Metadata map[string]Metadata `json:"metadata,omitempty"`
+	// Licenses The number of projects of every SPDX license identifier when counted with Options.Licenses
+	Licenses map[string]int `json:"licenses,omitempty"`
+	// Contributors The contributors of every project summed across its repos when counted with Options.Contributors
+	Contributors map[string]int `json:"contributors,omitempty"`
+	// TotalContributors The contributors summed across the group's projects
+	TotalContributors int `json:"totalContributors,omitempty"`
+	// Commits The commits of the last 52 weeks of every project summed across its repos when counted with
+	// Options.CommitActivity
+	Commits map[string]int `json:"commits,omitempty"`
+	// CommitsByLanguage The commits of the last 52 weeks summed across the projects of each top language
+	CommitsByLanguage map[string]int `json:"commitsByLanguage,omitempty"`
+	// GoVersions The Go version declared by the go.mod of every Go project when fetched with Options.GoModules,
+	// that of the project's first repo declaring one
+	GoVersions map[string]string `json:"goVersions,omitempty"`
+	// GoModules The number of projects directly requiring each module in the go.mod of any of their repos
+	GoModules map[string]int `json:"goModules,omitempty"`
+	// Releases How actively every project ships when listed with Options.Releases
+	Releases map[string]ProjectReleases `json:"releases,omitempty"`
+	// ReleaseCadence The release stats averaged across the projects with a release
+	ReleaseCadence *ReleaseCadence `json:"releaseCadence,omitempty"`
+	// Renamed The current URL of every renamed or transferred repo URL, only detected with Options.CheckRepos
+	Renamed map[string]string `json:"renamed,omitempty"`
+	// Weights The factor every owner/repo was scaled by in the totals, for the repos weighted by Collector.Weights
+	// or capped by Options.MaxRepoSize
+	Weights map[string]float64 `json:"weights,omitempty"`
+	// Archived The sorted names of the archived repos included in the stats
+	Archived []string `json:"archived,omitempty"`
+	// EstimatedBytes The sorted names of the repos whose bytes are estimated from the share of each language and the
+	// size of the whole repository, the GitLab repos unless lines are counted in clones
+	EstimatedBytes []string `json:"estimatedBytes,omitempty"`
+	// Gini Inequality of the Totals across languages, 0 when evenly spread and approaching 1 when one language dominates
+	Gini float64 `json:"gini"`
+}
+
+// RunMeta How and when a result was collected, for auditing the quality of past results
+type RunMeta struct {
+	// CollectedAt RFC 3339 time the collection finished, the latest of the groups of a combined result
+	CollectedAt string `json:"collectedAt"`
+	// BuildInfo The build of the tool that collected the result, Options.Build
+	BuildInfo
+	// Projects The projects of the group, including those skipped or failed
+	Projects int `json:"projects"`
+	// Skipped The projects left out because none of their repos contains any language stats
+	Skipped int `json:"skipped"`
+	// Failed The projects left out because their stats could not be collected, listed in Result.Errors
+	Failed int `json:"failed"`
+	// API How the languages were collected, "rest", "graphql" or "clone"
+	API string `json:"api"`
+	// Requests The API requests sent including retries, also those answered from the cache
+	Requests int `json:"requests"`
+	// MaxLanguagesPerRepo The Options.MaxLanguagesPerRepo the languages of every repo were capped at, 0 when not
+	MaxLanguagesPerRepo int `json:"maxLanguagesPerRepo,omitempty"`
+	// CappedRepos The repos with more languages than MaxLanguagesPerRepo, whose smallest ones are left out
+	CappedRepos int `json:"cappedRepos,omitempty"`
+}
+
+// BuildInfo Identifies a build of the tool, so that result files can be traced to the code producing them
+type BuildInfo struct {
+	// Version The release version, or "devel"
+	Version string `json:"version,omitempty"`
+	// Commit The git commit built, suffixed with "-dirty" when it had uncommitted changes
+	Commit string `json:"commit,omitempty"`
+	// BuildDate RFC 3339 time of the build, or of the commit when only that is known
+	BuildDate string `json:"buildDate,omitempty"`
+}
+
+// NormalizedLanguage A language's stats relative to the number of projects of the group
+type NormalizedLanguage struct {
+	// Projects The projects containing the language at all
+	Projects int `json:"projects"`
+	// ProjectShare Percentage of the group's projects containing the language, from 0 to 100 rounded to two decimals
+	ProjectShare float64 `json:"projectShare"`
+	// TopLanguageShare Percentage of the group's projects with the language as their top language
+	TopLanguageShare float64 `json:"topLanguageShare"`
+	// PerProject The language's Totals divided by all of the group's projects, rounded
+	PerProject int `json:"perProject"`
+}
+
+// NoLicense Counts the projects in Result.Licenses whose repos have no license GitHub or GitLab recognizes.
+// GitHub reports unrecognized license files as NOASSERTION, which is counted as is.
+const NoLicense = "none"
+
+// Metadata The activity and license of a repo, or of a project summed across its repos. License and
+// DefaultBranch are those of the project's first repo and PushedAt is the latest push to any of them.
+type Metadata struct {
+	Stars int `json:"stars"`
+	Forks int `json:"forks"`
+	// OpenIssues Open issues including pull requests
+	OpenIssues int `json:"openIssues"`
+	// License SPDX identifier of the license, e.g. Apache-2.0
+	License       string `json:"license,omitempty"`
+	DefaultBranch string `json:"defaultBranch,omitempty"`
+	// PushedAt RFC 3339 time of the last push
+	PushedAt string `json:"pushedAt,omitempty"`
+}
+
+// add Adds the repo's counts to the project's and takes its last push when later, the first repo's license
+// and default branch are kept
+func (m *Metadata) add(repo Metadata) {
+	m.Stars += repo.Stars
+	m.Forks += repo.Forks
+	m.OpenIssues += repo.OpenIssues
+	if m.License == "" && m.DefaultBranch == "" {
+		m.License, m.DefaultBranch = repo.License, repo.DefaultBranch
+	}
+	// RFC 3339 times in UTC sort as strings
+	if repo.PushedAt > m.PushedAt {
+		m.PushedAt = repo.PushedAt
+	}
+}
+
+// ProjectResult Per project details included in detailed output
+type ProjectResult struct {
+	URL string `json:"url"`
+	// Repos The owner/repo names of the repos aggregated into the project when there are several
+	Repos       []string `json:"repos,omitempty"`
+	TopLanguage string   `json:"topLanguage"`
+	// TopLanguages The project's top Options.TopN languages, largest first, when TopN is above 1
+	TopLanguages []string `json:"topLanguages,omitempty"`
+	MaturityDate string   `json:"maturityDate,omitempty"`
+	// Languages Bytes, or code lines when counted in clones, of each language summed across the project's repos
+	Languages map[string]int `json:"languages,omitempty"`
+	// Percentages Share of each language in the project's bytes, from 0 to 100 rounded to two decimals
+	Percentages map[string]float64 `json:"percentages,omitempty"`
+}
+
+// Totals The size of each language the group is measured by, TotalLines when set and TotalBytes otherwise
+func (r Result) Totals() map[string]int {
+	if r.TotalLines != nil {
+		return r.TotalLines
+	}
+	return r.TotalBytes
+}
+
+// Unit The unit of the Totals, "lines" or "bytes"
+func (r Result) Unit() string {
+	if r.TotalLines != nil {
+		return "lines"
+	}
+	return "bytes"
+}
+
+// Concentration The project contributing the most bytes of a language and its share of the language's total
+type Concentration struct {
+	Project string  `json:"project"`
+	Share   float64 `json:"share"`
+	lines   int
+}
+
+type LanguageLines struct {
+	Language string
+	Lines    int
+}
+
+// LanguageLinesList A slice of LanguageLinesList that implements sort.Interface to sort by values
+type LanguageLinesList []LanguageLines
+
+func (l LanguageLinesList) Len() int           { return len(l) }
+func (l LanguageLinesList) Swap(i, j int)      { l[i], l[j] = l[j], l[i] }
+func (l LanguageLinesList) Less(i, j int) bool { return l[i].Lines < l[j].Lines }
+
+// SortLanguageMap Converts a map of languages to values into a list sorted descending by value, languages of the
+// same value by name so that the order does not depend on the map's
+func SortLanguageMap(repoLanguages map[string]int) LanguageLinesList {
+	l := make(LanguageLinesList, len(repoLanguages))
+	var i int
+	for lang, lines := range repoLanguages {
+		l[i] = LanguageLines{Language: lang, Lines: lines}
+		i++
+	}
+	sortLanguages(l)
+	return l
+}
+
+// sortLanguages Sorts the list descending by number of lines, then by language
+func sortLanguages(l LanguageLinesList) {
+	sort.Slice(l, func(i, j int) bool {
+		if l[i].Lines != l[j].Lines {
+			return l[i].Lines > l[j].Lines
+		}
+		return l[i].Language < l[j].Language
+	})
+}
+
+// TopLanguageMap Maps each project to its top language
+func TopLanguageMap(result Result) map[string]string {
+	m := make(map[string]string, len(result.Projects))
+	for name, p := range result.Projects {
+		m[name] = p.TopLanguage
+	}
+	return m
+}
+
+// LanguagePercentages Computes each language's share of the list's total as a percentage rounded to two decimals
+func LanguagePercentages(l LanguageLinesList) map[string]float64 {
+	var total int
+	for _, language := range l {
+		total += language.Lines
+	}
+	m := make(map[string]float64, len(l))
+	for _, language := range l {
+		if total > 0 {
+			m[language.Language] = math.Round(float64(language.Lines)*10000/float64(total)) / 100
+		} else {
+			m[language.Language] = 0
+		}
+	}
+	return m
+}
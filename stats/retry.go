@@ -0,0 +1,66 @@
+package stats
+
+import (
+	"context"
+	"errors"
+	"github.com/google/go-github/v47/github"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+// Retry Configures how API requests failing with transient errors, 5xx responses and network errors, are retried
+type Retry struct {
+	// MaxAttempts Attempts per request including the first one, a single attempt when not positive
+	MaxAttempts int
+	// Backoff Wait before the first retry, doubled for every further retry
+	Backoff time.Duration
+	// Jitter Randomizes every wait by up to this fraction of it, e.g. 0.2 waits between 80% and 120%
+	Jitter float64
+}
+
+// delay The wait before the retry following the failed attempt, counting from 1
+func (r Retry) delay(attempt int) time.Duration {
+	d := r.Backoff << (attempt - 1)
+	if d < 0 {
+		d = r.Backoff
+	}
+	if r.Jitter > 0 {
+		d += time.Duration(float64(d) * r.Jitter * (2*rand.Float64() - 1))
+	}
+	return d
+}
+
+// isTransient Reports whether a failed request may succeed when sent again
+func isTransient(err error) bool {
+	if errors.Is(err, ErrNoFixture) {
+		return false
+	}
+	var accepted *github.AcceptedError
+	if errors.As(err, &accepted) {
+		// Retried by the callers expecting it, see computingRetry
+		return false
+	}
+	var errResp *github.ErrorResponse
+	if errors.As(err, &errResp) {
+		return errResp.Response != nil && errResp.Response.StatusCode >= 500
+	}
+	var httpErr *httpError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode >= 500 || httpErr.StatusCode == http.StatusTooManyRequests
+	}
+	// Anything but an error response from an API failed on the way, e.g. a reset connection or a timeout
+	return !errors.Is(err, context.Canceled)
+}
+
+// sleep Waits for d or until ctx is cancelled
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
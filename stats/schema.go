@@ -0,0 +1,60 @@
+package stats
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// SchemaVersion The version of the result JSON written by this version, saved as Result.SchemaVersion.
+// It is raised whenever a field changes meaning or is removed, adding a field keeps the version.
+//
+// Version 1 results carry no schemaVersion. The first of them, saved before totalBytes and gini were
+// introduced, hold GitHub's bytes in totalLines. Version 2 added schemaVersion.
+const SchemaVersion = 2
+
+// migrations Upgrade a result of version i+1 to version i+2, given the top level fields of its JSON
+var migrations = []func(r *Result, fields map[string]json.RawMessage){
+	migrateV1,
+}
+
+// MigrateResult Decodes a result of any schema version up to SchemaVersion and upgrades it to SchemaVersion.
+// It also returns the version the result had.
+func MigrateResult(data []byte) (Result, int, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return Result{}, 0, err
+	}
+	var r Result
+	if err := json.Unmarshal(data, &r); err != nil {
+		return Result{}, 0, err
+	}
+	version := r.SchemaVersion
+	if version == 0 {
+		version = 1
+	}
+	if version > SchemaVersion {
+		return Result{}, version, fmt.Errorf("schema version %d is newer than version %d, which this version reads", version, SchemaVersion)
+	}
+	for v := version; v < SchemaVersion; v++ {
+		migrations[v-1](&r, fields)
+	}
+	r.SchemaVersion = SchemaVersion
+	return r, version, nil
+}
+
+// migrateV1 Moves the bytes of results saved before totalBytes into totalBytes and computes their
+// percentages and Gini coefficient, which they lack
+func migrateV1(r *Result, fields map[string]json.RawMessage) {
+	_, hasBytes := fields["totalBytes"]
+	_, hasGini := fields["gini"]
+	if hasBytes || hasGini {
+		return
+	}
+	if r.TotalLines != nil {
+		r.TotalBytes, r.TotalLines = r.TotalLines, nil
+	}
+	if r.Percentages == nil {
+		r.Percentages = LanguagePercentages(SortLanguageMap(r.Totals()))
+	}
+	r.Gini = Gini(r.Totals())
+}
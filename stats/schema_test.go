@@ -0,0 +1,75 @@
+package stats
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMigrateResult(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    Result
+		version int
+		err     bool
+	}{
+		{
+			name:    "version 1 with the bytes in totalLines",
+			data:    `{"topLanguage":{"Go":1},"totalLines":{"Go":300,"Shell":100}}`,
+			want:    Result{SchemaVersion: SchemaVersion, TopLanguage: map[string]int{"Go": 1}, TotalBytes: map[string]int{"Go": 300, "Shell": 100}, Percentages: map[string]float64{"Go": 75, "Shell": 25}, Gini: 0.25},
+			version: 1,
+		},
+		{
+			name:    "version 1 with totalBytes",
+			data:    `{"topLanguage":{"Go":1},"totalBytes":{"Go":300},"gini":0}`,
+			want:    Result{SchemaVersion: SchemaVersion, TopLanguage: map[string]int{"Go": 1}, TotalBytes: map[string]int{"Go": 300}},
+			version: 1,
+		},
+		{
+			name:    "version 1 with lines and gini",
+			data:    `{"topLanguage":{"Go":1},"totalLines":{"Go":300},"gini":0}`,
+			want:    Result{SchemaVersion: SchemaVersion, TopLanguage: map[string]int{"Go": 1}, TotalLines: map[string]int{"Go": 300}},
+			version: 1,
+		},
+		{
+			name:    "current version",
+			data:    `{"schemaVersion":2,"topLanguage":{"Go":1},"totalLines":{"Go":300},"gini":0}`,
+			want:    Result{SchemaVersion: SchemaVersion, TopLanguage: map[string]int{"Go": 1}, TotalLines: map[string]int{"Go": 300}},
+			version: 2,
+		},
+		{
+			name:    "newer version",
+			data:    `{"schemaVersion":99,"topLanguage":{}}`,
+			version: 99,
+			err:     true,
+		},
+		{
+			name: "not JSON",
+			data: `{`,
+			err:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, version, err := MigrateResult([]byte(tt.data))
+			if tt.err {
+				if err == nil {
+					t.Fatal("got no error")
+				}
+				if version != tt.version {
+					t.Errorf("got version %d, want %d", version, tt.version)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if version != tt.version {
+				t.Errorf("got version %d, want %d", version, tt.version)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
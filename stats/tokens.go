@@ -0,0 +1,148 @@
+package stats
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// tokenPool Authenticates requests with one of several tokens, switching to the next token once GitHub
+// reports the rate limit of the current one as exhausted. A request rejected by the rate limit is sent again
+// with the next token. Towards the GitHub client the pool looks like a single token that is only exhausted
+// when all are, resetting when the first of them resets, so that requests wait only then.
+type tokenPool struct {
+	base   http.RoundTripper
+	mu     sync.Mutex
+	tokens []string
+	// resets When the rate limit of each token resets, zero while it has requests left
+	resets []time.Time
+	// remaining The requests each token had left after its last response, -1 when unknown
+	remaining []int
+	current   int
+}
+
+func newTokenPool(tokens []string, base http.RoundTripper) *tokenPool {
+	p := &tokenPool{
+		base:      base,
+		tokens:    tokens,
+		resets:    make([]time.Time, len(tokens)),
+		remaining: make([]int, len(tokens)),
+	}
+	for i := range p.remaining {
+		p.remaining[i] = -1
+	}
+	return p
+}
+
+func (p *tokenPool) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 1; ; attempt++ {
+		i := p.pick()
+		r := req.Clone(req.Context())
+		r.Header.Set("Authorization", "Bearer "+p.tokens[i])
+		if req.Body != nil && req.GetBody != nil && attempt > 1 {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			r.Body = body
+		}
+		resp, err := p.base.RoundTrip(r)
+		if err != nil {
+			return resp, err
+		}
+		if !p.observe(i, resp) {
+			return resp, nil
+		}
+
+		rejected := resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusTooManyRequests
+		next, ok := p.available()
+		if !ok {
+			// Every token is exhausted, report when the first one resets
+			resp.Header.Set("X-RateLimit-Reset", strconv.FormatInt(p.firstReset().Unix(), 10))
+			return resp, nil
+		}
+		if !rejected || attempt == len(p.tokens) || req.Body != nil && req.GetBody == nil {
+			// Hide the exhausted token, the next request goes out with another one
+			resp.Header.Set("X-RateLimit-Remaining", strconv.Itoa(next))
+			return resp, nil
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+}
+
+// observe Records the rate limit the response reports for the token and whether it is exhausted
+func (p *tokenPool) observe(i int, resp *http.Response) bool {
+	remaining, err := strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return false
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.remaining[i] = remaining
+	if remaining > 0 {
+		return false
+	}
+	if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		p.resets[i] = time.Unix(reset, 0)
+	} else {
+		p.resets[i] = time.Now().Add(time.Hour)
+	}
+	return true
+}
+
+// pick The index of the token to send the next request with, the current token unless it is exhausted.
+// When every token is exhausted it is the one resetting first.
+func (p *tokenPool) pick() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := time.Now()
+	for n := 0; n < len(p.tokens); n++ {
+		i := (p.current + n) % len(p.tokens)
+		if !p.resets[i].After(now) {
+			if i != p.current {
+				slog.Info("Switching GitHub tokens", "token", i+1, "tokens", len(p.tokens))
+				p.current = i
+			}
+			return i
+		}
+	}
+	return p.first()
+}
+
+// available Reports whether a token has requests left and how many, 1 when unknown
+func (p *tokenPool) available() (int, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := time.Now()
+	for i, reset := range p.resets {
+		if !reset.After(now) {
+			if p.remaining[i] > 0 {
+				return p.remaining[i], true
+			}
+			return 1, true
+		}
+	}
+	return 0, false
+}
+
+// first The index of the token whose rate limit resets first, p.mu must be held
+func (p *tokenPool) first() int {
+	first := 0
+	for i, reset := range p.resets {
+		if reset.Before(p.resets[first]) {
+			first = i
+		}
+	}
+	return first
+}
+
+// firstReset When the first token has requests again
+func (p *tokenPool) firstReset() time.Time {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.resets[p.first()]
+}
@@ -0,0 +1,111 @@
+package stats
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeRateLimits Answers requests reporting the remaining requests of every token, rejecting those of exhausted
+// tokens with 403 Forbidden, and records the token of every request
+type fakeRateLimits struct {
+	remaining map[string]int
+	reset     time.Time
+	sent      []string
+}
+
+func (f *fakeRateLimits) RoundTrip(req *http.Request) (*http.Response, error) {
+	token := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+	f.sent = append(f.sent, token)
+	status := http.StatusOK
+	if f.remaining[token] == 0 {
+		status = http.StatusForbidden
+	} else {
+		f.remaining[token]--
+	}
+	h := http.Header{}
+	h.Set("X-RateLimit-Remaining", strconv.Itoa(f.remaining[token]))
+	h.Set("X-RateLimit-Reset", strconv.FormatInt(f.reset.Unix(), 10))
+	return &http.Response{StatusCode: status, Header: h, Body: io.NopCloser(strings.NewReader("{}")), Request: req}, nil
+}
+
+func TestTokenPool(t *testing.T) {
+	reset := time.Now().Add(time.Hour).Truncate(time.Second)
+	tests := []struct {
+		name      string
+		remaining map[string]int
+		requests  int
+		// sent The tokens the requests went out with, including those sent again
+		sent []string
+		// status, remaining, reset The last response the client sees
+		status        int
+		lastRemaining string
+		lastReset     string
+	}{
+		{
+			name:          "stays on a token with requests left",
+			remaining:     map[string]int{"a": 5, "b": 5},
+			requests:      3,
+			sent:          []string{"a", "a", "a"},
+			status:        http.StatusOK,
+			lastRemaining: "2",
+		},
+		{
+			name:          "switches once a token is used up",
+			remaining:     map[string]int{"a": 2, "b": 5},
+			requests:      3,
+			sent:          []string{"a", "a", "b"},
+			status:        http.StatusOK,
+			lastRemaining: "4",
+		},
+		{
+			name:          "sends a rejected request again with the next token",
+			remaining:     map[string]int{"a": 0, "b": 5},
+			requests:      1,
+			sent:          []string{"a", "b"},
+			status:        http.StatusOK,
+			lastRemaining: "4",
+		},
+		{
+			name:          "reports the first reset once every token is used up",
+			remaining:     map[string]int{"a": 0, "b": 0},
+			requests:      1,
+			sent:          []string{"a", "b"},
+			status:        http.StatusForbidden,
+			lastRemaining: "0",
+			lastReset:     strconv.FormatInt(reset.Unix(), 10),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeRateLimits{remaining: tt.remaining, reset: reset}
+			pool := newTokenPool([]string{"a", "b"}, fake)
+			var resp *http.Response
+			for i := 0; i < tt.requests; i++ {
+				req, err := http.NewRequest(http.MethodGet, "https://api.github.com/rate_limit", nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if resp, err = pool.RoundTrip(req); err != nil {
+					t.Fatal(err)
+				}
+				resp.Body.Close()
+			}
+			if strings.Join(fake.sent, ",") != strings.Join(tt.sent, ",") {
+				t.Errorf("sent with %v, want %v", fake.sent, tt.sent)
+			}
+			if resp.StatusCode != tt.status {
+				t.Errorf("got status %d, want %d", resp.StatusCode, tt.status)
+			}
+			if got := resp.Header.Get("X-RateLimit-Remaining"); got != tt.lastRemaining {
+				t.Errorf("got %s requests remaining, want %s", got, tt.lastRemaining)
+			}
+			if got := resp.Header.Get("X-RateLimit-Reset"); tt.lastReset != "" && got != tt.lastReset {
+				t.Errorf("got reset %s, want %s", got, tt.lastReset)
+			}
+		})
+	}
+}
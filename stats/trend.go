@@ -0,0 +1,60 @@
+package stats
+
+import "sort"
+
+// TrendPoint A language's stats in a single run
+type TrendPoint struct {
+	Date        string `json:"date"`
+	TopLanguage int    `json:"topLanguage"`
+	// TotalLines The language's Result.Totals in the run, bytes unless counted in clones
+	TotalLines int `json:"totalLines"`
+}
+
+// LanguageTrend The time series of a language's stats across runs. Runs the language is missing from count as 0.
+type LanguageTrend struct {
+	Language string       `json:"language"`
+	Points   []TrendPoint `json:"points"`
+	// TopLanguageChange Change of the top language count from the first to the last run
+	TopLanguageChange int `json:"topLanguageChange"`
+	// TotalLinesChange Change of the total lines from the first to the last run
+	TotalLinesChange int `json:"totalLinesChange"`
+}
+
+// Trend Builds the time series of every language from results ordered oldest first, dates[i] being the date
+// of results[i]. The trends are sorted descending by the total lines of the last run, then by language.
+func Trend(dates []string, results []Result) []LanguageTrend {
+	languages := make(map[string]bool)
+	for _, r := range results {
+		for lang := range r.TopLanguage {
+			languages[lang] = true
+		}
+		for lang := range r.Totals() {
+			languages[lang] = true
+		}
+	}
+
+	trends := make([]LanguageTrend, 0, len(languages))
+	for lang := range languages {
+		t := LanguageTrend{Language: lang, Points: make([]TrendPoint, len(results))}
+		for i, r := range results {
+			t.Points[i] = TrendPoint{Date: dates[i], TopLanguage: r.TopLanguage[lang], TotalLines: r.Totals()[lang]}
+		}
+		if n := len(t.Points); n > 0 {
+			t.TopLanguageChange = t.Points[n-1].TopLanguage - t.Points[0].TopLanguage
+			t.TotalLinesChange = t.Points[n-1].TotalLines - t.Points[0].TotalLines
+		}
+		trends = append(trends, t)
+	}
+
+	last := len(results) - 1
+	sort.Slice(trends, func(i, j int) bool {
+		if last >= 0 {
+			a, b := trends[i].Points[last].TotalLines, trends[j].Points[last].TotalLines
+			if a != b {
+				return a > b
+			}
+		}
+		return trends[i].Language < trends[j].Language
+	})
+	return trends
+}
@@ -0,0 +1,136 @@
+package stats
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Validate Checks the invariants every result must satisfy and reports each violated one
+func Validate(result Result) error {
+	var violations []string
+	fail := func(format string, a ...interface{}) {
+		violations = append(violations, fmt.Sprintf(format, a...))
+	}
+
+	totals, key := result.Totals(), "totalBytes"
+	if result.TotalLines != nil {
+		key = "totalLines"
+	}
+	if result.TotalBytes != nil && result.TotalLines != nil {
+		fail("both totalBytes and totalLines are set, only one unit may be used")
+	}
+	var projects int
+	for lang, count := range result.TopLanguage {
+		if count < 0 {
+			fail("topLanguage[%s] is %d, counts must not be negative", lang, count)
+		}
+		if _, ok := totals[lang]; !ok {
+			fail("topLanguage[%s] has no %s entry", lang, key)
+		}
+		projects += count
+	}
+	for lang, v := range totals {
+		if v < 0 {
+			fail("%s[%s] is %d, %s must not be negative", key, lang, v, result.Unit())
+		}
+	}
+	for lang, pct := range result.Percentages {
+		if math.IsNaN(pct) || pct < 0 || pct > 100 {
+			fail("percentages[%s] is %v, must be within [0, 100]", lang, pct)
+		}
+		if _, ok := totals[lang]; !ok {
+			fail("percentages[%s] has no %s entry", lang, key)
+		}
+	}
+
+	if result.Projects != nil {
+		if projects != len(result.Projects) {
+			fail("topLanguage counts sum to %d but there are %d projects", projects, len(result.Projects))
+		}
+		tops := make(map[string]int)
+		for name, p := range result.Projects {
+			tops[p.TopLanguage]++
+			for lang, pct := range p.Percentages {
+				if math.IsNaN(pct) || pct < 0 || pct > 100 {
+					fail("projects[%s].percentages[%s] is %v, must be within [0, 100]", name, lang, pct)
+				}
+			}
+		}
+		for lang, count := range tops {
+			if result.TopLanguage[lang] != count {
+				fail("topLanguage[%s] is %d but it is the top language of %d projects", lang, result.TopLanguage[lang], count)
+			}
+		}
+	}
+
+	for lang, score := range result.WeightedTopLanguage {
+		if math.IsNaN(score) || score < 0 {
+			fail("weightedTopLanguage[%s] is %v, must not be negative", lang, score)
+		}
+	}
+
+	for lang, n := range result.LanguagePresence {
+		if n < 0 {
+			fail("languagePresence[%s] is %d, counts must not be negative", lang, n)
+		}
+		if n > projects {
+			fail("languagePresence[%s] is %d but there are %d projects", lang, n, projects)
+		}
+	}
+
+	for lang, names := range result.ProjectsByLanguage {
+		if len(names) != result.TopLanguage[lang] {
+			fail("projectsByLanguage[%s] lists %d projects but topLanguage[%s] is %d", lang, len(names), lang, result.TopLanguage[lang])
+		}
+	}
+	for lang, names := range result.ProjectsContaining {
+		if len(names) != result.LanguagePresence[lang] {
+			fail("projectsContaining[%s] lists %d projects but languagePresence[%s] is %d", lang, len(names), lang, result.LanguagePresence[lang])
+		}
+	}
+
+	for lang, n := range result.Normalized {
+		if n.Projects < 0 || n.PerProject < 0 {
+			fail("normalized[%s] has negative counts", lang)
+		}
+		for _, share := range []float64{n.ProjectShare, n.TopLanguageShare} {
+			if math.IsNaN(share) || share < 0 || share > 100 {
+				fail("normalized[%s] has the share %v, must be within [0, 100]", lang, share)
+			}
+		}
+		if _, ok := totals[lang]; !ok {
+			fail("normalized[%s] has no %s entry", lang, key)
+		}
+	}
+
+	for lang, c := range result.Concentration {
+		if math.IsNaN(c.Share) || c.Share < 0 || c.Share > 1 {
+			fail("concentration[%s].share is %v, must be within [0, 1]", lang, c.Share)
+		}
+		if _, ok := totals[lang]; !ok {
+			fail("concentration[%s] has no %s entry", lang, key)
+		}
+	}
+	if m := result.Meta; m != nil {
+		if m.Failed != len(result.Errors) {
+			fail("meta.failed is %d, must be the %d errors", m.Failed, len(result.Errors))
+		}
+		if m.Skipped < 0 || m.Failed < 0 || m.Skipped+m.Failed > m.Projects {
+			fail("meta counts %d skipped and %d failed of %d projects", m.Skipped, m.Failed, m.Projects)
+		}
+	}
+	if result.SchemaVersion > SchemaVersion {
+		fail("schemaVersion is %d, must be at most %d", result.SchemaVersion, SchemaVersion)
+	}
+	if math.IsNaN(result.Gini) || result.Gini < 0 || result.Gini > 1 {
+		fail("gini is %v, must be within [0, 1]", result.Gini)
+	}
+
+	if len(violations) > 0 {
+		sort.Strings(violations)
+		return fmt.Errorf("invalid result: %s", strings.Join(violations, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"cncf-language-stats/stats"
+	"database/sql"
+	"fmt"
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+	"log/slog"
+	"sort"
+	"strings"
+)
+
+// resultStore Saves the stats of every run to a database alongside the result files
+type resultStore interface {
+	// Save Saves the result of the group collected on date, replacing the rows of an earlier save
+	Save(date, repoGroup string, result stats.Result) error
+	Close() error
+}
+
+// openStore Opens the store described by spec, "sqlite:<path>", "postgres:<dsn>" or a postgres:// URL
+func openStore(spec string) (resultStore, error) {
+	driver, dsn, _ := strings.Cut(spec, ":")
+	switch {
+	case strings.HasPrefix(spec, "postgres://") || strings.HasPrefix(spec, "postgresql://"):
+		return openSQLStore("postgres", spec, postgresDialect)
+	case driver == "postgres" && dsn != "":
+		return openSQLStore("postgres", dsn, postgresDialect)
+	case driver == "sqlite" && dsn != "":
+		return openSQLStore("sqlite3", dsn, sqliteDialect)
+	}
+	return nil, fmt.Errorf("invalid store %q, must be sqlite:<path>, postgres:<dsn> or a postgres:// URL", spec)
+}
+
+// sqlDialect The statements of a database's SQL dialect
+type sqlDialect struct {
+	// migrations Changes the schema, migrations[i] migrating from version i to i+1.
+	// Released migrations must not change, changes to the schema are appended.
+	migrations []string
+	// insertMigration Records a migrated version in schema_migrations
+	insertMigration string
+	// upsertGroupLanguage Inserts or replaces the stats of a language in a group on a date and their unit
+	upsertGroupLanguage string
+	// upsertProjectLanguage Inserts or replaces the bytes or lines of a language in a project on a date and their unit
+	upsertProjectLanguage string
+	// deleteGroupLanguages, deleteProjectLanguages Delete the rows of a group on a date, so that the languages and
+	// projects a later save of the date no longer has do not linger
+	deleteGroupLanguages, deleteProjectLanguages string
+}
+
+var sqliteDialect = sqlDialect{
+	migrations: []string{
+		`CREATE TABLE group_languages (
+			date TEXT NOT NULL,
+			repo_group TEXT NOT NULL,
+			language TEXT NOT NULL,
+			top_language_count INTEGER NOT NULL,
+			total_lines INTEGER NOT NULL,
+			PRIMARY KEY (date, repo_group, language)
+		)`,
+		`CREATE TABLE project_languages (
+			date TEXT NOT NULL,
+			repo_group TEXT NOT NULL,
+			project TEXT NOT NULL,
+			language TEXT NOT NULL,
+			bytes INTEGER NOT NULL,
+			PRIMARY KEY (date, repo_group, project, language)
+		)`,
+		`ALTER TABLE group_languages ADD COLUMN unit TEXT NOT NULL DEFAULT 'bytes'`,
+		`ALTER TABLE project_languages ADD COLUMN unit TEXT NOT NULL DEFAULT 'bytes'`,
+	},
+	insertMigration: `INSERT INTO schema_migrations (version) VALUES (?)`,
+	upsertGroupLanguage: `INSERT INTO group_languages (date, repo_group, language, top_language_count, total_lines, unit)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (date, repo_group, language) DO UPDATE SET
+			top_language_count = excluded.top_language_count, total_lines = excluded.total_lines, unit = excluded.unit`,
+	upsertProjectLanguage: `INSERT INTO project_languages (date, repo_group, project, language, bytes, unit)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (date, repo_group, project, language) DO UPDATE SET bytes = excluded.bytes, unit = excluded.unit`,
+	deleteGroupLanguages:   `DELETE FROM group_languages WHERE date = ? AND repo_group = ?`,
+	deleteProjectLanguages: `DELETE FROM project_languages WHERE date = ? AND repo_group = ?`,
+}
+
+var postgresDialect = sqlDialect{
+	migrations: []string{
+		`CREATE TABLE group_languages (
+			date DATE NOT NULL,
+			repo_group TEXT NOT NULL,
+			language TEXT NOT NULL,
+			top_language_count INTEGER NOT NULL,
+			total_lines BIGINT NOT NULL,
+			PRIMARY KEY (date, repo_group, language)
+		)`,
+		`CREATE TABLE project_languages (
+			date DATE NOT NULL,
+			repo_group TEXT NOT NULL,
+			project TEXT NOT NULL,
+			language TEXT NOT NULL,
+			bytes BIGINT NOT NULL,
+			PRIMARY KEY (date, repo_group, project, language)
+		)`,
+		`ALTER TABLE group_languages ADD COLUMN unit TEXT NOT NULL DEFAULT 'bytes'`,
+		`ALTER TABLE project_languages ADD COLUMN unit TEXT NOT NULL DEFAULT 'bytes'`,
+	},
+	insertMigration: `INSERT INTO schema_migrations (version) VALUES ($1)`,
+	upsertGroupLanguage: `INSERT INTO group_languages (date, repo_group, language, top_language_count, total_lines, unit)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (date, repo_group, language) DO UPDATE SET
+			top_language_count = excluded.top_language_count, total_lines = excluded.total_lines, unit = excluded.unit`,
+	upsertProjectLanguage: `INSERT INTO project_languages (date, repo_group, project, language, bytes, unit)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (date, repo_group, project, language) DO UPDATE SET bytes = excluded.bytes, unit = excluded.unit`,
+	deleteGroupLanguages:   `DELETE FROM group_languages WHERE date = $1 AND repo_group = $2`,
+	deleteProjectLanguages: `DELETE FROM project_languages WHERE date = $1 AND repo_group = $2`,
+}
+
+// sqlStore A resultStore writing a row per language of every group and project to a SQL database
+type sqlStore struct {
+	db      *sql.DB
+	dialect sqlDialect
+}
+
+// openSQLStore Connects to the database and migrates its schema to the latest version
+func openSQLStore(driver, dsn string, dialect sqlDialect) (*sqlStore, error) {
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+	s := &sqlStore{db: db, dialect: dialect}
+	if err := s.migrate(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("migrating the %s schema: %w", driver, err)
+	}
+	return s, nil
+}
+
+// migrate Applies the migrations newer than the database's schema version, each in its own transaction
+func (s *sqlStore) migrate() error {
+	if _, err := s.db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (version INTEGER PRIMARY KEY)`); err != nil {
+		return err
+	}
+	var version int
+	if err := s.db.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&version); err != nil {
+		return err
+	}
+	for i := version; i < len(s.dialect.migrations); i++ {
+		tx, err := s.db.Begin()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(s.dialect.migrations[i]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("version %d: %w", i+1, err)
+		}
+		if _, err := tx.Exec(s.dialect.insertMigration, i+1); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+		slog.Info("Migrated the store", "schemaVersion", i+1)
+	}
+	return nil
+}
+
+func (s *sqlStore) Save(date, repoGroup string, result stats.Result) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, query := range []string{s.dialect.deleteGroupLanguages, s.dialect.deleteProjectLanguages} {
+		if _, err := tx.Exec(query, date, repoGroup); err != nil {
+			return fmt.Errorf("replacing %s: %w", repoGroup, err)
+		}
+	}
+	unit := result.Unit()
+	for lang, lines := range result.Totals() {
+		if _, err := tx.Exec(s.dialect.upsertGroupLanguage, date, repoGroup, lang, result.TopLanguage[lang], lines, unit); err != nil {
+			return fmt.Errorf("storing %s: %w", repoGroup, err)
+		}
+	}
+	projects := make([]string, 0, len(result.Projects))
+	for name := range result.Projects {
+		projects = append(projects, name)
+	}
+	sort.Strings(projects)
+	for _, name := range projects {
+		for lang, bytes := range result.Projects[name].Languages {
+			if _, err := tx.Exec(s.dialect.upsertProjectLanguage, date, repoGroup, name, lang, bytes, unit); err != nil {
+				return fmt.Errorf("storing %s project %s: %w", repoGroup, name, err)
+			}
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *sqlStore) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"cncf-language-stats/stats"
+	"encoding/json"
+	"time"
+)
+
+// runSummary The results of every group of a run and their combination in a single document, saved as summary.json
+type runSummary struct {
+	SchemaVersion int `json:"schemaVersion"`
+	// Date The date the results are saved under
+	Date string `json:"date"`
+	// GeneratedAt RFC 3339 time the summary was written
+	GeneratedAt string `json:"generatedAt"`
+	// Groups The collected groups in the order they were processed
+	Groups []summaryGroup `json:"groups"`
+	// All The groups combined, as saved in <date>-all.json with -all
+	All summaryGroup `json:"all"`
+}
+
+// summaryGroup The aggregate of a group with its counts
+type summaryGroup struct {
+	Group string `json:"group"`
+	// Projects The projects counted in the stats
+	Projects int `json:"projects"`
+	// Failed The projects left out because their stats could not be collected
+	Failed    int `json:"failed"`
+	Languages int `json:"languages"`
+	// TopLanguages The sorted languages most projects are written in, several when tied
+	TopLanguages []string `json:"topLanguages"`
+	// Result The result as saved in the group's result file, filtered and with the keys renamed by the resultWriter
+	Result json.RawMessage `json:"result"`
+}
+
+func newSummaryGroup(name string, result stats.Result, out resultWriter) (summaryGroup, error) {
+	result, err := out.filter(name, result)
+	if err != nil {
+		return summaryGroup{}, err
+	}
+	encoded, err := marshalResult(result, out.keyNames)
+	if err != nil {
+		return summaryGroup{}, err
+	}
+	g := summaryGroup{
+		Group:        name,
+		Failed:       len(result.Errors),
+		Languages:    len(result.Totals()),
+		TopLanguages: stats.Leaders(result.TopLanguage),
+		Result:       encoded,
+	}
+	for _, count := range result.TopLanguage {
+		g.Projects += count
+	}
+	if g.TopLanguages == nil {
+		g.TopLanguages = []string{}
+	}
+	return g, nil
+}
+
+// writeSummary Writes the results of the groups and their combination to every path, each result filtered and
+// encoded the way out saves it
+func writeSummary(paths []string, names []string, results []stats.Result, now time.Time, out resultWriter) error {
+	summary := runSummary{
+		SchemaVersion: stats.SchemaVersion,
+		Date:          now.UTC().Format("2006-01-02"),
+		GeneratedAt:   now.UTC().Format(time.RFC3339),
+		Groups:        make([]summaryGroup, len(names)),
+	}
+	var err error
+	if summary.All, err = newSummaryGroup("all", stats.Combine(results), out); err != nil {
+		return err
+	}
+	for i, name := range names {
+		if summary.Groups[i], err = newSummaryGroup(name, results[i], out); err != nil {
+			return err
+		}
+	}
+	b, err := json.MarshalIndent(summary, "", " ")
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		if err := writeFile(path, b, out.fileMode); err != nil {
+			return err
+		}
+	}
+	return nil
+}
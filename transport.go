@@ -0,0 +1,95 @@
+package main
+
+import (
+	"cncf-language-stats/stats"
+	"fmt"
+	"golang.org/x/time/rate"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LoggingTransport Logs the URL, status and remaining rate limit of every request sent through Base
+type LoggingTransport struct {
+	// Base Transport sending the requests, http.DefaultTransport when nil
+	Base http.RoundTripper
+}
+
+func (t LoggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		slog.Info("API request failed", "method", req.Method, "url", req.URL.String(), "error", err)
+		return resp, err
+	}
+	slog.Info("API request", "method", req.Method, "url", req.URL.String(), "status", resp.StatusCode, "rateRemaining", resp.Header.Get("X-RateLimit-Remaining"))
+	return resp, nil
+}
+
+// requestLimiter Paces API requests to at most one per throttle and maxPerHour an hour, the stricter of both,
+// nil when neither is set
+func requestLimiter(throttle time.Duration, maxPerHour int) *rate.Limiter {
+	limit := rate.Inf
+	if throttle > 0 {
+		limit = rate.Every(throttle)
+	}
+	if maxPerHour > 0 {
+		if l := rate.Limit(float64(maxPerHour) / time.Hour.Seconds()); l < limit {
+			limit = l
+		}
+	}
+	if limit == rate.Inf {
+		return nil
+	}
+	return rate.NewLimiter(limit, 1)
+}
+
+// parseHostLimits The limits of every host from -concurrency-per-host and -max-requests-per-hour-per-host.
+// throttle and maxPerHour pace github.com unless -max-requests-per-hour-per-host lists it.
+func parseHostLimits(throttle time.Duration, maxPerHour int, concurrency, maxPerHourPerHost string) (map[string]stats.HostLimit, error) {
+	concurrent, err := parseHostCounts("-concurrency-per-host", concurrency)
+	if err != nil {
+		return nil, err
+	}
+	perHour, err := parseHostCounts("-max-requests-per-hour-per-host", maxPerHourPerHost)
+	if err != nil {
+		return nil, err
+	}
+	limits := make(map[string]stats.HostLimit, len(stats.Hosts))
+	for _, host := range stats.Hosts {
+		limit := stats.HostLimit{Concurrency: concurrent[host]}
+		switch n, ok := perHour[host]; {
+		case ok && host == "github.com":
+			limit.Limiter = requestLimiter(throttle, n)
+		case ok:
+			limit.Limiter = requestLimiter(0, n)
+		case host == "github.com":
+			limit.Limiter = requestLimiter(throttle, maxPerHour)
+		}
+		limits[host] = limit
+	}
+	return limits, nil
+}
+
+// parseHostCounts Parses a comma separated list of host=N with a supported host and a positive N
+func parseHostCounts(name, list string) (map[string]int, error) {
+	counts := make(map[string]int)
+	for _, entry := range splitList(list) {
+		host, value, _ := strings.Cut(entry, "=")
+		if !slices.Contains(stats.Hosts, host) {
+			return nil, fmt.Errorf("invalid %s host %q, must be %s", name, host, strings.Join(stats.Hosts, " or "))
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("invalid %s count %q for %s, must be a positive number", name, value, host)
+		}
+		counts[host] = n
+	}
+	return counts, nil
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"cncf-language-stats/stats"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// trendReport The trend of every language of a group across its saved results
+type trendReport struct {
+	Group     string                `json:"group"`
+	Dates     []string              `json:"dates"`
+	Languages []stats.LanguageTrend `json:"languages"`
+}
+
+// saveTrendReport Builds the trend of the group's saved results and saves it as <date>-<group>-trend.json
+// and a Markdown table as <date>-<group>-trend.md, dated with the newest result
+func saveTrendReport(dir, repoGroup string, perm os.FileMode) error {
+	files, err := groupResultFiles(dir, repoGroup)
+	if err != nil {
+		return err
+	}
+	if len(files) < 2 {
+		slog.Warn("A trend needs at least two results, skipping", "group", repoGroup, "results", len(files), "dir", dir)
+		return nil
+	}
+
+	report := trendReport{Group: repoGroup, Dates: make([]string, len(files))}
+	results := make([]stats.Result, len(files))
+	for i, file := range files {
+		report.Dates[i] = resultFileDate(file)
+		if results[i], err = loadBaseline(file); err != nil {
+			return err
+		}
+	}
+	report.Languages = stats.Trend(report.Dates, results)
+
+	b, err := json.MarshalIndent(report, "", " ")
+	if err != nil {
+		return err
+	}
+	base := filepath.Join(dir, report.Dates[len(report.Dates)-1]+"-"+repoGroup+"-trend")
+	if err := writeFile(base+".json", b, perm); err != nil {
+		return err
+	}
+	slog.Info("Saved the trend", "group", repoGroup, "results", len(files), "path", base+".json")
+	return writeFile(base+".md", []byte(report.markdown()), perm)
+}
+
+// markdown Renders the report as a table comparing the first and last run of every language
+func (r trendReport) markdown() string {
+	first, last := r.Dates[0], r.Dates[len(r.Dates)-1]
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Language trend for %s\n\n", r.Group)
+	fmt.Fprintf(&b, "%d runs from %s to %s.\n\n", len(r.Dates), first, last)
+	fmt.Fprintf(&b, "| Language | Top language %s | Top language %s | Change | Total lines %s | Total lines %s | Change |\n", first, last, first, last)
+	b.WriteString("| --- | ---: | ---: | ---: | ---: | ---: | ---: |\n")
+	for _, t := range r.Languages {
+		p0, p1 := t.Points[0], t.Points[len(t.Points)-1]
+		fmt.Fprintf(&b, "| %s | %d | %d | %+d | %d | %d | %s |\n",
+			t.Language, p0.TopLanguage, p1.TopLanguage, t.TopLanguageChange, p0.TotalLines, p1.TotalLines, percentChange(p0.TotalLines, p1.TotalLines))
+	}
+	return b.String()
+}
+
+// percentChange Formats the relative change from a to b, "new" when a is 0
+func percentChange(a, b int) string {
+	if a == 0 {
+		if b == 0 {
+			return "0%"
+		}
+		return "new"
+	}
+	return fmt.Sprintf("%+.1f%%", float64(b-a)*100/float64(a))
+}
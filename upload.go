@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// uploader Puts files into an S3 bucket or a Cloud Storage bucket through its S3 compatible XML API.
+// Requests are signed with AWS signature version 4.
+type uploader struct {
+	// endpoint URL of the bucket the object keys are appended to
+	endpoint             string
+	prefix               string
+	region               string
+	accessKey, secretKey string
+	sessionToken         string
+	client               *http.Client
+}
+
+// newUploader Creates an uploader for a s3://bucket/prefix or gs://bucket/prefix destination.
+// S3 credentials are read from AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and the optional AWS_SESSION_TOKEN,
+// the region from AWS_REGION and AWS_ENDPOINT_URL replaces the AWS endpoint, e.g. for MinIO.
+// Cloud Storage uses the HMAC key in GCS_ACCESS_KEY_ID and GCS_SECRET_ACCESS_KEY.
+func newUploader(dest string) (*uploader, error) {
+	u, err := url.Parse(dest)
+	if err != nil {
+		return nil, err
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid upload destination %q, must be s3://bucket/prefix or gs://bucket/prefix", dest)
+	}
+	up := &uploader{
+		prefix: strings.Trim(u.Path, "/"),
+		client: &http.Client{Timeout: time.Minute},
+	}
+
+	switch u.Scheme {
+	case "s3":
+		up.accessKey, up.secretKey = os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY")
+		up.sessionToken = os.Getenv("AWS_SESSION_TOKEN")
+		if up.region = os.Getenv("AWS_REGION"); up.region == "" {
+			up.region = "us-east-1"
+		}
+		if endpoint := os.Getenv("AWS_ENDPOINT_URL"); endpoint != "" {
+			up.endpoint = strings.TrimSuffix(endpoint, "/") + "/" + u.Host
+		} else {
+			up.endpoint = "https://" + u.Host + ".s3." + up.region + ".amazonaws.com"
+		}
+	case "gs":
+		up.accessKey, up.secretKey = os.Getenv("GCS_ACCESS_KEY_ID"), os.Getenv("GCS_SECRET_ACCESS_KEY")
+		up.region = "auto"
+		up.endpoint = "https://storage.googleapis.com/" + u.Host
+	default:
+		return nil, fmt.Errorf("invalid upload destination %q, must be s3://bucket/prefix or gs://bucket/prefix", dest)
+	}
+	if up.accessKey == "" || up.secretKey == "" {
+		return nil, fmt.Errorf("uploading to %s needs credentials, see the README", dest)
+	}
+	return up, nil
+}
+
+// Upload Puts the file into the bucket under the prefix followed by the file's base name
+func (u *uploader) Upload(ctx context.Context, file string) error {
+	body, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	return u.put(ctx, filepath.Base(file), body)
+}
+
+// put Puts body into the bucket under the prefix followed by name
+func (u *uploader) put(ctx context.Context, name string, body []byte) error {
+	key := path.Join(u.prefix, name)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, u.endpoint+"/"+escapeKey(key), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	if contentType := mime.TypeByExtension(path.Ext(name)); contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	u.sign(req, body, time.Now().UTC())
+
+	resp, err := u.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("uploading %s: %s: %s", name, resp.Status, bytes.TrimSpace(msg))
+	}
+	slog.Info("Uploaded", "file", name, "url", req.URL.String())
+	return nil
+}
+
+// sign Adds the AWS signature version 4 headers to the request
+func (u *uploader) sign(req *http.Request, body []byte, now time.Time) {
+	payloadHash := sha256Hex(body)
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if u.sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", u.sessionToken)
+	}
+
+	// Sign the host and every header of the request
+	signed := []string{"host"}
+	for h := range req.Header {
+		signed = append(signed, strings.ToLower(h))
+	}
+	sort.Strings(signed)
+	var headers strings.Builder
+	for _, h := range signed {
+		value := req.Header.Get(h)
+		if h == "host" {
+			value = req.URL.Host
+		}
+		headers.WriteString(h + ":" + strings.TrimSpace(value) + "\n")
+	}
+	signedHeaders := strings.Join(signed, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		headers.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := day + "/" + u.region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	key := hmacSHA256([]byte("AWS4"+u.secretKey), day)
+	for _, part := range []string{u.region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		u.accessKey, scope, signedHeaders, signature))
+}
+
+// escapeKey URI encodes an object key the way signature version 4 expects, everything but unreserved
+// characters and the slashes separating segments
+func escapeKey(key string) string {
+	var b strings.Builder
+	for _, c := range []byte(key) {
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9', strings.IndexByte("-._~/", c) >= 0:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func sha256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bytes"
+	"cncf-language-stats/stats"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// errInvalidFiles Returned by validate when a file violates its schema
+var errInvalidFiles = errors.New("the files are invalid")
+
+// runValidate Checks result files and repos.yaml files against their embedded JSON Schemas and the checks of
+// stats.Validate and stats.CheckRepos, returning an error when any file is invalid
+func runValidate(args []string) error {
+	fs := flag.NewFlagSet("validate", flag.ExitOnError)
+	schemaName := fs.String("schema", "auto", "Schema the files are checked against, result, repos or auto to tell them apart by their content")
+	printSchema := fs.String("print", "", "Print the result or repos schema instead of checking files")
+	writeSchemas := fs.String("write-schemas", "", "Generate the schemas from the types into this directory instead of checking files")
+	fileMode := fileModeFlag(0644)
+	fs.Var(&fileMode, "file-mode", "Octal permissions of the schemas written by -write-schemas")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: cncf-language-stats validate [flags] file...")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	switch {
+	case *writeSchemas != "":
+		names := make([]string, 0, len(schemas))
+		for name := range schemas {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			b, err := json.MarshalIndent(schemas[name](), "", "  ")
+			if err != nil {
+				return err
+			}
+			if err := writeFile(filepath.Join(*writeSchemas, name+".schema.json"), append(b, '\n'), os.FileMode(fileMode)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case *printSchema != "":
+		if _, ok := schemas[*printSchema]; !ok {
+			return fmt.Errorf("invalid -print %q, must be result or repos", *printSchema)
+		}
+		b, err := schemaFiles.ReadFile("schemas/" + *printSchema + ".schema.json")
+		if err != nil {
+			return err
+		}
+		_, err = os.Stdout.Write(b)
+		return err
+	}
+	if _, ok := schemas[*schemaName]; !ok && *schemaName != "auto" {
+		return fmt.Errorf("invalid -schema %q, must be result, repos or auto", *schemaName)
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	var invalid int
+	for _, file := range fs.Args() {
+		name, problems, err := validateFile(file, *schemaName)
+		if err != nil {
+			return err
+		}
+		if len(problems) == 0 {
+			fmt.Printf("%s: valid %s\n", file, name)
+			continue
+		}
+		invalid++
+		fmt.Printf("%s: %d problems with the %s schema:\n", file, len(problems), name)
+		for _, p := range problems {
+			fmt.Println(" ", p)
+		}
+	}
+	if invalid > 0 {
+		return fmt.Errorf("%w, %d of %d", errInvalidFiles, invalid, fs.NArg())
+	}
+	return nil
+}
+
+// validateFile The name of the schema the file was checked against and the problems found. A file is a result
+// when it is JSON with a topLanguage key, otherwise it is a repos.yaml, unless name selects the schema.
+func validateFile(file, name string) (string, []string, error) {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return "", nil, err
+	}
+	var value interface{}
+	if ext := strings.ToLower(filepath.Ext(file)); ext == ".yaml" || ext == ".yml" {
+		err = yaml.Unmarshal(b, &value)
+	} else {
+		dec := json.NewDecoder(bytes.NewReader(b))
+		dec.UseNumber()
+		err = dec.Decode(&value)
+	}
+	if err != nil {
+		return "", []string{fmt.Sprintf("cannot be parsed: %v", err)}, nil
+	}
+	if name == "auto" {
+		name = "repos"
+		if fields, ok := value.(map[string]interface{}); ok {
+			if _, ok := fields["topLanguage"]; ok {
+				name = "result"
+			}
+		}
+	}
+
+	schema, err := loadSchema(name)
+	if err != nil {
+		return "", nil, err
+	}
+	problems := validateSchema(schema, value, "$")
+	if name == "result" {
+		// Results of older schema versions may lack required fields, which migrate adds
+		if _, version, err := stats.MigrateResult(b); err == nil && version < stats.SchemaVersion && len(problems) > 0 {
+			problems = append(problems, fmt.Sprintf("schema version %d is older than version %d, upgrade the file with migrate", version, stats.SchemaVersion))
+		}
+	}
+	if len(problems) > 0 {
+		return name, problems, nil
+	}
+	switch name {
+	case "result":
+		var result stats.Result
+		if err := json.Unmarshal(b, &result); err != nil {
+			return name, []string{err.Error()}, nil
+		}
+		if err := stats.Validate(result); err != nil {
+			problems = append(problems, err.Error())
+		}
+	case "repos":
+		_, errs := stats.CheckRepos(b)
+		for _, err := range errs {
+			problems = append(problems, err.Error())
+		}
+	}
+	return name, problems, nil
+}
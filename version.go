@@ -0,0 +1,51 @@
+package main
+
+import (
+	"cncf-language-stats/stats"
+	"runtime/debug"
+	"strings"
+)
+
+// version, commit and buildDate Identify release builds, set with
+// -ldflags "-X main.version=v1.2.3 -X main.commit=$(git rev-parse HEAD) -X main.buildDate=$(date -u +%FT%TZ)"
+var version, commit, buildDate string
+
+// toolBuild The build set with -ldflags, completed from the module version and the git commit go build embeds.
+// The version is "devel" when neither knows it.
+func toolBuild() stats.BuildInfo {
+	build := stats.BuildInfo{Version: version, Commit: commit, BuildDate: buildDate}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		if build.Version == "" && info.Main.Version != "" && info.Main.Version != "(devel)" {
+			build.Version = info.Main.Version
+		}
+		settings := make(map[string]string)
+		for _, s := range info.Settings {
+			settings[s.Key] = s.Value
+		}
+		if build.Commit == "" && settings["vcs.revision"] != "" {
+			build.Commit = settings["vcs.revision"]
+			if settings["vcs.modified"] == "true" {
+				build.Commit += "-dirty"
+			}
+		}
+		if build.BuildDate == "" {
+			build.BuildDate = settings["vcs.time"]
+		}
+	}
+	if build.Version == "" {
+		build.Version = "devel"
+	}
+	return build
+}
+
+// formatBuild The build as printed by -version
+func formatBuild(build stats.BuildInfo) string {
+	s := []string{"cncf-language-stats " + build.Version}
+	if build.Commit != "" {
+		s = append(s, "commit "+build.Commit)
+	}
+	if build.BuildDate != "" {
+		s = append(s, "built "+build.BuildDate)
+	}
+	return strings.Join(s, ", ")
+}
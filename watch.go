@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"github.com/fsnotify/fsnotify"
+	"log/slog"
+	"path/filepath"
+	"time"
+)
+
+// watch Calls fn once and again every time one of files changes, until ctx is cancelled.
+// Changes arriving within debounce of each other result in a single call.
+func watch(ctx context.Context, files []string, debounce time.Duration, fn func(context.Context) error) error {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	// Watch the parent directories since editors often save by replacing the file,
+	// which silently ends a watch on the file itself
+	watched := make(map[string]bool)
+	for _, f := range files {
+		path, err := filepath.Abs(f)
+		if err != nil {
+			return err
+		}
+		if err := w.Add(filepath.Dir(path)); err != nil {
+			return err
+		}
+		watched[path] = true
+	}
+
+	runOnce := func() {
+		if err := fn(ctx); err != nil && ctx.Err() == nil {
+			slog.Error("Run failed", "error", err)
+		}
+	}
+	runOnce()
+
+	var rerun <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("Stopped watching")
+			return nil
+		case event := <-w.Events:
+			if watched[event.Name] && event.Op&(fsnotify.Write|fsnotify.Create) != 0 {
+				rerun = time.After(debounce)
+			}
+		case err := <-w.Errors:
+			slog.Error("Watching failed", "error", err)
+		case <-rerun:
+			rerun = nil
+			slog.Info("Change detected, re-running")
+			runOnce()
+		}
+	}
+}
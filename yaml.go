@@ -0,0 +1,44 @@
+package main
+
+import (
+	"bytes"
+	"cncf-language-stats/stats"
+	"gopkg.in/yaml.v3"
+)
+
+func init() {
+	registerResultFormat("yaml", marshalResultYAML)
+}
+
+// marshalResultYAML Encodes the result as YAML with the same keys and structure as its JSON. The JSON is
+// re-encoded rather than the Result marshalled directly, which would need yaml tags mirroring all json tags.
+func marshalResultYAML(repoGroup string, result stats.Result, keyNames map[string]string) ([]resultFile, error) {
+	b, err := marshalResult(result, keyNames)
+	if err != nil {
+		return nil, err
+	}
+	// JSON is valid YAML, decoding it keeps the order of the keys
+	var doc yaml.Node
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		return nil, err
+	}
+	blockStyle(&doc)
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return []resultFile{{suffix: ".yaml", data: buf.Bytes()}}, nil
+}
+
+// blockStyle Drops the flow style and quotes the decoded JSON has, the encoder quotes strings only where needed
+func blockStyle(n *yaml.Node) {
+	n.Style = 0
+	for _, c := range n.Content {
+		blockStyle(c)
+	}
+}